@@ -0,0 +1,388 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/exr"
+	"github.com/locatw/go-ray-tracer/image/imagefile"
+	"github.com/locatw/go-ray-tracer/image/tonemap"
+	. "github.com/locatw/go-ray-tracer/rendering"
+	"github.com/locatw/go-ray-tracer/scenefile"
+)
+
+// Exit codes of the command.
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "render", description: "render a scene file to an image", run: runRender},
+	{name: "validate", description: "validate a scene file", run: runValidate},
+	{name: "info", description: "print statistics of a scene file", run: runInfo},
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: go-ray-tracer <command> [flags] <scene file>\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(w, "\nRun 'go-ray-tracer <command> -h' for flags of each command.\n")
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stdout)
+		return exitSuccess
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command: %s\n\n", args[0])
+	printUsage(stderr)
+	return exitUsage
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: go-ray-tracer %s [flags] <scene file>\n\nflags:\n", name)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// Parse flags and return the scene file path. The second return value is an exit code
+// if the command must exit, or -1 to continue.
+func parseFlags(flags *flag.FlagSet, args []string, stderr io.Writer) (string, int) {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return "", exitSuccess
+		}
+		return "", exitUsage
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "exactly one scene file is required\n\n")
+		flags.Usage()
+		return "", exitUsage
+	}
+
+	return flags.Arg(0), -1
+}
+
+type renderOptions struct {
+	output              string
+	format              string
+	width               int
+	height              int
+	samplingCount       int
+	traceRecursionLimit int
+	workerCount         int
+	seed                int64
+	bitDepth            int
+	alpha               bool
+	passes              bool
+	exrCompression      string
+	exrPixelType        string
+	exrColorSpace       string
+	toneMapping         string
+	exposure            float64
+	whitePoint          float64
+	misHeuristic        string
+}
+
+var exrCompressions = map[string]exr.Compression{
+	"none": exr.NoCompression,
+	"rle":  exr.RleCompression,
+	"zips": exr.ZipsCompression,
+	"zip":  exr.ZipCompression,
+}
+
+var exrPixelTypes = map[string]exr.PixelType{
+	"half":  exr.Half,
+	"float": exr.Float,
+}
+
+func runRender(args []string, stdout io.Writer, stderr io.Writer) int {
+	options := renderOptions{}
+
+	flags := newFlagSet("render", stderr)
+	flags.StringVar(&options.output, "o", "image.ppm", "output image path")
+	flags.StringVar(&options.format, "format", "", "output image format (ppm, png, pfm, hdr, exr). inferred from the output path if omitted")
+	flags.IntVar(&options.width, "width", 0, "image width in pixels, overriding the scene file")
+	flags.IntVar(&options.height, "height", 0, "image height in pixels, overriding the scene file")
+	flags.IntVar(&options.samplingCount, "samples", 0, "samples per pixel, overriding the scene file")
+	flags.IntVar(&options.traceRecursionLimit, "depth", 0, "trace recursion limit, overriding the scene file")
+	flags.IntVar(&options.workerCount, "workers", 0, "number of rendering workers (default number of CPUs)")
+	flags.Int64Var(&options.seed, "seed", 0, "random seed for reproducible rendering")
+	flags.IntVar(&options.bitDepth, "bit-depth", 8, "bits per channel of png output (8 or 16)")
+	flags.BoolVar(&options.alpha, "alpha", false, "write coverage of primary rays as alpha channel of png and exr output")
+	flags.BoolVar(&options.passes, "passes", false, "write albedo, normal and depth layers in addition to beauty to exr output")
+	flags.StringVar(&options.exrCompression, "exr-compression", "zip", "compression of exr output (none, rle, zips, zip)")
+	flags.StringVar(&options.exrPixelType, "exr-pixel-type", "half", "pixel type of exr output (half, float)")
+	flags.StringVar(&options.exrColorSpace, "exr-color-space", "linear-srgb", "color space of exr output (linear-srgb, rec2020, acescg)")
+	flags.StringVar(&options.toneMapping, "tonemap", "",
+		"tone mapping operator ("+strings.Join(tonemap.OperatorNames, ", ")+"), overriding the scene file")
+	flags.Float64Var(&options.exposure, "exposure", 0.0, "exposure compensation in EV, overriding the scene file")
+	flags.Float64Var(&options.whitePoint, "white-point", 0.0, "white point of extended-reinhard and hable operators (requires -tonemap)")
+	flags.StringVar(&options.misHeuristic, "mis", "",
+		"heuristic of multiple importance sampling ("+strings.Join(MisHeuristicNames, ", ")+"), overriding the scene file")
+
+	path, code := parseFlags(flags, args, stderr)
+	if 0 <= code {
+		return code
+	}
+
+	format, err := resolveFormat(options.format, options.output)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitUsage
+	}
+
+	if options.bitDepth != 8 && options.bitDepth != 16 {
+		fmt.Fprintf(stderr, "-bit-depth must be 8 or 16\n")
+		return exitUsage
+	}
+
+	exrCompression, ok := exrCompressions[options.exrCompression]
+	if !ok {
+		fmt.Fprintf(stderr, "-exr-compression must be one of none, rle, zips and zip\n")
+		return exitUsage
+	}
+
+	exrPixelType, ok := exrPixelTypes[options.exrPixelType]
+	if !ok {
+		fmt.Fprintf(stderr, "-exr-pixel-type must be half or float\n")
+		return exitUsage
+	}
+
+	exrColorSpace, err := image.ParseColorSpace(options.exrColorSpace)
+	if err != nil || !exrColorSpace.IsLinear() {
+		fmt.Fprintf(stderr, "-exr-color-space must be one of linear-srgb, rec2020 and acescg\n")
+		return exitUsage
+	}
+
+	if options.passes && format != imagefile.Exr {
+		fmt.Fprintf(stderr, "-passes requires exr output\n")
+		return exitUsage
+	}
+
+	setFlags := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	if setFlags["white-point"] && !setFlags["tonemap"] {
+		fmt.Fprintf(stderr, "-white-point requires -tonemap\n")
+		return exitUsage
+	}
+	if setFlags["white-point"] && options.whitePoint <= 0.0 {
+		fmt.Fprintf(stderr, "-white-point must be positive\n")
+		return exitUsage
+	}
+
+	var operator tonemap.Operator
+	if setFlags["tonemap"] {
+		operator, err = tonemap.CreateOperator(options.toneMapping, options.whitePoint)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return exitUsage
+		}
+	}
+
+	var misHeuristic MisHeuristic
+	if setFlags["mis"] {
+		misHeuristic, err = ParseMisHeuristic(options.misHeuristic)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return exitUsage
+		}
+	}
+
+	positiveFlags := []struct {
+		name  string
+		value int
+	}{
+		{name: "width", value: options.width},
+		{name: "height", value: options.height},
+		{name: "samples", value: options.samplingCount},
+		{name: "depth", value: options.traceRecursionLimit},
+	}
+	for _, f := range positiveFlags {
+		if setFlags[f.name] && f.value <= 0 {
+			fmt.Fprintf(stderr, "-%s must be positive\n", f.name)
+			return exitUsage
+		}
+	}
+
+	scene, setting, err := scenefile.Load(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitFailure
+	}
+
+	if setFlags["width"] {
+		setting.Resolution.Width = options.width
+	}
+	if setFlags["height"] {
+		setting.Resolution.Height = options.height
+	}
+	if setFlags["samples"] {
+		setting.SamplingCount = options.samplingCount
+	}
+	if setFlags["depth"] {
+		setting.TraceRecursionLimit = options.traceRecursionLimit
+	}
+	if setFlags["workers"] {
+		setting.WorkerCount = options.workerCount
+	}
+	if setFlags["seed"] {
+		seed := options.seed
+		setting.RandomSeed = &seed
+	}
+	if operator != nil {
+		setting.ToneMapping.Operator = operator
+	}
+	if setFlags["exposure"] {
+		setting.ToneMapping.Exposure = options.exposure
+	}
+	if setFlags["mis"] {
+		setting.MisHeuristic = misHeuristic
+	}
+	setting.Log = stdout
+
+	rayTracer := RayTracer{Scene: scene, RenderingSetting: setting}
+
+	startTime := time.Now()
+	result := rayTracer.RenderPasses()
+	elapsed := time.Since(startTime)
+
+	fmt.Fprintf(stdout, "%0.3f [s]\n", elapsed.Seconds())
+
+	if format == imagefile.Exr {
+		layers := createExrLayers(result, options.alpha, options.passes, exrPixelType)
+		if err := exr.WriteExr(options.output, layers, exr.Options{Compression: exrCompression, ColorSpace: exrColorSpace}); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return exitFailure
+		}
+
+		return exitSuccess
+	}
+
+	img := result.Beauty
+
+	// the beauty is premultiplied by coverage, which formats other than exr take as straight alpha
+	if options.alpha {
+		img = image.Unpremultiply(img)
+	}
+
+	// high dynamic range formats keep linear radiance for post processing
+	if !format.IsHighDynamicRange() {
+		img = setting.ToneMapping.Apply(img)
+	}
+
+	imageOptions := imagefile.Options{BitDepth: options.bitDepth, Alpha: options.alpha}
+	if err := imagefile.WriteFormat(options.output, format, img, imageOptions); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitFailure
+	}
+
+	return exitSuccess
+}
+
+func createExrLayers(result RenderResult, alpha bool, passes bool, pixelType exr.PixelType) []exr.Layer {
+	channels := "RGB"
+	if alpha {
+		channels = "RGBA"
+	}
+
+	layers := []exr.Layer{{Image: result.Beauty, Channels: channels, PixelType: pixelType}}
+	if passes {
+		layers = append(layers,
+			exr.Layer{Name: "albedo", Image: result.Albedo, PixelType: pixelType},
+			exr.Layer{Name: "normal", Image: result.Normal, PixelType: pixelType},
+			// depth needs more precision than half regardless of pixelType
+			exr.Layer{Name: "depth", Image: result.Depth, Channels: "R", PixelType: exr.Float})
+	}
+
+	return layers
+}
+
+func resolveFormat(name string, output string) (imagefile.Format, error) {
+	if name == "" {
+		return imagefile.FormatFromPath(output)
+	}
+
+	return imagefile.ParseFormat(name)
+}
+
+func runValidate(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("validate", stderr)
+
+	path, code := parseFlags(flags, args, stderr)
+	if 0 <= code {
+		return code
+	}
+
+	if _, _, err := scenefile.Load(path); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(stdout, "%s: ok\n", path)
+	return exitSuccess
+}
+
+func runInfo(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("info", stderr)
+
+	path, code := parseFlags(flags, args, stderr)
+	if 0 <= code {
+		return code
+	}
+
+	scene, setting, err := scenefile.Load(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitFailure
+	}
+
+	statistics := scene.Statistics()
+
+	fmt.Fprintf(stdout, "Scene: %s\n", path)
+	fmt.Fprintf(stdout, "Camera: origin %v, direction %v\n", scene.Camera.Origin, scene.Camera.Direction)
+	fmt.Fprintf(stdout, "Resolution: %dx%d\n", setting.Resolution.Width, setting.Resolution.Height)
+	fmt.Fprintf(stdout, "Samples per pixel: %d\n", setting.SamplingCount)
+	fmt.Fprintf(stdout, "Trace recursion limit: %d\n", setting.TraceRecursionLimit)
+	fmt.Fprintf(stdout, "Shapes: %d (bounded %d, unbounded %d)\n",
+		statistics.ShapeCount, statistics.BoundedShapeCount, statistics.UnboundedShapeCount)
+	fmt.Fprintf(stdout, "Triangles: %d\n", statistics.TriangleCount)
+	fmt.Fprintf(stdout, "Lights: %d\n", statistics.LightCount)
+	if 0 < statistics.BoundedShapeCount {
+		fmt.Fprintf(stdout, "Bounds: %v - %v\n", statistics.Bounds.Min, statistics.Bounds.Max)
+	}
+
+	return exitSuccess
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "go-ray-tracer")
+	if err != nil {
+		t.Fatalf("cannot create temp directory for test")
+	}
+
+	defer os.RemoveAll(dir)
+
+	invalidScene := filepath.Join(dir, "invalid.json")
+	err = ioutil.WriteFile(invalidScene, []byte(`{"shapes": [{"type": "cube"}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validScene := filepath.Join("scenes", "cornell_box.json")
+	output := filepath.Join(dir, "image.png")
+	exrOutput := filepath.Join(dir, "image.exr")
+
+	patterns := []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expectedOut  string
+		expectedErr  string
+	}{
+		{name: "no command", args: []string{}, expectedCode: exitUsage, expectedErr: "usage:"},
+		{name: "unknown command", args: []string{"draw"}, expectedCode: exitUsage, expectedErr: "unknown command: draw"},
+		{name: "missing scene file", args: []string{"validate"}, expectedCode: exitUsage, expectedErr: "exactly one scene file is required"},
+		{name: "valid scene", args: []string{"validate", validScene}, expectedCode: exitSuccess, expectedOut: "ok"},
+		{name: "invalid scene", args: []string{"validate", invalidScene}, expectedCode: exitFailure, expectedErr: "shapes[0].type: unknown shape type"},
+		{name: "info", args: []string{"info", validScene}, expectedCode: exitSuccess, expectedOut: "Shapes: 8 (bounded 3, unbounded 5)"},
+		{name: "unsupported format", args: []string{"render", "-o", "image.bmp", validScene}, expectedCode: exitUsage, expectedErr: "unsupported image format: bmp"},
+		{name: "unsupported bit depth", args: []string{"render", "-o", "image.png", "-bit-depth", "12", validScene}, expectedCode: exitUsage, expectedErr: "-bit-depth must be 8 or 16"},
+		{name: "passes without exr", args: []string{"render", "-o", "image.png", "-passes", validScene}, expectedCode: exitUsage, expectedErr: "-passes requires exr output"},
+		{name: "unsupported exr compression", args: []string{"render", "-o", "image.exr", "-exr-compression", "piz", validScene}, expectedCode: exitUsage, expectedErr: "-exr-compression must be"},
+		{name: "non-linear exr color space", args: []string{"render", "-o", "image.exr", "-exr-color-space", "srgb", validScene}, expectedCode: exitUsage, expectedErr: "-exr-color-space must be"},
+		{name: "unknown tone mapping", args: []string{"render", "-tonemap", "filmic", validScene}, expectedCode: exitUsage, expectedErr: "unknown tone mapping operator: filmic"},
+		{name: "white point without tone mapping", args: []string{"render", "-white-point", "4", validScene}, expectedCode: exitUsage, expectedErr: "-white-point requires -tonemap"},
+		{name: "unknown MIS heuristic", args: []string{"render", "-mis", "maximum", validScene}, expectedCode: exitUsage, expectedErr: "unknown MIS heuristic: maximum"},
+		{name: "non-positive samples", args: []string{"render", "-samples", "0", validScene}, expectedCode: exitUsage, expectedErr: "-samples must be positive"},
+		{
+			name:         "render",
+			args:         []string{"render", "-width", "4", "-height", "4", "-samples", "1", "-seed", "1", "-tonemap", "aces", "-exposure", "-1", "-mis", "balance", "-o", output, validScene},
+			expectedCode: exitSuccess,
+			expectedOut:  "Workers: ",
+		},
+		{
+			name:         "render exr with passes",
+			args:         []string{"render", "-width", "4", "-height", "4", "-samples", "1", "-seed", "1", "-passes", "-exr-color-space", "acescg", "-o", exrOutput, validScene},
+			expectedCode: exitSuccess,
+			expectedOut:  "[s]",
+		},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			code := run(pattern.args, stdout, stderr)
+
+			if code != pattern.expectedCode {
+				t.Errorf("run(%v) must return %d, actual %d (stderr: %s)", pattern.args, pattern.expectedCode, code, stderr)
+			}
+			if !strings.Contains(stdout.String(), pattern.expectedOut) {
+				t.Errorf("run(%v) must write %q to stdout, actual %q", pattern.args, pattern.expectedOut, stdout)
+			}
+			if !strings.Contains(stderr.String(), pattern.expectedErr) {
+				t.Errorf("run(%v) must write %q to stderr, actual %q", pattern.args, pattern.expectedErr, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Axis-aligned bounding box.
+type AABB struct {
+	Min, Max Vector
+}
+
+// Shapes which have finite extent implement Bounded.
+// Unbounded shapes such as Plane don't implement it.
+type Bounded interface {
+	Bounds() AABB
+}
+
+// Create an empty box, which becomes the other box by union with it.
+func CreateEmptyAABB() AABB {
+	inf := math.Inf(1)
+
+	return AABB{
+		Min: Vector{X: inf, Y: inf, Z: inf},
+		Max: Vector{X: -inf, Y: -inf, Z: -inf},
+	}
+}
+
+func UnionAABB(box1 AABB, box2 AABB) AABB {
+	return AABB{
+		Min: Vector{
+			X: math.Min(box1.Min.X, box2.Min.X),
+			Y: math.Min(box1.Min.Y, box2.Min.Y),
+			Z: math.Min(box1.Min.Z, box2.Min.Z),
+		},
+		Max: Vector{
+			X: math.Max(box1.Max.X, box2.Max.X),
+			Y: math.Max(box1.Max.Y, box2.Max.Y),
+			Z: math.Max(box1.Max.Z, box2.Max.Z),
+		},
+	}
+}
+
+func UnionAABBPoint(box AABB, point Vector) AABB {
+	return UnionAABB(box, AABB{Min: point, Max: point})
+}
+
+func (box AABB) IsEmpty() bool {
+	return box.Max.X < box.Min.X || box.Max.Y < box.Min.Y || box.Max.Z < box.Min.Z
+}
+
+func (box AABB) IsFinite() bool {
+	return !box.IsEmpty() &&
+		!math.IsInf(box.Min.X, 0) && !math.IsInf(box.Min.Y, 0) && !math.IsInf(box.Min.Z, 0) &&
+		!math.IsInf(box.Max.X, 0) && !math.IsInf(box.Max.Y, 0) && !math.IsInf(box.Max.Z, 0)
+}
+
+func (box AABB) Contains(point Vector) bool {
+	return box.Min.X <= point.X && point.X <= box.Max.X &&
+		box.Min.Y <= point.Y && point.Y <= box.Max.Y &&
+		box.Min.Z <= point.Z && point.Z <= box.Max.Z
+}
+
+func (box AABB) Diagonal() Vector {
+	return Subtract(box.Max, box.Min)
+}
+
+func (box AABB) SurfaceArea() float64 {
+	if box.IsEmpty() {
+		return 0.0
+	}
+
+	d := box.Diagonal()
+
+	return 2.0 * (d.X*d.Y + d.Y*d.Z + d.Z*d.X)
+}
+
+func (box AABB) Centroid() Vector {
+	return Multiply(0.5, Add(box.Min, box.Max))
+}
+
+// Return the smallest sphere which encloses the box.
+func (box AABB) BoundingSphere() (center Vector, radius float64) {
+	center = box.Centroid()
+
+	if box.IsEmpty() {
+		return center, 0.0
+	}
+
+	halfDiagonal := Subtract(box.Max, center)
+
+	return center, halfDiagonal.Length()
+}
+
+// Return the axis along which the box is longest.
+func (box AABB) MaximumExtent() Axis {
+	d := box.Diagonal()
+
+	if d.Y < d.X && d.Z < d.X {
+		return XAxis
+	} else if d.Z < d.Y {
+		return YAxis
+	} else {
+		return ZAxis
+	}
+}
+
+// Test whether a ray hits the box within (0, tMax] by the slab method.
+// invDirection is the reciprocal of each component of the ray direction.
+func (box AABB) IntersectRay(ray Ray, invDirection Vector, tMax float64) bool {
+	t0 := 0.0
+	t1 := tMax
+
+	if !intersectSlab(box.Min.X, box.Max.X, ray.Origin.X, invDirection.X, &t0, &t1) {
+		return false
+	}
+	if !intersectSlab(box.Min.Y, box.Max.Y, ray.Origin.Y, invDirection.Y, &t0, &t1) {
+		return false
+	}
+
+	return intersectSlab(box.Min.Z, box.Max.Z, ray.Origin.Z, invDirection.Z, &t0, &t1)
+}
+
+func intersectSlab(min, max, origin, invDirection float64, t0, t1 *float64) bool {
+	tNear := (min - origin) * invDirection
+	tFar := (max - origin) * invDirection
+	if tFar < tNear {
+		tNear, tFar = tFar, tNear
+	}
+
+	// enlarge the far distance a little to be conservative against rounding errors
+	tFar *= 1.0 + 1.0e-9
+
+	// NaN, which appears when a ray lies on the slab boundary, is ignored by these comparisons.
+	if *t0 < tNear {
+		*t0 = tNear
+	}
+	if tFar < *t1 {
+		*t1 = tFar
+	}
+
+	return *t0 <= *t1
+}
+
+func CreateInverseDirection(direction Vector) Vector {
+	return Vector{X: 1.0 / direction.X, Y: 1.0 / direction.Y, Z: 1.0 / direction.Z}
+}
@@ -0,0 +1,129 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestUnionAABB(t *testing.T) {
+	box1 := AABB{Min: Vector{X: 0.0, Y: 0.0, Z: 0.0}, Max: Vector{X: 1.0, Y: 1.0, Z: 1.0}}
+	box2 := AABB{Min: Vector{X: -1.0, Y: 0.5, Z: 0.5}, Max: Vector{X: 0.5, Y: 2.0, Z: 0.5}}
+	expected := AABB{Min: Vector{X: -1.0, Y: 0.0, Z: 0.0}, Max: Vector{X: 1.0, Y: 2.0, Z: 1.0}}
+
+	result := UnionAABB(box1, box2)
+	if result != expected {
+		t.Errorf("UnionAABB(%v, %v) must return %v, actual is %v", box1, box2, expected, result)
+	}
+
+	result = UnionAABB(CreateEmptyAABB(), box1)
+	if result != box1 {
+		t.Errorf("UnionAABB(empty, %v) must return %v, actual is %v", box1, box1, result)
+	}
+}
+
+func TestAABBSurfaceArea(t *testing.T) {
+	patterns := []struct {
+		box      AABB
+		expected float64
+	}{
+		{box: AABB{Min: CreateZeroVector(), Max: Vector{X: 1.0, Y: 2.0, Z: 3.0}}, expected: 22.0},
+		{box: AABB{Min: CreateZeroVector(), Max: CreateZeroVector()}, expected: 0.0},
+		{box: CreateEmptyAABB(), expected: 0.0},
+	}
+
+	for _, pattern := range patterns {
+		result := pattern.box.SurfaceArea()
+		if result != pattern.expected {
+			t.Errorf("%v.SurfaceArea() must return %f, actual is %f", pattern.box, pattern.expected, result)
+		}
+	}
+}
+
+func TestAABBCentroid(t *testing.T) {
+	box := AABB{Min: Vector{X: -1.0, Y: 0.0, Z: 1.0}, Max: Vector{X: 1.0, Y: 2.0, Z: 5.0}}
+	expected := Vector{X: 0.0, Y: 1.0, Z: 3.0}
+
+	result := box.Centroid()
+	if result != expected {
+		t.Errorf("%v.Centroid() must return %v, actual is %v", box, expected, result)
+	}
+}
+
+func TestAABBContains(t *testing.T) {
+	box := AABB{Min: Vector{X: -1.0, Y: -1.0, Z: -1.0}, Max: Vector{X: 1.0, Y: 1.0, Z: 1.0}}
+	patterns := []struct {
+		point    Vector
+		expected bool
+	}{
+		{point: CreateZeroVector(), expected: true},
+		{point: Vector{X: 1.0, Y: -1.0, Z: 1.0}, expected: true},
+		{point: Vector{X: 1.1, Y: 0.0, Z: 0.0}, expected: false},
+	}
+
+	for _, pattern := range patterns {
+		result := box.Contains(pattern.point)
+		if result != pattern.expected {
+			t.Errorf("%v.Contains(%v) must return %t, actual is %t", box, pattern.point, pattern.expected, result)
+		}
+	}
+}
+
+func TestAABBBoundingSphere(t *testing.T) {
+	box := AABB{Min: Vector{X: 0.0, Y: 0.0, Z: 0.0}, Max: Vector{X: 2.0, Y: 4.0, Z: 4.0}}
+	expectedCenter := Vector{X: 1.0, Y: 2.0, Z: 2.0}
+	expectedRadius := 3.0
+
+	center, radius := box.BoundingSphere()
+	if center != expectedCenter || radius != expectedRadius {
+		t.Errorf("%v.BoundingSphere() must return (%v, %f), actual is (%v, %f)",
+			box, expectedCenter, expectedRadius, center, radius)
+	}
+}
+
+func TestAABBMaximumExtent(t *testing.T) {
+	patterns := []struct {
+		box      AABB
+		expected Axis
+	}{
+		{box: AABB{Min: CreateZeroVector(), Max: Vector{X: 3.0, Y: 1.0, Z: 2.0}}, expected: XAxis},
+		{box: AABB{Min: CreateZeroVector(), Max: Vector{X: 1.0, Y: 3.0, Z: 2.0}}, expected: YAxis},
+		{box: AABB{Min: CreateZeroVector(), Max: Vector{X: 1.0, Y: 2.0, Z: 3.0}}, expected: ZAxis},
+	}
+
+	for _, pattern := range patterns {
+		result := pattern.box.MaximumExtent()
+		if result != pattern.expected {
+			t.Errorf("%v.MaximumExtent() must return %d, actual is %d", pattern.box, pattern.expected, result)
+		}
+	}
+}
+
+func TestAABBIntersectRay(t *testing.T) {
+	box := AABB{Min: Vector{X: -1.0, Y: -1.0, Z: -1.0}, Max: Vector{X: 1.0, Y: 1.0, Z: 1.0}}
+
+	patterns := []struct {
+		ray      Ray
+		tMax     float64
+		expected bool
+	}{
+		{ray: CreateRay(Vector{X: 0.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0}), tMax: math.Inf(1), expected: true},
+		{ray: CreateRay(Vector{X: 0.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0}), tMax: 3.0, expected: false},
+		{ray: CreateRay(Vector{X: 0.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: 1.0}), tMax: math.Inf(1), expected: false},
+		{ray: CreateRay(Vector{X: 0.0, Y: 0.0, Z: 0.0}, Vector{X: 1.0, Y: 2.0, Z: 3.0}), tMax: math.Inf(1), expected: true},
+		{ray: CreateRay(Vector{X: 2.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0}), tMax: math.Inf(1), expected: false},
+		// a ray which lies on the boundary of the box
+		{ray: CreateRay(Vector{X: 1.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0}), tMax: math.Inf(1), expected: true},
+	}
+
+	for _, pattern := range patterns {
+		invDir := CreateInverseDirection(pattern.ray.Direction)
+
+		result := box.IntersectRay(pattern.ray, invDir, pattern.tMax)
+		if result != pattern.expected {
+			t.Errorf("%v.IntersectRay(%v, %v, %f) must return %t, actual is %t",
+				box, pattern.ray, invDir, pattern.tMax, pattern.expected, result)
+		}
+	}
+}
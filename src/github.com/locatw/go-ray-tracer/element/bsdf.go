@@ -0,0 +1,166 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Bidirectional scattering distribution function. Directions are unit vectors in the shading frame,
+// whose Z axis is the surface normal. outgoing points toward the viewer and incoming toward the light.
+type BSDF interface {
+	// Value of the BSDF. Delta components such as perfect mirrors are not included.
+	Evaluate(outgoing Vector, incoming Vector) Color
+	// Sample an incoming direction. It returns false if the outgoing direction is not scattered.
+	Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool)
+	// Probability density with which Sample returns incoming, with respect to solid angle.
+	// Delta components are not included.
+	Pdf(outgoing Vector, incoming Vector) float64
+	// Color of the surface for the albedo render pass.
+	Albedo() Color
+}
+
+type BSDFSample struct {
+	Incoming Vector
+	// Value of the BSDF multiplied by |cos| of Incoming and divided by Pdf.
+	Weight Color
+	// Probability density of Incoming with respect to solid angle, or the probability of a delta component.
+	Pdf float64
+	// Whether Incoming is chosen by a delta component, which cannot be sampled by lights.
+	IsDelta bool
+}
+
+func cosTheta(v Vector) float64 {
+	return v.Z
+}
+
+func sameHemisphere(v1 Vector, v2 Vector) bool {
+	return 0.0 < v1.Z*v2.Z
+}
+
+// Mirror direction of v about the normal of the shading frame.
+func reflectLocal(v Vector) Vector {
+	return Vector{X: -v.X, Y: -v.Y, Z: v.Z}
+}
+
+// Refract v into the other side of the surface, where eta is the ratio of the index of refraction
+// on the side of v to the one on the other side. It returns false on total internal reflection.
+func refractLocal(v Vector, eta float64) (Vector, bool) {
+	cosI := math.Abs(v.Z)
+	sin2T := eta * eta * math.Max(0.0, 1.0-cosI*cosI)
+	if 1.0 <= sin2T {
+		return Vector{}, false
+	}
+
+	cosT := math.Sqrt(1.0 - sin2T)
+	if 0.0 < v.Z {
+		cosT = -cosT
+	}
+
+	return Normalize(Vector{X: -eta * v.X, Y: -eta * v.Y, Z: cosT}), true
+}
+
+// Cosine weighted direction on the hemisphere of +Z.
+func sampleCosineHemisphere(rnd *rand.Rand) Vector {
+	r := math.Sqrt(rnd.Float64())
+	phi := 2.0 * math.Pi * rnd.Float64()
+	x := r * math.Cos(phi)
+	y := r * math.Sin(phi)
+
+	return Vector{X: x, Y: y, Z: math.Sqrt(math.Max(0.0, 1.0-x*x-y*y))}
+}
+
+func maxComponent(color Color) float64 {
+	return math.Max(float64(color.R), math.Max(float64(color.G), float64(color.B)))
+}
+
+// Sum of BSDFs, which samples one of them at a time.
+type MixtureBSDF struct {
+	Components []BSDF
+	// Probabilities to choose each component by Sample, which sum to 1.
+	Probabilities []float64
+}
+
+// Create a mixture which chooses components in proportion to weights. Components of zero weight are dropped.
+func CreateMixtureBSDF(components []BSDF, weights []float64) *MixtureBSDF {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	mixture := &MixtureBSDF{}
+	for i, component := range components {
+		if 0.0 < weights[i] {
+			mixture.Components = append(mixture.Components, component)
+			mixture.Probabilities = append(mixture.Probabilities, weights[i]/total)
+		}
+	}
+
+	return mixture
+}
+
+func (bsdf *MixtureBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	color := CreateDefaultColor(Black)
+	for _, component := range bsdf.Components {
+		color = AddColor(color, component.Evaluate(outgoing, incoming))
+	}
+
+	return color
+}
+
+func (bsdf *MixtureBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	if len(bsdf.Components) == 0 {
+		return BSDFSample{}, false
+	}
+
+	index := len(bsdf.Components) - 1
+	u := rnd.Float64()
+	for i, probability := range bsdf.Probabilities {
+		if u < probability {
+			index = i
+			break
+		}
+		u -= probability
+	}
+
+	sample, ok := bsdf.Components[index].Sample(rnd, outgoing)
+	if !ok {
+		return BSDFSample{}, false
+	}
+
+	if sample.IsDelta {
+		sample.Weight = DivideScalar(sample.Weight, bsdf.Probabilities[index])
+		sample.Pdf *= bsdf.Probabilities[index]
+		return sample, true
+	}
+
+	// the other components may also scatter into the sampled direction
+	sample.Pdf = bsdf.Pdf(outgoing, sample.Incoming)
+	if sample.Pdf <= 0.0 {
+		return BSDFSample{}, false
+	}
+	f := bsdf.Evaluate(outgoing, sample.Incoming)
+	sample.Weight = MultiplyScalar(math.Abs(cosTheta(sample.Incoming))/sample.Pdf, f)
+
+	return sample, true
+}
+
+func (bsdf *MixtureBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	pdf := 0.0
+	for i, component := range bsdf.Components {
+		pdf += bsdf.Probabilities[i] * component.Pdf(outgoing, incoming)
+	}
+
+	return pdf
+}
+
+func (bsdf *MixtureBSDF) Albedo() Color {
+	color := CreateDefaultColor(Black)
+	for _, component := range bsdf.Components {
+		color = AddColor(color, component.Albedo())
+	}
+
+	return color
+}
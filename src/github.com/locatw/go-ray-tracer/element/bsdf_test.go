@@ -0,0 +1,227 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Check that samples agree with Evaluate and Pdf, and return the average of their weights,
+// which is the directional albedo for outgoing.
+func checkBSDFSamples(t *testing.T, bsdf BSDF, outgoing Vector, count int) Color {
+	t.Helper()
+
+	rnd := rand.New(rand.NewSource(1))
+
+	sum := CreateDefaultColor(Black)
+	for i := 0; i < count; i++ {
+		sample, ok := bsdf.Sample(rnd, outgoing)
+		if !ok {
+			continue
+		}
+
+		sum = AddColor(sum, sample.Weight)
+
+		if sample.IsDelta {
+			continue
+		}
+
+		pdf := bsdf.Pdf(outgoing, sample.Incoming)
+		if 1e-6*pdf < math.Abs(pdf-sample.Pdf) {
+			t.Fatalf("Pdf(%v, %v) must return %f of the sample, actual %f", outgoing, sample.Incoming, sample.Pdf, pdf)
+		}
+
+		expected := MultiplyScalar(math.Abs(cosTheta(sample.Incoming))/pdf, bsdf.Evaluate(outgoing, sample.Incoming))
+		if 1e-4 < math.Abs(float64(expected.G-sample.Weight.G)) {
+			t.Fatalf("weight of a sample toward %v must be %v, actual %v", sample.Incoming, expected, sample.Weight)
+		}
+	}
+
+	return DivideScalar(sum, float64(count))
+}
+
+// Integrate Pdf over the sphere by stratified uniform sampling, which is the probability of non-delta samples.
+func integratePdf(bsdf BSDF, outgoing Vector, count int) float64 {
+	rnd := rand.New(rand.NewSource(2))
+	n := int(math.Sqrt(float64(count)))
+
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			z := 1.0 - 2.0*(float64(i)+rnd.Float64())/float64(n)
+			r := math.Sqrt(math.Max(0.0, 1.0-z*z))
+			phi := 2.0 * math.Pi * (float64(j) + rnd.Float64()) / float64(n)
+			incoming := Vector{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}
+
+			sum += bsdf.Pdf(outgoing, incoming) * 4.0 * math.Pi
+		}
+	}
+
+	return sum / float64(n*n)
+}
+
+func TestLambertianBSDF(t *testing.T) {
+	bsdf := &LambertianBSDF{Reflectance: Color{R: 0.5, G: 0.25, B: 1.0}}
+
+	for _, outgoing := range []Vector{Normalize(Vector{X: 0.3, Y: 0.1, Z: 1.0}), Normalize(Vector{X: 0.3, Y: 0.1, Z: -1.0})} {
+		albedo := checkBSDFSamples(t, bsdf, outgoing, 1000)
+		if albedo != bsdf.Reflectance {
+			t.Errorf("albedo for %v must be %v, actual %v", outgoing, bsdf.Reflectance, albedo)
+		}
+
+		if integral := integratePdf(bsdf, outgoing, 100000); 0.02 < math.Abs(integral-1.0) {
+			t.Errorf("Pdf for %v must integrate to 1, actual %f", outgoing, integral)
+		}
+	}
+
+	t.Run("When directions are on the opposite sides", func(t *testing.T) {
+		f := bsdf.Evaluate(Vector{X: 0.0, Y: 0.0, Z: 1.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0})
+
+		t.Run("it does not transmit light", func(t *testing.T) {
+			if !f.NearlyEqual(CreateDefaultColor(Black)) {
+				t.Errorf("got: %v, want: black", f)
+			}
+		})
+	})
+}
+
+func TestMirrorBSDF(t *testing.T) {
+	bsdf := &MirrorBSDF{Reflectance: Color{R: 0.9, G: 0.9, B: 0.9}}
+	outgoing := Normalize(Vector{X: 0.3, Y: -0.4, Z: 1.0})
+
+	sample, ok := bsdf.Sample(rand.New(rand.NewSource(1)), outgoing)
+
+	expected := Vector{X: -outgoing.X, Y: -outgoing.Y, Z: outgoing.Z}
+	if !ok || !sample.IsDelta || !sample.Incoming.NearlyEqual(expected) || sample.Weight != bsdf.Reflectance {
+		t.Errorf("Sample(%v) must return a delta sample toward %v, actual %v", outgoing, expected, sample)
+	}
+}
+
+func TestSchlickDielectricBSDF(t *testing.T) {
+	bsdf := &SchlickDielectricBSDF{Reflectance: CreateDefaultColor(White), IndexOfRefraction: 1.5}
+
+	t.Run("When light enters the object", func(t *testing.T) {
+		outgoing := Normalize(Vector{X: 1.0, Y: 0.0, Z: 1.0})
+		rnd := rand.New(rand.NewSource(1))
+
+		reflected := 0
+		count := 100000
+		for i := 0; i < count; i++ {
+			sample, _ := bsdf.Sample(rnd, outgoing)
+			if 0.0 < sample.Incoming.Z {
+				reflected++
+				continue
+			}
+
+			// Snell's law with sin of outgoing 1/sqrt(2)
+			sinT := math.Hypot(sample.Incoming.X, sample.Incoming.Y)
+			if 1e-9 < math.Abs(sinT-math.Sqrt(0.5)/1.5) || 0.0 < sample.Incoming.X {
+				t.Fatalf("refracted direction must follow Snell's law, actual %v", sample.Incoming)
+			}
+		}
+
+		t.Run("it reflects with Schlick's reflectance", func(t *testing.T) {
+			expected := schlickReflectance(math.Sqrt(0.5), 1.0, 1.5)
+			actual := float64(reflected) / float64(count)
+			if 0.005 < math.Abs(actual-expected) {
+				t.Errorf("got: %f, want: %f", actual, expected)
+			}
+		})
+	})
+
+	t.Run("When light is totally reflected inside the object", func(t *testing.T) {
+		outgoing := Normalize(Vector{X: 1.0, Y: 0.0, Z: -0.2})
+
+		sample, ok := bsdf.Sample(rand.New(rand.NewSource(1)), outgoing)
+
+		t.Run("it reflects all light", func(t *testing.T) {
+			if !ok || 0.0 <= sample.Incoming.Z || sample.Pdf != 1.0 {
+				t.Errorf("got: %v, want: reflection with probability 1", sample)
+			}
+		})
+	})
+}
+
+func TestMixtureBSDF(t *testing.T) {
+	diffuse := &LambertianBSDF{Reflectance: Color{R: 0.5, G: 0.5, B: 0.5}}
+	mirror := &MirrorBSDF{Reflectance: Color{R: 0.25, G: 0.25, B: 0.25}}
+	bsdf := CreateMixtureBSDF([]BSDF{diffuse, mirror}, []float64{0.5, 0.25})
+	outgoing := Normalize(Vector{X: 0.3, Y: 0.1, Z: 1.0})
+
+	albedo := checkBSDFSamples(t, bsdf, outgoing, 100000)
+
+	t.Run("it returns the sum of components in expectation", func(t *testing.T) {
+		if 0.01 < math.Abs(float64(albedo.G)-0.75) {
+			t.Errorf("got: %v, want: 0.75", albedo)
+		}
+	})
+
+	t.Run("it returns Pdf of non-delta components", func(t *testing.T) {
+		expected := 2.0 / 3.0
+		if integral := integratePdf(bsdf, outgoing, 100000); 0.02 < math.Abs(integral-expected) {
+			t.Errorf("got: %f, want: %f", integral, expected)
+		}
+	})
+}
+
+func TestMaterialGetBSDF(t *testing.T) {
+	ior := 1.5
+
+	diffuse := CreateDefaultMaterial()
+	diffuse.Diffuse = Color{R: 0.5, G: 0.5, B: 0.5}
+
+	glass := CreateDefaultMaterial()
+	glass.Specular = CreateDefaultColor(White)
+	glass.IndexOfRefraction = &ior
+
+	coated := diffuse
+	coated.Specular = Color{R: 0.25, G: 0.25, B: 0.25}
+
+	custom := CreateDefaultMaterial()
+	custom.BSDF = &MirrorBSDF{Reflectance: CreateDefaultColor(White)}
+
+	textured := diffuse
+	textured.Textures = &MaterialTextures{Diffuse: &ConstantTexture{Color: Color{R: 0.25, G: 0.5, B: 0.75}}}
+
+	roughness := CreateDefaultMaterial()
+	roughness.BSDF = &RoughConductorBSDF{Distribution: CreateGGXDistribution(0.2, 0.4)}
+	roughness.Textures = &MaterialTextures{Roughness: &ConstantTexture{Color: Color{R: 0.25, G: 0.25, B: 0.25}}}
+
+	patterns := []struct {
+		name     string
+		material Material
+		expected BSDF
+	}{
+		{name: "emitter", material: CreateDefaultMaterial(), expected: nil},
+		{name: "diffuse", material: diffuse, expected: &LambertianBSDF{Reflectance: diffuse.Diffuse}},
+		{name: "glass", material: glass, expected: &SchlickDielectricBSDF{Reflectance: glass.Specular, IndexOfRefraction: ior}},
+		{
+			name:     "coated",
+			material: coated,
+			expected: &MixtureBSDF{
+				Components:    []BSDF{&LambertianBSDF{Reflectance: diffuse.Diffuse}, &MirrorBSDF{Reflectance: coated.Specular}},
+				Probabilities: []float64{0.5 / 0.75, 0.25 / 0.75},
+			},
+		},
+		{name: "custom", material: custom, expected: custom.BSDF},
+		{name: "textured", material: textured, expected: &LambertianBSDF{Reflectance: Color{R: 0.25, G: 0.5, B: 0.75}}},
+		{
+			// the ratio of roughness is kept
+			name:     "textured roughness",
+			material: roughness,
+			expected: &RoughConductorBSDF{Distribution: CreateGGXDistribution(0.25, 0.5)},
+		},
+	}
+
+	for _, pattern := range patterns {
+		result := pattern.material.GetBSDF(TexturePoint{})
+
+		if !reflect.DeepEqual(result, pattern.expected) {
+			t.Errorf("GetBSDF() of %s must return %v, actual %v", pattern.name, pattern.expected, result)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package element
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+const (
+	bvhBinCount     = 12
+	bvhMaxLeafSize  = 4
+	bvhTraverseCost = 0.125
+)
+
+// Bounding volume hierarchy built with the surface area heuristic.
+type BVH struct {
+	shapes []Shape
+	nodes  []bvhNode
+}
+
+// Node of the flattened tree. A leaf refers shapes[offset:offset+count],
+// and an interior node has its first child just after it and its second child at secondChild.
+type bvhNode struct {
+	bounds      AABB
+	offset      int
+	count       int
+	secondChild int
+	axis        Axis
+}
+
+type bvhPrimitive struct {
+	shape    Shape
+	bounds   AABB
+	centroid Vector
+}
+
+type bvhBin struct {
+	bounds AABB
+	count  int
+}
+
+// Create a BVH over shapes. Every shape must implement Bounded.
+func CreateBVH(shapes []Shape) *BVH {
+	primitives := make([]bvhPrimitive, len(shapes))
+	for i, shape := range shapes {
+		bounded, ok := shape.(Bounded)
+		if !ok {
+			panic(fmt.Sprintf("cannot create BVH because shape %v does not implement Bounded.", shape))
+		}
+
+		bounds := bounded.Bounds()
+		primitives[i] = bvhPrimitive{shape: shape, bounds: bounds, centroid: bounds.Centroid()}
+	}
+
+	bvh := &BVH{
+		shapes: make([]Shape, 0, len(shapes)),
+		nodes:  make([]bvhNode, 0, 2*len(shapes)),
+	}
+
+	if 0 < len(primitives) {
+		bvh.build(primitives)
+	}
+
+	return bvh
+}
+
+// Split shapes into shapes which have finite bounds and the others.
+func SplitBoundedShapes(shapes []Shape) (bounded []Shape, unbounded []Shape) {
+	for _, shape := range shapes {
+		if IsBoundedShape(shape) {
+			bounded = append(bounded, shape)
+		} else {
+			unbounded = append(unbounded, shape)
+		}
+	}
+
+	return bounded, unbounded
+}
+
+// Test whether a shape implements Bounded and its bounds are finite.
+func IsBoundedShape(shape Shape) bool {
+	bounded, ok := shape.(Bounded)
+
+	return ok && bounded.Bounds().IsFinite()
+}
+
+func (bvh *BVH) Bounds() AABB {
+	if len(bvh.nodes) == 0 {
+		return CreateEmptyAABB()
+	}
+
+	return bvh.nodes[0].bounds
+}
+
+func (bvh *BVH) ShapeCount() int {
+	return len(bvh.shapes)
+}
+
+// Return the closest hit of the shapes in the hierarchy, or nil.
+func (bvh *BVH) Intersect(ray Ray) *HitInfo {
+	return bvh.intersect(ray, math.Inf(1), false)
+}
+
+// Whether any shape in the hierarchy is hit before a ray travels tMax.
+// It stops at the first hit found, so it is cheaper than Intersect for shadow rays.
+func (bvh *BVH) IntersectAny(ray Ray, tMax float64) bool {
+	return bvh.intersect(ray, tMax, true) != nil
+}
+
+// Return the closest hit before tMax, or the first hit found if anyHit is true.
+func (bvh *BVH) intersect(ray Ray, tMax float64, anyHit bool) *HitInfo {
+	if len(bvh.nodes) == 0 {
+		return nil
+	}
+
+	invDir := CreateInverseDirection(ray.Direction)
+	dirIsNegative := [3]bool{invDir.X < 0.0, invDir.Y < 0.0, invDir.Z < 0.0}
+
+	var minHitInfo *HitInfo
+
+	var stack [64]int
+	stackSize := 0
+	current := 0
+
+	for {
+		node := &bvh.nodes[current]
+
+		if node.bounds.IntersectRay(ray, invDir, tMax) {
+			if 0 < node.count {
+				for _, shape := range bvh.shapes[node.offset : node.offset+node.count] {
+					hitInfo := shape.Intersect(ray)
+
+					if hitInfo != nil && hitInfo.T < tMax {
+						if anyHit {
+							return hitInfo
+						}
+
+						minHitInfo = hitInfo
+						tMax = hitInfo.T
+					}
+				}
+			} else {
+				// visit the nearer child first
+				if dirIsNegative[node.axis] {
+					stack[stackSize] = current + 1
+					current = node.secondChild
+				} else {
+					stack[stackSize] = node.secondChild
+					current = current + 1
+				}
+				stackSize++
+				continue
+			}
+		}
+
+		if stackSize == 0 {
+			break
+		}
+
+		stackSize--
+		current = stack[stackSize]
+	}
+
+	return minHitInfo
+}
+
+func (bvh *BVH) build(primitives []bvhPrimitive) int {
+	bounds := CreateEmptyAABB()
+	centroidBounds := CreateEmptyAABB()
+	for _, primitive := range primitives {
+		bounds = UnionAABB(bounds, primitive.bounds)
+		centroidBounds = UnionAABBPoint(centroidBounds, primitive.centroid)
+	}
+
+	index := len(bvh.nodes)
+	bvh.nodes = append(bvh.nodes, bvhNode{bounds: bounds})
+
+	axis := centroidBounds.MaximumExtent()
+	min := centroidBounds.Min.Component(axis)
+	max := centroidBounds.Max.Component(axis)
+
+	if len(primitives) == 1 || max <= min {
+		bvh.makeLeaf(index, primitives)
+		return index
+	}
+
+	mid := splitBySurfaceAreaHeuristic(primitives, bounds, axis, min, max)
+	if mid < 0 {
+		bvh.makeLeaf(index, primitives)
+		return index
+	}
+
+	bvh.build(primitives[:mid])
+	secondChild := bvh.build(primitives[mid:])
+
+	bvh.nodes[index].axis = axis
+	bvh.nodes[index].secondChild = secondChild
+
+	return index
+}
+
+func (bvh *BVH) makeLeaf(index int, primitives []bvhPrimitive) {
+	bvh.nodes[index].offset = len(bvh.shapes)
+	bvh.nodes[index].count = len(primitives)
+
+	for _, primitive := range primitives {
+		bvh.shapes = append(bvh.shapes, primitive.shape)
+	}
+}
+
+// Partition primitives at the cheapest bin boundary and return the split position,
+// or -1 if making a leaf is cheaper.
+func splitBySurfaceAreaHeuristic(primitives []bvhPrimitive, bounds AABB, axis Axis, min float64, max float64) int {
+	binIndex := func(primitive *bvhPrimitive) int {
+		b := int(bvhBinCount * (primitive.centroid.Component(axis) - min) / (max - min))
+		if bvhBinCount <= b {
+			b = bvhBinCount - 1
+		}
+		return b
+	}
+
+	var bins [bvhBinCount]bvhBin
+	for i := range bins {
+		bins[i].bounds = CreateEmptyAABB()
+	}
+	for i := range primitives {
+		b := binIndex(&primitives[i])
+		bins[b].count++
+		bins[b].bounds = UnionAABB(bins[b].bounds, primitives[i].bounds)
+	}
+
+	// cost of splitting after each bin, computed by sweeping from both sides
+	var costs [bvhBinCount - 1]float64
+	leftBounds := CreateEmptyAABB()
+	leftCount := 0
+	for i := 0; i < bvhBinCount-1; i++ {
+		leftBounds = UnionAABB(leftBounds, bins[i].bounds)
+		leftCount += bins[i].count
+		costs[i] = float64(leftCount) * leftBounds.SurfaceArea()
+	}
+	rightBounds := CreateEmptyAABB()
+	rightCount := 0
+	for i := bvhBinCount - 1; 0 < i; i-- {
+		rightBounds = UnionAABB(rightBounds, bins[i].bounds)
+		rightCount += bins[i].count
+		costs[i-1] += float64(rightCount) * rightBounds.SurfaceArea()
+	}
+
+	minCostBin := 0
+	for i := 1; i < len(costs); i++ {
+		if costs[i] < costs[minCostBin] {
+			minCostBin = i
+		}
+	}
+
+	minCost := bvhTraverseCost + costs[minCostBin]/bounds.SurfaceArea()
+	leafCost := float64(len(primitives))
+	if len(primitives) <= bvhMaxLeafSize && leafCost <= minCost {
+		return -1
+	}
+
+	mid := 0
+	for i := range primitives {
+		if binIndex(&primitives[i]) <= minCostBin {
+			primitives[i], primitives[mid] = primitives[mid], primitives[i]
+			mid++
+		}
+	}
+
+	if mid == 0 || mid == len(primitives) {
+		return len(primitives) / 2
+	}
+
+	return mid
+}
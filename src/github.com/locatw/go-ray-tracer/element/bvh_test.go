@@ -0,0 +1,112 @@
+package element
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func createRandomVector(rnd *rand.Rand, scale float64) Vector {
+	return Vector{
+		X: scale * (rnd.Float64() - 0.5),
+		Y: scale * (rnd.Float64() - 0.5),
+		Z: scale * (rnd.Float64() - 0.5),
+	}
+}
+
+func TestBVHIntersect(t *testing.T) {
+	t.Run("When BVH has no shapes", func(t *testing.T) {
+		bvh := CreateBVH([]Shape{})
+		ray := CreateRay(CreateZeroVector(), CreateAxisVector(ZAxis))
+
+		t.Run("it returns nil", func(t *testing.T) {
+			hitInfo := bvh.Intersect(ray)
+
+			if hitInfo != nil {
+				t.Errorf("got: %v, want: %v", hitInfo, nil)
+			}
+		})
+	})
+
+	t.Run("When BVH has many shapes", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+
+		shapes := make([]Shape, 0)
+		for i := 0; i < 200; i++ {
+			shapes = append(shapes, &Sphere{Center: createRandomVector(rnd, 20.0), Radius: 0.1 + rnd.Float64()})
+		}
+		for i := 0; i < 200; i++ {
+			v0 := createRandomVector(rnd, 20.0)
+			shapes = append(shapes, &Triangle{
+				Vertices: [3]Vector{v0, Add(v0, createRandomVector(rnd, 3.0)), Add(v0, createRandomVector(rnd, 3.0))},
+			})
+		}
+
+		bvh := CreateBVH(shapes)
+
+		t.Run("it has all shapes", func(t *testing.T) {
+			if bvh.ShapeCount() != len(shapes) {
+				t.Errorf("got: %d, want: %d", bvh.ShapeCount(), len(shapes))
+			}
+		})
+
+		t.Run("it returns the same closest hit as testing all shapes", func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				ray := CreateRay(createRandomVector(rnd, 40.0), createRandomVector(rnd, 1.0))
+
+				var expected *HitInfo
+				for _, shape := range shapes {
+					hitInfo := shape.Intersect(ray)
+					if hitInfo != nil && (expected == nil || hitInfo.T < expected.T) {
+						expected = hitInfo
+					}
+				}
+
+				hitInfo := bvh.Intersect(ray)
+
+				if (hitInfo == nil) != (expected == nil) {
+					t.Fatalf("got: %v, want: %v for ray %v", hitInfo, expected, ray)
+				}
+				if hitInfo != nil && (hitInfo.Object != expected.Object || hitInfo.T != expected.T) {
+					t.Fatalf("got: %v, want: %v for ray %v", hitInfo, expected, ray)
+				}
+			}
+		})
+
+		t.Run("it tells whether any shape is hit before the distance as testing all shapes", func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				ray := CreateRay(createRandomVector(rnd, 40.0), createRandomVector(rnd, 1.0))
+				distance := 20.0 * rnd.Float64()
+
+				expected := false
+				for _, shape := range shapes {
+					hitInfo := shape.Intersect(ray)
+					if hitInfo != nil && hitInfo.T < distance {
+						expected = true
+					}
+				}
+
+				actual := bvh.IntersectAny(ray, distance)
+
+				if actual != expected {
+					t.Fatalf("got: %v, want: %v for ray %v and distance %v", actual, expected, ray, distance)
+				}
+			}
+		})
+	})
+}
+
+func TestSplitBoundedShapes(t *testing.T) {
+	sphere := &Sphere{Center: CreateZeroVector(), Radius: 1.0}
+	plane := &Plane{Center: CreateZeroVector(), Normal: CreateAxisVector(YAxis)}
+
+	bounded, unbounded := SplitBoundedShapes([]Shape{plane, sphere})
+
+	if len(bounded) != 1 || bounded[0] != sphere {
+		t.Errorf("SplitBoundedShapes must return %v as bounded shapes, actual is %v", sphere, bounded)
+	}
+	if len(unbounded) != 1 || unbounded[0] != plane {
+		t.Errorf("SplitBoundedShapes must return %v as unbounded shapes, actual is %v", plane, unbounded)
+	}
+}
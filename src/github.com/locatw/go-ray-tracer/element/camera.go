@@ -1,12 +1,25 @@
 package element
 
 import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/locatw/go-ray-tracer/transform"
+
 	. "github.com/locatw/go-ray-tracer/vector"
 )
 
 type Camera struct {
 	Origin, Direction, Up Vector
 	Fov                   float64
+	Lens                  Lens
+	// Perspective projection is used if it is nil.
+	Projection Projection
+	// Interval of time where rays are sampled. The shutter is instantaneous if they are the same.
+	ShutterOpen, ShutterClose float64
+	// Motion applied to the camera placed by Origin, Direction and Up. The camera is static if it is nil.
+	Motion *transform.AnimatedTransform
 }
 
 func CreateCamera(origin Vector, direction Vector, up Vector, fov float64) Camera {
@@ -14,9 +27,77 @@ func CreateCamera(origin Vector, direction Vector, up Vector, fov float64) Camer
 	correctedUp := Normalize(Cross(direction, Cross(up, direction)))
 
 	return Camera{
-		Origin:    origin,
-		Direction: correctedDir,
-		Up:        correctedUp,
-		Fov:       fov,
+		Origin:     origin,
+		Direction:  correctedDir,
+		Up:         correctedUp,
+		Fov:        fov,
+		Projection: PerspectiveProjection{},
+	}
+}
+
+// Create a camera which looks at bounds from the given direction, placed at the distance where the
+// bounding sphere of bounds fits in the narrower of the vertical fov and the horizontal one of the
+// film whose ratio of the width to the height is aspect. It returns an error if bounds is empty or
+// infinite.
+func CreateCameraToFrame(bounds AABB, direction Vector, up Vector, fov float64, aspect float64) (Camera, error) {
+	if bounds.IsEmpty() || !bounds.IsFinite() {
+		return Camera{}, errors.New("bounds to frame must be non-empty and finite")
+	}
+
+	horizontalFov := 2.0 * math.Atan(aspect*math.Tan(fov/2.0))
+
+	center, radius := bounds.BoundingSphere()
+	distance := radius / math.Sin(math.Min(fov, horizontalFov)/2.0)
+
+	origin := Subtract(center, Multiply(distance, Normalize(direction)))
+
+	return CreateCamera(origin, direction, up, fov), nil
+}
+
+// Create a ray through a point on the film in [0, 1] from the top left, whose ratio of the width to
+// the height is aspect. With a thin lens, the ray starts at a sampled point on the aperture and passes
+// the point in focus on the plane at FocusDistance. Rays which do not reach the plane are focused at
+// infinity. The time of the ray is sampled in the shutter interval. It returns false if the projection
+// does not see the point.
+func (camera *Camera) CreateRay(rnd *rand.Rand, filmX float64, filmY float64, aspect float64) (Ray, bool) {
+	projection := camera.Projection
+	if projection == nil {
+		projection = PerspectiveProjection{}
 	}
+
+	origin, direction, ok := projection.CreateLocalRay(camera, filmX, filmY, aspect)
+	if !ok {
+		return Ray{}, false
+	}
+
+	if !camera.Lens.IsPinhole() {
+		x, y := camera.Lens.SampleAperture(rnd)
+		lensPoint := Add(origin, Vector{X: x, Y: y, Z: 0.0})
+
+		if 0.0 < direction.Z {
+			focusPoint := Add(origin, Multiply(camera.Lens.FocusDistance/direction.Z, direction))
+			direction = Subtract(focusPoint, lensPoint)
+		}
+		origin = lensPoint
+	}
+
+	frame := Frame{Tangent: Normalize(Cross(camera.Direction, camera.Up)), Bitangent: camera.Up, Normal: camera.Direction}
+	worldOrigin := Add(camera.Origin, frame.ToWorld(origin))
+	worldDirection := frame.ToWorld(direction)
+
+	time := camera.ShutterOpen
+	if camera.ShutterOpen < camera.ShutterClose {
+		time += rnd.Float64() * (camera.ShutterClose - camera.ShutterOpen)
+	}
+
+	if camera.Motion != nil {
+		motion := camera.Motion.At(time)
+		worldOrigin = motion.TransformPoint(worldOrigin)
+		worldDirection = motion.TransformVector(worldDirection)
+	}
+
+	ray := CreateRay(worldOrigin, worldDirection)
+	ray.Time = time
+
+	return ray, true
 }
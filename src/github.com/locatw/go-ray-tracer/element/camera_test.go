@@ -1,8 +1,11 @@
 package element
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
+	"github.com/locatw/go-ray-tracer/transform"
 	. "github.com/locatw/go-ray-tracer/vector"
 )
 
@@ -36,3 +39,161 @@ func TestCreateCamera(t *testing.T) {
 			origin, dir, up, fov, fov, camera.Fov)
 	}
 }
+
+func TestCreateCameraToFrame(t *testing.T) {
+	bounds := AABB{Min: Vector{X: -1.0, Y: -1.0, Z: -1.0}, Max: Vector{X: 1.0, Y: 1.0, Z: 1.0}}
+	dir := Multiply(-1.0, CreateAxisVector(ZAxis))
+	up := CreateAxisVector(YAxis)
+	fov := math.Pi / 3.0
+	// the bounding sphere has radius sqrt(3), and sin(fov / 2) is 0.5
+	verticalOrigin := Vector{X: 0.0, Y: 0.0, Z: 2.0 * math.Sqrt(3.0)}
+	// tan of the half horizontal fov is 0.5 tan(fov / 2) = 1 / sqrt(12), whose sin is 1 / sqrt(13)
+	horizontalOrigin := Vector{X: 0.0, Y: 0.0, Z: math.Sqrt(3.0) * math.Sqrt(13.0)}
+
+	patterns := []struct {
+		aspect         float64
+		expectedOrigin Vector
+	}{
+		{aspect: 1.0, expectedOrigin: verticalOrigin},
+		{aspect: 2.0, expectedOrigin: verticalOrigin},
+		{aspect: 0.5, expectedOrigin: horizontalOrigin},
+	}
+
+	for _, pattern := range patterns {
+		camera, err := CreateCameraToFrame(bounds, dir, up, fov, pattern.aspect)
+
+		if err != nil {
+			t.Fatalf("CreateCameraToFrame(%v, %v, %v, %f, %f) must not return error, actual %v",
+				bounds, dir, up, fov, pattern.aspect, err)
+		}
+
+		if !camera.Origin.NearlyEqual(pattern.expectedOrigin) {
+			t.Errorf("CreateCameraToFrame(%v, %v, %v, %f, %f) must return camera which origin is %v, actual origin is %v",
+				bounds, dir, up, fov, pattern.aspect, pattern.expectedOrigin, camera.Origin)
+		}
+
+		if camera.Direction != dir {
+			t.Errorf("CreateCameraToFrame(%v, %v, %v, %f, %f) must return camera which direction is %v, actual direction is %v",
+				bounds, dir, up, fov, pattern.aspect, dir, camera.Direction)
+		}
+	}
+
+	invalidBounds := []AABB{
+		CreateEmptyAABB(),
+		{Min: CreateZeroVector(), Max: Vector{X: math.Inf(1), Y: 1.0, Z: 1.0}},
+	}
+
+	for _, bounds := range invalidBounds {
+		_, err := CreateCameraToFrame(bounds, dir, up, fov, 1.0)
+
+		if err == nil {
+			t.Errorf("CreateCameraToFrame(%v, %v, %v, %f, %f) must return error", bounds, dir, up, fov, 1.0)
+		}
+	}
+}
+
+func TestCameraCreateRay(t *testing.T) {
+	origin := Vector{X: 0.0, Y: 0.0, Z: 10.0}
+	fov := math.Pi / 2.0
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("When the camera is a pinhole", func(t *testing.T) {
+		camera := CreateCamera(origin, Vector{X: 0.0, Y: 0.0, Z: -1.0}, CreateAxisVector(YAxis), fov)
+
+		t.Run("it returns a ray from the origin through the film", func(t *testing.T) {
+			// tan(fov / 2) is 1, and the film is twice as wide as its height
+			ray, ok := camera.CreateRay(rnd, 0.75, 0.25, 2.0)
+
+			expected := Normalize(Vector{X: 1.0, Y: 0.5, Z: -1.0})
+			if !ok || ray.Origin != origin || !ray.Direction.NearlyEqual(expected) {
+				t.Errorf("got: %v, want: ray from %v toward %v", ray, origin, expected)
+			}
+		})
+	})
+
+	t.Run("When the camera has a thin lens", func(t *testing.T) {
+		camera := CreateCamera(origin, Vector{X: 0.0, Y: 0.0, Z: -1.0}, CreateAxisVector(YAxis), fov)
+		camera.Lens = CreateThinLens(0.5, 4.0)
+		// the point on the plane in focus, at distance 4 along the direction
+		focusPoint := Add(origin, Multiply(4.0, Vector{X: 0.2, Y: 0.1, Z: -1.0}))
+
+		t.Run("it returns rays from the aperture through the point in focus", func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				ray, _ := camera.CreateRay(rnd, 0.6, 0.45, 1.0)
+
+				offset := Subtract(ray.Origin, origin)
+				toFocus := Normalize(Subtract(focusPoint, ray.Origin))
+				if 0.5 < offset.Length() || offset.Z != 0.0 || !ray.Direction.NearlyEqual(toFocus) {
+					t.Fatalf("got: %v, want: ray from the aperture toward %v", ray, focusPoint)
+				}
+			}
+		})
+	})
+}
+
+func TestCameraCreateRayInShutter(t *testing.T) {
+	origin := Vector{X: 0.0, Y: 0.0, Z: 10.0}
+	camera := CreateCamera(origin, Vector{X: 0.0, Y: 0.0, Z: -1.0}, CreateAxisVector(YAxis), math.Pi/2.0)
+	camera.ShutterOpen = 1.0
+	camera.ShutterClose = 3.0
+	motion := transform.CreateAnimatedTransform(
+		transform.CreateIdentityTransform(), 1.0,
+		transform.CreateTranslation(Vector{X: 2.0, Y: 0.0, Z: 0.0}), 3.0)
+	camera.Motion = &motion
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("it returns rays at times in the shutter interval from the moved camera", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			ray, _ := camera.CreateRay(rnd, 0.5, 0.5, 1.0)
+
+			expected := Vector{X: ray.Time - 1.0, Y: 0.0, Z: 10.0}
+			if ray.Time < 1.0 || 3.0 < ray.Time || !ray.Origin.NearlyEqual(expected) {
+				t.Fatalf("got: %v, want: ray at time in [1, 3] from %v", ray, expected)
+			}
+		}
+	})
+}
+
+func TestLensSampleAperture(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("When the aperture has blades", func(t *testing.T) {
+		lens := Lens{ApertureRadius: 2.0, FocusDistance: 1.0, ApertureBlades: 6, AnamorphicRatio: 1.0}
+		// the apothem of the hexagon inscribed in the circle of radius 2
+		apothem := 2.0 * math.Cos(math.Pi/6.0)
+
+		t.Run("it returns points inside the regular polygon", func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				x, y := lens.SampleAperture(rnd)
+
+				for j := 0; j < 6; j++ {
+					// normal of the edge between vertices at pi/2 + j*pi/3 and pi/2 + (j+1)*pi/3
+					angle := math.Pi/2.0 + (float64(j)+0.5)*math.Pi/3.0
+					if apothem+1e-9 < x*math.Cos(angle)+y*math.Sin(angle) {
+						t.Fatalf("got: (%f, %f), want: point inside the hexagon", x, y)
+					}
+				}
+			}
+		})
+	})
+
+	t.Run("When the aperture is anamorphic", func(t *testing.T) {
+		lens := Lens{ApertureRadius: 1.0, FocusDistance: 1.0, AnamorphicRatio: 2.0}
+
+		t.Run("it returns points in the ellipse stretched horizontally", func(t *testing.T) {
+			maxX := 0.0
+			for i := 0; i < 1000; i++ {
+				x, y := lens.SampleAperture(rnd)
+
+				if 1.0+1e-9 < (x/2.0)*(x/2.0)+y*y {
+					t.Fatalf("got: (%f, %f), want: point inside the ellipse", x, y)
+				}
+				maxX = math.Max(maxX, math.Abs(x))
+			}
+
+			if maxX <= 1.0 {
+				t.Errorf("got: %f, want: points wider than the height", maxX)
+			}
+		})
+	})
+}
@@ -0,0 +1,114 @@
+package element
+
+import "sort"
+
+// Piecewise-constant distribution over [0, 1] whose density on each of len(Function) intervals
+// is proportional to the function value.
+type Distribution1D struct {
+	Function []float64
+	// Cumulative distribution at the boundaries of intervals, whose length is len(Function) + 1.
+	CDF []float64
+	// Integral of Function over [0, 1].
+	Integral float64
+}
+
+// Create a distribution of non-negative values. If all values are zero, it becomes uniform.
+func CreateDistribution1D(function []float64) *Distribution1D {
+	n := len(function)
+	cdf := make([]float64, n+1)
+
+	for i, value := range function {
+		cdf[i+1] = cdf[i] + value/float64(n)
+	}
+
+	integral := cdf[n]
+	for i := 1; i <= n; i++ {
+		if integral == 0.0 {
+			cdf[i] = float64(i) / float64(n)
+		} else {
+			cdf[i] /= integral
+		}
+	}
+
+	return &Distribution1D{Function: function, CDF: cdf, Integral: integral}
+}
+
+func (distribution *Distribution1D) Count() int {
+	return len(distribution.Function)
+}
+
+// Map a uniform random number in [0, 1) to a value in [0, 1), and return it with its density and interval.
+func (distribution *Distribution1D) Sample(u float64) (float64, float64, int) {
+	n := distribution.Count()
+
+	// the last interval whose cumulative distribution at the start is not greater than u
+	index := sort.Search(n, func(i int) bool { return u < distribution.CDF[i+1] })
+	if n <= index {
+		index = n - 1
+	}
+
+	width := distribution.CDF[index+1] - distribution.CDF[index]
+	offset := u - distribution.CDF[index]
+	if 0.0 < width {
+		offset /= width
+	}
+
+	return (float64(index) + offset) / float64(n), distribution.Pdf(index), index
+}
+
+// Density of values in an interval.
+func (distribution *Distribution1D) Pdf(index int) float64 {
+	if distribution.Integral == 0.0 {
+		return 1.0
+	}
+
+	return distribution.Function[index] / distribution.Integral
+}
+
+// Piecewise-constant distribution over [0, 1]^2, which samples a row by the marginal distribution
+// and then a column by the conditional distribution of the row.
+type Distribution2D struct {
+	conditionals []*Distribution1D
+	marginal     *Distribution1D
+}
+
+// Create a distribution of values arranged in rows of width.
+func CreateDistribution2D(function []float64, width int, height int) *Distribution2D {
+	conditionals := make([]*Distribution1D, height)
+	integrals := make([]float64, height)
+
+	for y := 0; y < height; y++ {
+		conditionals[y] = CreateDistribution1D(function[y*width : (y+1)*width])
+		integrals[y] = conditionals[y].Integral
+	}
+
+	return &Distribution2D{conditionals: conditionals, marginal: CreateDistribution1D(integrals)}
+}
+
+// Map uniform random numbers to a point in [0, 1)^2, and return it with its density.
+func (distribution *Distribution2D) Sample(u1 float64, u2 float64) (float64, float64, float64) {
+	y, pdfY, row := distribution.marginal.Sample(u2)
+	x, pdfX, _ := distribution.conditionals[row].Sample(u1)
+
+	return x, y, pdfX * pdfY
+}
+
+// Density of a point in [0, 1]^2.
+func (distribution *Distribution2D) Pdf(x float64, y float64) float64 {
+	row := clampIndex(int(y*float64(distribution.marginal.Count())), distribution.marginal.Count())
+	conditional := distribution.conditionals[row]
+	column := clampIndex(int(x*float64(conditional.Count())), conditional.Count())
+
+	return conditional.Pdf(column) * distribution.marginal.Pdf(row)
+}
+
+func clampIndex(i int, n int) int {
+	if i < 0 {
+		return 0
+	}
+	if n <= i {
+		return n - 1
+	}
+
+	return i
+}
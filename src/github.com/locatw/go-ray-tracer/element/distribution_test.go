@@ -0,0 +1,57 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDistribution1DSample(t *testing.T) {
+	distribution := CreateDistribution1D([]float64{1.0, 0.0, 3.0})
+
+	patterns := []struct {
+		u             float64
+		expected      float64
+		expectedPdf   float64
+		expectedIndex int
+	}{
+		{u: 0.0, expected: 0.0, expectedPdf: 0.75, expectedIndex: 0},
+		{u: 0.125, expected: 1.0 / 6.0, expectedPdf: 0.75, expectedIndex: 0},
+		// the interval of zero is skipped
+		{u: 0.25, expected: 2.0 / 3.0, expectedPdf: 2.25, expectedIndex: 2},
+		{u: 0.625, expected: 5.0 / 6.0, expectedPdf: 2.25, expectedIndex: 2},
+	}
+
+	for _, pattern := range patterns {
+		x, pdf, index := distribution.Sample(pattern.u)
+
+		if 1e-9 < math.Abs(x-pattern.expected) || 1e-9 < math.Abs(pdf-pattern.expectedPdf) || index != pattern.expectedIndex {
+			t.Errorf("Sample(%f) must return (%f, %f, %d), actual (%f, %f, %d)",
+				pattern.u, pattern.expected, pattern.expectedPdf, pattern.expectedIndex, x, pdf, index)
+		}
+	}
+}
+
+func TestDistribution2D(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	distribution := CreateDistribution2D([]float64{1.0, 2.0, 0.0, 0.5, 0.0, 4.0}, 3, 2)
+
+	counts := make([]int, 6)
+	count := 100000
+	for i := 0; i < count; i++ {
+		x, y, pdf := distribution.Sample(rnd.Float64(), rnd.Float64())
+		if expected := distribution.Pdf(x, y); 1e-9 < math.Abs(pdf-expected) {
+			t.Fatalf("Sample must return pdf %f given by Pdf(%f, %f), actual %f", expected, x, y, pdf)
+		}
+
+		counts[int(y*2.0)*3+int(x*3.0)]++
+	}
+
+	// each cell is chosen in proportion to its value
+	for i, value := range []float64{1.0, 2.0, 0.0, 0.5, 0.0, 4.0} {
+		expected := value / 7.5
+		if actual := float64(counts[i]) / float64(count); 0.01 < math.Abs(actual-expected) {
+			t.Errorf("cell %d must be sampled with probability %f, actual %f", i, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Light at infinity which is seen by rays escaping from the scene.
+type InfiniteLight interface {
+	Light
+
+	// Radiance arriving from direction, which is a unit vector toward the light.
+	Radiance(direction Vector) Color
+	// Solid angle density that Sample returns direction.
+	Pdf(direction Vector) float64
+}
+
+// Light surrounding the scene given by an equirectangular image, whose center is seen toward -Z and
+// top row is toward +Y. Directions are sampled in proportion to the luminance of pixels, taking the
+// maximum over the pixels which are interpolated in the region of each pixel.
+type EnvironmentLight struct {
+	// Image in linear sRGB.
+	Image Image
+	// Angle in radians to rotate the image around the Y axis, counterclockwise seen from above.
+	Rotation float64
+	// Scale of the radiance of the image.
+	Intensity Color
+
+	distribution *Distribution2D
+}
+
+func CreateEnvironmentLight(img Image, rotation float64, intensity Color) *EnvironmentLight {
+	img = ConvertImage(img, LinearSRGB)
+
+	// pixels near the poles cover smaller solid angles
+	weights := make([]float64, img.Width*img.Height)
+	for y := 0; y < img.Height; y++ {
+		sinTheta := math.Sin(math.Pi * (float64(y) + 0.5) / float64(img.Height))
+
+		for x := 0; x < img.Width; x++ {
+			weights[y*img.Width+x] = maxNeighborLuminance(img, intensity, x, y) * sinTheta
+		}
+	}
+
+	return &EnvironmentLight{
+		Image:        img,
+		Rotation:     rotation,
+		Intensity:    intensity,
+		distribution: CreateDistribution2D(weights, img.Width, img.Height),
+	}
+}
+
+// Return the maximum luminance of a pixel and its neighbors, which Radiance interpolates in the
+// region of the pixel, so that the pdf is positive wherever the radiance is.
+func maxNeighborLuminance(img Image, intensity Color, x int, y int) float64 {
+	max := 0.0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			i := wrapIndex(y+dy, img.Height, ClampWrap)*img.Width + wrapIndex(x+dx, img.Width, RepeatWrap)
+			max = math.Max(max, Luminance(MultiplyColor(intensity, img.Pixels[i].Color)))
+		}
+	}
+
+	return max
+}
+
+// Return the coordinates of a direction in the image and the sine of its polar angle.
+func (light *EnvironmentLight) toImage(direction Vector) (float64, float64, float64) {
+	local := rotateAroundY(direction, -light.Rotation)
+
+	cosTheta := math.Max(-1.0, math.Min(1.0, local.Y))
+	u := 0.5 + math.Atan2(local.X, -local.Z)/(2.0*math.Pi)
+	v := math.Acos(cosTheta) / math.Pi
+
+	return u, v, math.Sqrt(math.Max(0.0, 1.0-cosTheta*cosTheta))
+}
+
+// Return the direction of coordinates in the image and the sine of its polar angle.
+func (light *EnvironmentLight) fromImage(u float64, v float64) (Vector, float64) {
+	local, sinTheta := equirectangularDirection(u, v)
+
+	return rotateAroundY(local, light.Rotation), sinTheta
+}
+
+// Return the direction of coordinates in an equirectangular image without rotation, and the sine of its polar angle.
+func equirectangularDirection(u float64, v float64) (Vector, float64) {
+	phi := 2.0 * math.Pi * (u - 0.5)
+	theta := math.Pi * v
+	sinTheta := math.Sin(theta)
+
+	return Vector{X: sinTheta * math.Sin(phi), Y: math.Cos(theta), Z: -sinTheta * math.Cos(phi)}, sinTheta
+}
+
+func rotateAroundY(v Vector, angle float64) Vector {
+	c := math.Cos(angle)
+	s := math.Sin(angle)
+
+	return Vector{X: c*v.X + s*v.Z, Y: v.Y, Z: -s*v.X + c*v.Z}
+}
+
+// Bilinearly interpolated radiance, which wraps around horizontally.
+func (light *EnvironmentLight) Radiance(direction Vector) Color {
+	img := &light.Image
+	if img.Width == 0 || img.Height == 0 {
+		return CreateDefaultColor(Black)
+	}
+
+	u, v, _ := light.toImage(direction)
+
+	x := u*float64(img.Width) - 0.5
+	y := v*float64(img.Height) - 0.5
+	x0 := math.Floor(x)
+	y0 := math.Floor(y)
+	dx := x - x0
+	dy := y - y0
+
+	pixel := func(x int, y int) Color {
+		return img.Pixels[wrapIndex(y, img.Height, ClampWrap)*img.Width+wrapIndex(x, img.Width, RepeatWrap)].Color
+	}
+
+	top := LerpColor(dx, pixel(int(x0), int(y0)), pixel(int(x0)+1, int(y0)))
+	bottom := LerpColor(dx, pixel(int(x0), int(y0)+1), pixel(int(x0)+1, int(y0)+1))
+
+	return MultiplyColor(light.Intensity, LerpColor(dy, top, bottom))
+}
+
+func (light *EnvironmentLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	if light.Image.Width == 0 || light.Image.Height == 0 {
+		return LightSample{}, false
+	}
+
+	u, v, imagePdf := light.distribution.Sample(rnd.Float64(), rnd.Float64())
+	if imagePdf == 0.0 {
+		return LightSample{}, false
+	}
+
+	direction, sinTheta := light.fromImage(u, v)
+	if sinTheta == 0.0 {
+		return LightSample{}, false
+	}
+
+	return LightSample{
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Radiance:  light.Radiance(direction),
+		Pdf:       imagePdf / (2.0 * math.Pi * math.Pi * sinTheta),
+		IsDelta:   false,
+	}, true
+}
+
+func (light *EnvironmentLight) Pdf(direction Vector) float64 {
+	if light.Image.Width == 0 || light.Image.Height == 0 {
+		return 0.0
+	}
+
+	u, v, sinTheta := light.toImage(direction)
+	if sinTheta == 0.0 {
+		return 0.0
+	}
+
+	return light.distribution.Pdf(u, v) / (2.0 * math.Pi * math.Pi * sinTheta)
+}
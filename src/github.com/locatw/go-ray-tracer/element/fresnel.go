@@ -0,0 +1,81 @@
+package element
+
+import (
+	"math"
+	"math/cmplx"
+
+	. "github.com/locatw/go-ray-tracer/image"
+)
+
+// Fresnel reflectance of unpolarized light at a dielectric boundary. eta is the index of refraction
+// on the other side relative to the side of the normal, and cosTheta is negative on the other side.
+func FresnelDielectric(cosTheta float64, eta float64) float64 {
+	cosTheta = math.Max(-1.0, math.Min(1.0, cosTheta))
+	if cosTheta < 0.0 {
+		eta = 1.0 / eta
+		cosTheta = -cosTheta
+	}
+
+	sin2T := (1.0 - cosTheta*cosTheta) / (eta * eta)
+	if 1.0 <= sin2T {
+		return 1.0
+	}
+	cosT := math.Sqrt(1.0 - sin2T)
+
+	parallel := (eta*cosTheta - cosT) / (eta*cosTheta + cosT)
+	perpendicular := (cosTheta - eta*cosT) / (cosTheta + eta*cosT)
+
+	return (parallel*parallel + perpendicular*perpendicular) / 2.0
+}
+
+// Fresnel reflectance of unpolarized light on a conductor whose complex index of refraction is eta + ik.
+func FresnelConductor(cosTheta float64, eta Color, k Color) Color {
+	return Color{
+		R: float32(fresnelComplex(cosTheta, complex(float64(eta.R), float64(k.R)))),
+		G: float32(fresnelComplex(cosTheta, complex(float64(eta.G), float64(k.G)))),
+		B: float32(fresnelComplex(cosTheta, complex(float64(eta.B), float64(k.B)))),
+	}
+}
+
+func fresnelComplex(cosTheta float64, eta complex128) float64 {
+	cosTheta = math.Max(0.0, math.Min(1.0, cosTheta))
+
+	cosI := complex(cosTheta, 0.0)
+	sin2T := complex(1.0-cosTheta*cosTheta, 0.0) / (eta * eta)
+	cosT := cmplx.Sqrt(1.0 - sin2T)
+
+	parallel := (eta*cosI - cosT) / (eta*cosI + cosT)
+	perpendicular := (cosI - eta*cosT) / (cosI + eta*cosT)
+
+	return (norm(parallel) + norm(perpendicular)) / 2.0
+}
+
+func norm(c complex128) float64 {
+	return real(c)*real(c) + imag(c)*imag(c)
+}
+
+// Complex index of refraction of a conductor for red, green and blue.
+type ComplexIOR struct {
+	Eta Color
+	K   Color
+}
+
+// Measured indices of refraction of metals, sampled at about 650, 550 and 450 nm.
+var ConductorPresets = map[string]ComplexIOR{
+	"gold": {
+		Eta: Color{R: 0.143, G: 0.374, B: 1.442},
+		K:   Color{R: 3.983, G: 2.385, B: 1.603},
+	},
+	"copper": {
+		Eta: Color{R: 0.200, G: 0.924, B: 1.102},
+		K:   Color{R: 3.912, G: 2.452, B: 2.142},
+	},
+	"aluminium": {
+		Eta: Color{R: 1.657, G: 0.880, B: 0.521},
+		K:   Color{R: 9.224, G: 6.270, B: 4.837},
+	},
+	"silver": {
+		Eta: Color{R: 0.155, G: 0.117, B: 0.138},
+		K:   Color{R: 4.828, G: 3.122, B: 2.147},
+	},
+}
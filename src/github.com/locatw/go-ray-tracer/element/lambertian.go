@@ -0,0 +1,49 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Ideal diffuse reflection, which reflects on the same side of the surface as the viewer.
+type LambertianBSDF struct {
+	Reflectance Color
+}
+
+func (bsdf *LambertianBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	if !sameHemisphere(outgoing, incoming) {
+		return CreateDefaultColor(Black)
+	}
+
+	return MultiplyScalar(1.0/math.Pi, bsdf.Reflectance)
+}
+
+func (bsdf *LambertianBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	incoming := sampleCosineHemisphere(rnd)
+	if cosTheta(outgoing) < 0.0 {
+		incoming.Z = -incoming.Z
+	}
+
+	pdf := bsdf.Pdf(outgoing, incoming)
+	if pdf <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	// cosine weighted sampling cancels the cosine and 1/pi
+	return BSDFSample{Incoming: incoming, Weight: bsdf.Reflectance, Pdf: pdf}, true
+}
+
+func (bsdf *LambertianBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	if !sameHemisphere(outgoing, incoming) {
+		return 0.0
+	}
+
+	return math.Abs(cosTheta(incoming)) / math.Pi
+}
+
+func (bsdf *LambertianBSDF) Albedo() Color {
+	return bsdf.Reflectance
+}
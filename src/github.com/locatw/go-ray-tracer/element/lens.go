@@ -0,0 +1,83 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Thin lens of a camera. The zero value is a pinhole.
+type Lens struct {
+	// Radius of the aperture, which is the half height of an anamorphic aperture.
+	ApertureRadius float64
+	// Distance from the lens to the plane in focus along the direction of the camera.
+	FocusDistance float64
+	// Number of blades, which make the aperture a regular polygon. The aperture is a disk if it is less than 3.
+	ApertureBlades int
+	// Ratio of the width of the aperture to its height, which stretches bokeh. 0 is regarded as 1.
+	AnamorphicRatio float64
+}
+
+func CreateThinLens(apertureRadius float64, focusDistance float64) Lens {
+	return Lens{ApertureRadius: apertureRadius, FocusDistance: focusDistance, AnamorphicRatio: 1.0}
+}
+
+func (lens *Lens) IsPinhole() bool {
+	return lens.ApertureRadius <= 0.0
+}
+
+// Sample a point on the aperture uniformly, in coordinates of the right and up of the camera.
+func (lens *Lens) SampleAperture(rnd *rand.Rand) (float64, float64) {
+	var x, y float64
+	if lens.ApertureBlades < 3 {
+		x, y = concentricSampleDisk(rnd.Float64(), rnd.Float64())
+	} else {
+		x, y = uniformSamplePolygon(lens.ApertureBlades, rnd.Float64(), rnd.Float64(), rnd.Float64())
+	}
+
+	ratio := lens.AnamorphicRatio
+	if ratio == 0.0 {
+		ratio = 1.0
+	}
+
+	return lens.ApertureRadius * ratio * x, lens.ApertureRadius * y
+}
+
+// Map a unit square to a unit disk, keeping areas uniform (Shirley and Chiu, 1997).
+func concentricSampleDisk(u1 float64, u2 float64) (float64, float64) {
+	a := 2.0*u1 - 1.0
+	b := 2.0*u2 - 1.0
+	if a == 0.0 && b == 0.0 {
+		return 0.0, 0.0
+	}
+
+	var r, theta float64
+	if math.Abs(a) > math.Abs(b) {
+		r = a
+		theta = (math.Pi / 4.0) * (b / a)
+	} else {
+		r = b
+		theta = math.Pi/2.0 - (math.Pi/4.0)*(a/b)
+	}
+
+	return r * math.Cos(theta), r * math.Sin(theta)
+}
+
+// Sample a point uniformly in a regular polygon inscribed in the unit circle, with a vertex at the top.
+// The polygon is split into triangles at its center, which have the same area.
+func uniformSamplePolygon(sides int, u1 float64, u2 float64, u3 float64) (float64, float64) {
+	index := int(u1 * float64(sides))
+	if sides <= index {
+		index = sides - 1
+	}
+
+	angle := 2.0 * math.Pi / float64(sides)
+	theta0 := math.Pi/2.0 + float64(index)*angle
+	theta1 := theta0 + angle
+
+	// uniform barycentric coordinates of the triangle of the center and two vertices
+	s := math.Sqrt(u2)
+	b0 := s * (1.0 - u3)
+	b1 := s * u3
+
+	return b0*math.Cos(theta0) + b1*math.Cos(theta1), b0*math.Sin(theta0) + b1*math.Sin(theta1)
+}
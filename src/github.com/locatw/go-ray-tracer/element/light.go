@@ -0,0 +1,182 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Light sampled from a shaded point.
+type LightSample struct {
+	// Unit vector from the shaded point toward the light.
+	Direction Vector
+	// Distance to the sampled point, or +Inf for lights at infinity.
+	Distance float64
+	// Incident radiance along Direction. For delta lights, it is the incident irradiance
+	// on a surface perpendicular to Direction.
+	Radiance Color
+	// Probability density of Direction with respect to solid angle. It is 1 for delta lights.
+	Pdf float64
+	// Whether the light is described by a delta distribution, which cannot be hit by rays.
+	IsDelta bool
+}
+
+type Light interface {
+	// Sample the incident light at position. It returns false if no light arrives.
+	Sample(rnd *rand.Rand, position Vector) (LightSample, bool)
+}
+
+// Light which emits uniformly in all directions from a point.
+type PointLight struct {
+	Position Vector
+	// Radiant intensity, which is power per solid angle.
+	Intensity Color
+}
+
+func (light *PointLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	return sampleDeltaPositionLight(light.Position, light.Intensity, position)
+}
+
+// Point light restricted to a cone. Intensity falls off smoothly between the falloff cone and the outer cone.
+type SpotLight struct {
+	Position Vector
+	// Unit direction of the cone axis.
+	Direction Vector
+	Intensity Color
+	// Cosines of the half angles of the outer cone and the cone where falloff starts.
+	CosTotalWidth   float64
+	CosFalloffStart float64
+}
+
+// Create a spot light by half angles of the cones in radians.
+func CreateSpotLight(position Vector, direction Vector, intensity Color, totalWidth float64, falloffStart float64) *SpotLight {
+	return &SpotLight{
+		Position:        position,
+		Direction:       Normalize(direction),
+		Intensity:       intensity,
+		CosTotalWidth:   math.Cos(totalWidth),
+		CosFalloffStart: math.Cos(math.Min(falloffStart, totalWidth)),
+	}
+}
+
+func (light *SpotLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	sample, ok := sampleDeltaPositionLight(light.Position, light.Intensity, position)
+	if !ok {
+		return sample, false
+	}
+
+	falloff := light.falloff(Dot(Multiply(-1.0, sample.Direction), light.Direction))
+	if falloff == 0.0 {
+		return LightSample{}, false
+	}
+	sample.Radiance = MultiplyScalar(falloff, sample.Radiance)
+
+	return sample, true
+}
+
+func (light *SpotLight) falloff(cosTheta float64) float64 {
+	if cosTheta < light.CosTotalWidth {
+		return 0.0
+	}
+	if light.CosFalloffStart <= cosTheta || light.CosFalloffStart == light.CosTotalWidth {
+		return 1.0
+	}
+
+	// smoothstep between the cones
+	x := (cosTheta - light.CosTotalWidth) / (light.CosFalloffStart - light.CosTotalWidth)
+
+	return x * x * (3.0 - 2.0*x)
+}
+
+func sampleDeltaPositionLight(lightPosition Vector, intensity Color, position Vector) (LightSample, bool) {
+	v := Subtract(lightPosition, position)
+	distance := v.Length()
+	if distance == 0.0 {
+		return LightSample{}, false
+	}
+
+	return LightSample{
+		Direction: Multiply(1.0/distance, v),
+		Distance:  distance,
+		Radiance:  MultiplyScalar(1.0/(distance*distance), intensity),
+		Pdf:       1.0,
+		IsDelta:   true,
+	}, true
+}
+
+// Light at infinity which illuminates along a direction, such as the sun.
+type DirectionalLight struct {
+	// Unit direction in which light travels.
+	Direction Vector
+	// Irradiance on a surface perpendicular to Direction.
+	Irradiance Color
+}
+
+func (light *DirectionalLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	return LightSample{
+		Direction: Multiply(-1.0, light.Direction),
+		Distance:  math.Inf(1),
+		Radiance:  light.Irradiance,
+		Pdf:       1.0,
+		IsDelta:   true,
+	}, true
+}
+
+// Light emitted from the surface of a shape, whose radiance is given by Emission of its material.
+type AreaLight struct {
+	Shape SampleableShape
+}
+
+func (light *AreaLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	sample := light.Shape.Sample(rnd, position)
+
+	v := Subtract(sample.Position, position)
+	distance := v.Length()
+	if distance == 0.0 {
+		return LightSample{}, false
+	}
+	direction := Multiply(1.0/distance, v)
+
+	radiance := EmittedRadiance(light.Shape.GetMaterial(), Multiply(-1.0, direction), sample.Normal)
+	pdf := AreaToSolidAnglePdf(sample.Pdf, position, sample.Position, sample.Normal)
+	if pdf == 0.0 || radiance.NearlyEqual(CreateDefaultColor(Black)) {
+		return LightSample{}, false
+	}
+
+	return LightSample{Direction: direction, Distance: distance, Radiance: radiance, Pdf: pdf, IsDelta: false}, true
+}
+
+// Solid angle density that Sample returns the direction from reference to position on the surface.
+func (light *AreaLight) Pdf(reference Vector, position Vector, normal Vector) float64 {
+	pdf := light.Shape.Pdf(reference, position, normal)
+
+	return AreaToSolidAnglePdf(pdf, reference, position, normal)
+}
+
+// Radiance emitted from a surface toward outgoing direction.
+// Emission is scaled by the cosine to the normal, and surfaces emit only on the front side.
+func EmittedRadiance(material Material, outgoing Vector, normal Vector) Color {
+	cosTheta := Dot(outgoing, normal)
+	if cosTheta <= 0.0 {
+		return CreateDefaultColor(Black)
+	}
+
+	return MultiplyScalar(cosTheta, material.Emission)
+}
+
+// Create area lights for shapes which have emission and can be sampled.
+func CreateAreaLights(shapes []Shape) []Light {
+	lights := make([]Light, 0)
+
+	for _, shape := range shapes {
+		if shape.GetMaterial().Emission.NearlyEqual(CreateDefaultColor(Black)) || !IsSampleableShape(shape) {
+			continue
+		}
+
+		lights = append(lights, &AreaLight{Shape: shape.(SampleableShape)})
+	}
+
+	return lights
+}
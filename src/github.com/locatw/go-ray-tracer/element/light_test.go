@@ -0,0 +1,291 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Solid angle of a triangle seen from origin (Van Oosterom and Strackee, 1983).
+func triangleSolidAngle(origin Vector, vertices [3]Vector) float64 {
+	a := Subtract(vertices[0], origin)
+	b := Subtract(vertices[1], origin)
+	c := Subtract(vertices[2], origin)
+	la := a.Length()
+	lb := b.Length()
+	lc := c.Length()
+
+	numerator := Dot(a, Cross(b, c))
+	denominator := la*lb*lc + Dot(a, b)*lc + Dot(a, c)*lb + Dot(b, c)*la
+
+	return 2.0 * math.Abs(math.Atan2(numerator, denominator))
+}
+
+// Estimate the solid angle of a shape by sampling, which is the expectation of 1 / (solid angle pdf).
+func estimateSolidAngle(shape SampleableShape, reference Vector, count int) float64 {
+	rnd := rand.New(rand.NewSource(1))
+
+	sum := 0.0
+	for i := 0; i < count; i++ {
+		sample := shape.Sample(rnd, reference)
+		sum += 1.0 / AreaToSolidAnglePdf(sample.Pdf, reference, sample.Position, sample.Normal)
+	}
+
+	return sum / float64(count)
+}
+
+func TestSampleableShape(t *testing.T) {
+	vertices := [3]Vector{{X: 0.0, Y: 1.0, Z: 0.0}, {X: 1.0, Y: 1.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 2.0}}
+	reference := Vector{X: 0.2, Y: 0.0, Z: 0.3}
+
+	scaling := transform.Compose(
+		transform.CreateScaling(Vector{X: 2.0, Y: 1.0, Z: 0.5}),
+		transform.CreateRotation(Vector{X: 0.0, Y: 0.0, Z: 1.0}, 0.3))
+	transformedVertices := [3]Vector{}
+	for i, v := range vertices {
+		transformedVertices[i] = scaling.TransformPoint(v)
+	}
+
+	patterns := []struct {
+		name     string
+		shape    SampleableShape
+		expected float64
+	}{
+		{
+			name:     "triangle",
+			shape:    &Triangle{Vertices: vertices},
+			expected: triangleSolidAngle(reference, vertices),
+		},
+		{
+			name: "mesh",
+			shape: CreateMesh("mesh", []*Triangle{
+				{Vertices: vertices},
+				{Vertices: [3]Vector{vertices[1], {X: 1.0, Y: 1.0, Z: 2.0}, vertices[2]}},
+			}, CreateDefaultMaterial()),
+			expected: triangleSolidAngle(reference, vertices) +
+				triangleSolidAngle(reference, [3]Vector{vertices[1], {X: 1.0, Y: 1.0, Z: 2.0}, vertices[2]}),
+		},
+		{
+			name:     "transformed triangle with non-uniform scaling",
+			shape:    &TransformedShape{Shape: &Triangle{Vertices: vertices}, Transform: scaling},
+			expected: triangleSolidAngle(reference, transformedVertices),
+		},
+		{
+			// cone of a sphere whose half angle is asin(1/2)
+			name:     "sphere",
+			shape:    &Sphere{Center: Vector{X: 0.0, Y: 2.0, Z: 0.0}, Radius: 1.0},
+			expected: 2.0 * math.Pi * (1.0 - math.Cos(math.Asin(1.0/math.Sqrt(4.0+0.04+0.09)))),
+		},
+	}
+
+	for _, pattern := range patterns {
+		actual := estimateSolidAngle(pattern.shape, reference, 20000)
+
+		if 0.02*pattern.expected < math.Abs(actual-pattern.expected) {
+			t.Errorf("sampling %s must estimate solid angle %f, actual %f", pattern.name, pattern.expected, actual)
+		}
+	}
+}
+
+func TestSampleableShapePdf(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	reference := Vector{X: 0.5, Y: -3.0, Z: 0.2}
+
+	shapes := []SampleableShape{
+		&Sphere{Center: Vector{X: 0.0, Y: 1.0, Z: 0.0}, Radius: 2.0},
+		&TransformedShape{
+			Shape:     &Sphere{Center: CreateZeroVector(), Radius: 1.0},
+			Transform: transform.CreateScaling(Vector{X: 1.0, Y: 2.0, Z: 3.0}),
+		},
+	}
+
+	for _, shape := range shapes {
+		for i := 0; i < 100; i++ {
+			sample := shape.Sample(rnd, reference)
+			pdf := shape.Pdf(reference, sample.Position, sample.Normal)
+
+			if 1e-6*sample.Pdf < math.Abs(pdf-sample.Pdf) {
+				t.Errorf("Pdf(%v, %v) must return %f returned by Sample, actual %f", reference, sample.Position, sample.Pdf, pdf)
+				break
+			}
+		}
+	}
+}
+
+func TestPointLightSample(t *testing.T) {
+	light := &PointLight{Position: Vector{X: 0.0, Y: 2.0, Z: 0.0}, Intensity: Color{R: 4.0, G: 8.0, B: 12.0}}
+
+	sample, ok := light.Sample(nil, CreateZeroVector())
+
+	expected := LightSample{
+		Direction: Vector{X: 0.0, Y: 1.0, Z: 0.0},
+		Distance:  2.0,
+		Radiance:  Color{R: 1.0, G: 2.0, B: 3.0},
+		Pdf:       1.0,
+		IsDelta:   true,
+	}
+	if !ok || sample != expected {
+		t.Errorf("Sample must return %v, actual %v", expected, sample)
+	}
+}
+
+func TestSpotLightSample(t *testing.T) {
+	light := CreateSpotLight(
+		Vector{X: 0.0, Y: 1.0, Z: 0.0},
+		Vector{X: 0.0, Y: -1.0, Z: 0.0},
+		Color{R: 1.0, G: 1.0, B: 1.0},
+		math.Pi/4.0,
+		math.Pi/8.0)
+
+	patterns := []struct {
+		position Vector
+		expected float32
+		ok       bool
+	}{
+		// on the axis
+		{position: CreateZeroVector(), expected: 1.0, ok: true},
+		// 30 degrees from the axis, between the cones
+		{position: Vector{X: math.Tan(math.Pi / 6.0), Y: 0.0, Z: 0.0}, ok: true},
+		// outside of the cone
+		{position: Vector{X: 2.0, Y: 0.0, Z: 0.0}, ok: false},
+	}
+
+	for _, pattern := range patterns {
+		sample, ok := light.Sample(nil, pattern.position)
+		if ok != pattern.ok {
+			t.Errorf("Sample(%v) must return %t, actual %t", pattern.position, pattern.ok, ok)
+			continue
+		}
+
+		if pattern.expected != 0.0 && sample.Radiance.R != pattern.expected {
+			t.Errorf("Sample(%v) must return radiance %f, actual %f", pattern.position, pattern.expected, sample.Radiance.R)
+		}
+
+		distance2 := float32(sample.Distance * sample.Distance)
+		if ok && (sample.Radiance.R*distance2 <= 0.0 || 1.0 < sample.Radiance.R*distance2) {
+			t.Errorf("Sample(%v) must return falloff in (0, 1], actual %f", pattern.position, sample.Radiance.R*distance2)
+		}
+	}
+}
+
+func TestAreaLightSample(t *testing.T) {
+	material := CreateDefaultMaterial()
+	material.Emission = Color{R: 2.0, G: 2.0, B: 2.0}
+	light := &AreaLight{Shape: &Triangle{
+		Vertices: [3]Vector{{X: -1.0, Y: 1.0, Z: -1.0}, {X: 1.0, Y: 1.0, Z: -1.0}, {X: 0.0, Y: 1.0, Z: 1.0}},
+		Material: material,
+	}}
+
+	t.Run("When a point is in front of the light", func(t *testing.T) {
+		// the geometric normal of the triangle is -Y
+		sample, ok := light.Sample(rand.New(rand.NewSource(1)), CreateZeroVector())
+
+		t.Run("it returns emission scaled by the cosine at the light", func(t *testing.T) {
+			cosTheta := float32(Dot(sample.Direction, Vector{X: 0.0, Y: 1.0, Z: 0.0}))
+			if !ok || math.Abs(float64(sample.Radiance.R-2.0*cosTheta)) > 1e-6 || sample.IsDelta {
+				t.Errorf("got: %v, want: radiance %f", sample, 2.0*cosTheta)
+			}
+		})
+	})
+
+	t.Run("When a point is behind the light", func(t *testing.T) {
+		_, ok := light.Sample(rand.New(rand.NewSource(1)), Vector{X: 0.0, Y: 2.0, Z: 0.0})
+
+		t.Run("it returns no light", func(t *testing.T) {
+			if ok {
+				t.Errorf("got: true, want: false")
+			}
+		})
+	})
+}
+
+func TestCreateAreaLights(t *testing.T) {
+	emissive := CreateDefaultMaterial()
+	emissive.Emission = Color{R: 1.0, G: 1.0, B: 1.0}
+
+	shapes := []Shape{
+		&Sphere{Radius: 1.0, Material: emissive},
+		&Sphere{Radius: 1.0, Material: CreateDefaultMaterial()},
+		&Plane{Normal: Vector{X: 0.0, Y: 1.0, Z: 0.0}, Material: emissive},
+		&TransformedShape{Shape: &Sphere{Radius: 1.0, Material: emissive}, Transform: transform.CreateIdentityTransform()},
+	}
+
+	lights := CreateAreaLights(shapes)
+
+	if len(lights) != 2 {
+		t.Errorf("CreateAreaLights must return lights of emissive spheres, actual %v", lights)
+	}
+}
+
+func TestEnvironmentLight(t *testing.T) {
+	img := CreateImage(16, 8)
+	for i := range img.Pixels {
+		img.Pixels[i].Color = Color{R: 0.1, G: 0.2, B: 0.3}
+	}
+	img.Pixels[2*16+5].Color = Color{R: 100.0, G: 100.0, B: 100.0}
+	light := CreateEnvironmentLight(img, 0.7, Color{R: 2.0, G: 2.0, B: 2.0})
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("it returns samples consistent with Radiance and Pdf", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			sample, ok := light.Sample(rnd, CreateZeroVector())
+			if !ok {
+				continue
+			}
+
+			radiance := light.Radiance(sample.Direction)
+			pdf := light.Pdf(sample.Direction)
+			if !sample.Radiance.NearlyEqual(radiance) || 1e-6*pdf < math.Abs(sample.Pdf-pdf) || !math.IsInf(sample.Distance, 1) {
+				t.Fatalf("got: %v, want: radiance %v and pdf %f at infinity", sample, radiance, pdf)
+			}
+		}
+	})
+
+	t.Run("it returns Pdf which integrates to 1 over the sphere", func(t *testing.T) {
+		sum := 0.0
+		n := 800
+		for i := 0; i < n; i++ {
+			cosTheta := 1.0 - 2.0*(float64(i)+0.5)/float64(n)
+			sinTheta := math.Sqrt(1.0 - cosTheta*cosTheta)
+			for j := 0; j < 2*n; j++ {
+				phi := 2.0 * math.Pi * (float64(j) + 0.5) / float64(2*n)
+				sum += light.Pdf(Vector{X: sinTheta * math.Cos(phi), Y: cosTheta, Z: sinTheta * math.Sin(phi)})
+			}
+		}
+		integral := sum * 4.0 * math.Pi / float64(2*n*n)
+
+		if 0.01 < math.Abs(integral-1.0) {
+			t.Errorf("got: %f, want: 1", integral)
+		}
+	})
+
+	t.Run("it returns positive Pdf wherever Radiance is positive", func(t *testing.T) {
+		dark := CreateImage(16, 8)
+		dark.Pixels[2*16+5].Color = Color{R: 1.0, G: 1.0, B: 1.0}
+		spot := CreateEnvironmentLight(dark, 0.0, Color{R: 1.0, G: 1.0, B: 1.0})
+
+		for i := 0; i < 10000; i++ {
+			direction, _ := equirectangularDirection(rnd.Float64(), rnd.Float64())
+
+			if 0.0 < Luminance(spot.Radiance(direction)) && spot.Pdf(direction) <= 0.0 {
+				t.Fatalf("got: pdf %f, want: positive pdf for radiance %v", spot.Pdf(direction), spot.Radiance(direction))
+			}
+		}
+	})
+
+	t.Run("it returns radiance of the rotated image scaled by intensity", func(t *testing.T) {
+		// the center of the image is seen toward -Z before rotation
+		direction := Vector{X: -math.Sin(0.7), Y: 0.0, Z: -math.Cos(0.7)}
+		unrotated := CreateEnvironmentLight(img, 0.0, Color{R: 2.0, G: 2.0, B: 2.0})
+
+		actual := light.Radiance(direction)
+		expected := unrotated.Radiance(Vector{X: 0.0, Y: 0.0, Z: -1.0})
+		if !actual.NearlyEqual(expected) {
+			t.Errorf("got: %v, want: %v", actual, expected)
+		}
+	})
+}
@@ -1,12 +1,49 @@
 package element
 
-import . "github.com/locatw/go-ray-tracer/image"
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/image"
+)
 
 type Material struct {
-	Emission          Color
+	Emission Color
+	// Scattering of the surface. If it is nil, Diffuse, Specular and IndexOfRefraction describe it.
+	BSDF              BSDF
 	Diffuse           Color
 	Specular          Color
 	IndexOfRefraction *float64
+	// Textures which override colors and roughness of the material. Emission is not textured,
+	// because lights are sampled without points on surfaces.
+	Textures *MaterialTextures
+}
+
+// Textures of a material, where nil textures keep the values of the material.
+type MaterialTextures struct {
+	// Used by legacy materials.
+	Diffuse  Texture
+	Specular Texture
+	// Used by principled materials.
+	BaseColor Texture
+	Metallic  Texture
+	// Used by principled, conductor and dielectric materials.
+	Roughness Texture
+
+	// Used by all materials to perturb the shading normal.
+	// Normal is a tangent-space normal map whose colors in [0, 1] map to [-1, 1], where Z is the normal.
+	// Bump is a height map, which is used only if Normal is nil.
+	Normal Texture
+	Bump   Texture
+	// Height of the surface where the value of Bump is 1.
+	BumpScale float64
+}
+
+// BSDF whose parameters can be overridden by textures.
+type TexturableBSDF interface {
+	BSDF
+
+	// Return the BSDF with parameters evaluated at a point.
+	ApplyTextures(textures *MaterialTextures, point TexturePoint) BSDF
 }
 
 func CreateDefaultMaterial() Material {
@@ -14,3 +51,59 @@ func CreateDefaultMaterial() Material {
 
 	return Material{Emission: black, Diffuse: black, Specular: black, IndexOfRefraction: nil}
 }
+
+// Return the BSDF of the material at a point, or nil if it does not scatter light.
+// Without BSDF, the material is the sum of a Lambertian component of Diffuse and a perfect mirror
+// of Specular, which becomes a Schlick dielectric if IndexOfRefraction is set.
+func (material *Material) GetBSDF(point TexturePoint) BSDF {
+	textures := material.Textures
+
+	if material.BSDF != nil {
+		if texturable, ok := material.BSDF.(TexturableBSDF); ok && textures != nil {
+			return texturable.ApplyTextures(textures, point)
+		}
+
+		return material.BSDF
+	}
+
+	if textures != nil {
+		textured := *material
+		if textures.Diffuse != nil {
+			textured.Diffuse = textures.Diffuse.Evaluate(point)
+		}
+		if textures.Specular != nil {
+			textured.Specular = textures.Specular.Evaluate(point)
+		}
+		textured.Textures = nil
+
+		return textured.GetBSDF(point)
+	}
+
+	black := CreateDefaultColor(Black)
+
+	components := make([]BSDF, 0, 2)
+	weights := make([]float64, 0, 2)
+
+	if !material.Diffuse.NearlyEqual(black) {
+		components = append(components, &LambertianBSDF{Reflectance: material.Diffuse})
+		weights = append(weights, maxComponent(material.Diffuse))
+	}
+
+	if material.IndexOfRefraction != nil {
+		components = append(components, &SchlickDielectricBSDF{Reflectance: material.Specular, IndexOfRefraction: *material.IndexOfRefraction})
+		// transmission is not tinted by Specular
+		weights = append(weights, math.Max(1.0, maxComponent(material.Specular)))
+	} else if !material.Specular.NearlyEqual(black) {
+		components = append(components, &MirrorBSDF{Reflectance: material.Specular})
+		weights = append(weights, maxComponent(material.Specular))
+	}
+
+	switch len(components) {
+	case 0:
+		return nil
+	case 1:
+		return components[0]
+	default:
+		return CreateMixtureBSDF(components, weights)
+	}
+}
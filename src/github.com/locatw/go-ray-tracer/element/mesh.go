@@ -0,0 +1,62 @@
+package element
+
+type Mesh struct {
+	Name      string
+	Triangles []*Triangle
+	Material  Material
+	bvh       *BVH
+	// cumulative areas of triangles for sampling
+	areaCdf []float64
+}
+
+func CreateMesh(name string, triangles []*Triangle, material Material) *Mesh {
+	for _, triangle := range triangles {
+		triangle.Material = material
+	}
+
+	shapes := make([]Shape, len(triangles))
+	for i, triangle := range triangles {
+		shapes[i] = triangle
+	}
+
+	return &Mesh{
+		Name:      name,
+		Triangles: triangles,
+		Material:  material,
+		bvh:       CreateBVH(shapes),
+		areaCdf:   createAreaCdf(triangles),
+	}
+}
+
+func (mesh *Mesh) Intersect(ray Ray) *HitInfo {
+	// a mesh which is not created by CreateMesh has no BVH
+	if mesh.bvh != nil {
+		return mesh.bvh.Intersect(ray)
+	}
+
+	var minHitInfo *HitInfo
+
+	for _, triangle := range mesh.Triangles {
+		hitInfo := triangle.Intersect(ray)
+
+		if hitInfo != nil && (minHitInfo == nil || hitInfo.T < minHitInfo.T) {
+			minHitInfo = hitInfo
+		}
+	}
+
+	return minHitInfo
+}
+
+func (mesh *Mesh) GetMaterial() Material {
+	return mesh.Material
+}
+
+func (mesh *Mesh) Bounds() AABB {
+	bounds := CreateEmptyAABB()
+
+	for _, triangle := range mesh.Triangles {
+		bounds = UnionAABB(bounds, triangle.Bounds())
+	}
+
+	return bounds
+}
@@ -0,0 +1,126 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Roughness below which microfacet distributions are clamped, because they become numerically unstable.
+// Use MirrorBSDF or SchlickDielectricBSDF for perfectly smooth surfaces.
+const minimumRoughnessAlpha = 1e-3
+
+// Trowbridge-Reitz (GGX) distribution of microfacet normals in the shading frame.
+// AlphaX and AlphaY are the widths along the tangent and the bitangent.
+type GGXDistribution struct {
+	AlphaX float64
+	AlphaY float64
+}
+
+// Create a distribution from perceptual roughness in [0, 1] along the tangent and the bitangent.
+func CreateGGXDistribution(roughnessX float64, roughnessY float64) GGXDistribution {
+	return GGXDistribution{
+		AlphaX: math.Max(minimumRoughnessAlpha, roughnessX*roughnessX),
+		AlphaY: math.Max(minimumRoughnessAlpha, roughnessY*roughnessY),
+	}
+}
+
+// Density of microfacet normals with respect to projected area.
+func (distribution GGXDistribution) D(m Vector) float64 {
+	if m.Z <= 0.0 {
+		return 0.0
+	}
+
+	x := m.X / distribution.AlphaX
+	y := m.Y / distribution.AlphaY
+	e := x*x + y*y + m.Z*m.Z
+
+	return 1.0 / (math.Pi * distribution.AlphaX * distribution.AlphaY * e * e)
+}
+
+// Smith's auxiliary function, which is the shadowed area of microfacets relative to the visible area.
+func (distribution GGXDistribution) Lambda(w Vector) float64 {
+	if w.Z == 0.0 {
+		return math.Inf(1)
+	}
+
+	x := distribution.AlphaX * w.X
+	y := distribution.AlphaY * w.Y
+
+	return (math.Sqrt(1.0+(x*x+y*y)/(w.Z*w.Z)) - 1.0) / 2.0
+}
+
+// Masking function, which is the fraction of microfacets visible from w.
+func (distribution GGXDistribution) G1(w Vector) float64 {
+	return 1.0 / (1.0 + distribution.Lambda(w))
+}
+
+// Height correlated masking and shadowing function.
+func (distribution GGXDistribution) G(outgoing Vector, incoming Vector) float64 {
+	return 1.0 / (1.0 + distribution.Lambda(outgoing) + distribution.Lambda(incoming))
+}
+
+// Density of microfacet normals visible from w with respect to solid angle, which is the density of
+// SampleVisibleNormal. w is flipped to the upper hemisphere.
+func (distribution GGXDistribution) VisiblePdf(w Vector, m Vector) float64 {
+	if w.Z == 0.0 {
+		return 0.0
+	}
+	if w.Z < 0.0 {
+		w = Multiply(-1.0, w)
+	}
+
+	return distribution.G1(w) / w.Z * distribution.D(m) * math.Max(0.0, Dot(w, m))
+}
+
+// Sample a microfacet normal visible from w (Heitz, 2018). w is flipped to the upper hemisphere.
+func (distribution GGXDistribution) SampleVisibleNormal(w Vector, u1 float64, u2 float64) Vector {
+	// stretch w to the configuration of a hemisphere
+	wh := Normalize(Vector{X: distribution.AlphaX * w.X, Y: distribution.AlphaY * w.Y, Z: w.Z})
+	if wh.Z < 0.0 {
+		wh = Multiply(-1.0, wh)
+	}
+
+	t1 := Vector{X: 1.0, Y: 0.0, Z: 0.0}
+	if wh.Z < 0.99999 {
+		t1 = Normalize(Cross(Vector{X: 0.0, Y: 0.0, Z: 1.0}, wh))
+	}
+	t2 := Cross(wh, t1)
+
+	// uniform point on a disk, warped to the projection of the hemisphere
+	r := math.Sqrt(u1)
+	phi := 2.0 * math.Pi * u2
+	p1 := r * math.Cos(phi)
+	p2 := r * math.Sin(phi)
+	s := 0.5 * (1.0 + wh.Z)
+	p2 = (1.0-s)*math.Sqrt(math.Max(0.0, 1.0-p1*p1)) + s*p2
+
+	nh := AddAll(Multiply(p1, t1), Multiply(p2, t2), Multiply(math.Sqrt(math.Max(0.0, 1.0-p1*p1-p2*p2)), wh))
+
+	return Normalize(Vector{X: distribution.AlphaX * nh.X, Y: distribution.AlphaY * nh.Y, Z: math.Max(1e-6, nh.Z)})
+}
+
+// Mirror direction of v about m.
+func reflectAbout(v Vector, m Vector) Vector {
+	return Subtract(Multiply(2.0*Dot(v, m), m), v)
+}
+
+// Refract v about m, where eta is the ratio of the index of refraction on the other side to the one on
+// the side of m. It returns false on total internal reflection.
+func refractAbout(v Vector, m Vector, eta float64) (Vector, bool) {
+	cosI := Dot(v, m)
+	if cosI < 0.0 {
+		eta = 1.0 / eta
+		cosI = -cosI
+		m = Multiply(-1.0, m)
+	}
+
+	sin2T := math.Max(0.0, 1.0-cosI*cosI) / (eta * eta)
+	if 1.0 <= sin2T {
+		return Vector{}, false
+	}
+
+	cosT := math.Sqrt(1.0 - sin2T)
+
+	return Add(Multiply(-1.0/eta, v), Multiply(cosI/eta-cosT, m)), true
+}
@@ -0,0 +1,139 @@
+package element
+
+import (
+	"math"
+
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Number of times where bounds of moving shapes are sampled.
+const motionBoundsSteps = 64
+
+// Shape placed in the world by an animated transform, which is evaluated at the time of rays.
+// Moving shapes are not sampled as area lights, and their emission is found only by rays.
+type AnimatedShape struct {
+	Shape  Shape
+	Motion transform.AnimatedTransform
+}
+
+func (shape *AnimatedShape) Intersect(ray Ray) *HitInfo {
+	placed := TransformedShape{Shape: shape.Shape, Transform: shape.Motion.At(ray.Time)}
+
+	return placed.Intersect(ray)
+}
+
+func (shape *AnimatedShape) GetMaterial() Material {
+	return shape.Shape.GetMaterial()
+}
+
+// Return the bounds of the shape over the whole motion.
+func (shape *AnimatedShape) Bounds() AABB {
+	bounded, ok := shape.Shape.(Bounded)
+	if !ok {
+		inf := math.Inf(1)
+		return AABB{Min: Vector{X: -inf, Y: -inf, Z: -inf}, Max: Vector{X: inf, Y: inf, Z: inf}}
+	}
+
+	box := bounded.Bounds()
+	if box.IsEmpty() {
+		return box
+	}
+
+	if !shape.Motion.IsAnimated() {
+		return TransformAABB(shape.Motion.Start, box)
+	}
+
+	// bounds at sampled times are padded by the distance which corners move between samples,
+	// which covers corners moving along arcs by rotation
+	step := (shape.Motion.EndTime - shape.Motion.StartTime) / float64(motionBoundsSteps)
+	result := CreateEmptyAABB()
+	padding := 0.0
+	previous := shape.Motion.Start
+	for i := 0; i <= motionBoundsSteps; i++ {
+		current := shape.Motion.At(shape.Motion.StartTime + float64(i)*step)
+
+		result = UnionAABB(result, TransformAABB(current, box))
+		padding = math.Max(padding, maxCornerDistance(previous, current, box))
+		previous = current
+	}
+
+	pad := Vector{X: padding, Y: padding, Z: padding}
+
+	return AABB{Min: Subtract(result.Min, pad), Max: Add(result.Max, pad)}
+}
+
+// Return the largest distance between corners of a box transformed by two transforms.
+func maxCornerDistance(t1 transform.Transform, t2 transform.Transform, box AABB) float64 {
+	distance := 0.0
+
+	for i := 0; i < 8; i++ {
+		corner := box.Min
+		if i&1 != 0 {
+			corner.X = box.Max.X
+		}
+		if i&2 != 0 {
+			corner.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = box.Max.Z
+		}
+
+		d := Subtract(t1.TransformPoint(corner), t2.TransformPoint(corner))
+		distance = math.Max(distance, d.Length())
+	}
+
+	return distance
+}
+
+// Shape which is at its place at StartTime and moves by Velocity per unit time until EndTime.
+// Moving shapes are not sampled as area lights, and their emission is found only by rays.
+type LinearMotionShape struct {
+	Shape              Shape
+	Velocity           Vector
+	StartTime, EndTime float64
+}
+
+func (shape *LinearMotionShape) offset(time float64) Vector {
+	time = math.Max(shape.StartTime, math.Min(shape.EndTime, time))
+
+	return Multiply(time-shape.StartTime, shape.Velocity)
+}
+
+func (shape *LinearMotionShape) Intersect(ray Ray) *HitInfo {
+	offset := shape.offset(ray.Time)
+
+	hitInfo := shape.Shape.Intersect(Ray{Origin: Subtract(ray.Origin, offset), Direction: ray.Direction, Time: ray.Time})
+	if hitInfo == nil {
+		return nil
+	}
+
+	hitInfo.Position = Add(hitInfo.Position, offset)
+
+	return hitInfo
+}
+
+func (shape *LinearMotionShape) GetMaterial() Material {
+	return shape.Shape.GetMaterial()
+}
+
+// Return the bounds of the shape over the whole motion.
+func (shape *LinearMotionShape) Bounds() AABB {
+	bounded, ok := shape.Shape.(Bounded)
+	if !ok {
+		inf := math.Inf(1)
+		return AABB{Min: Vector{X: -inf, Y: -inf, Z: -inf}, Max: Vector{X: inf, Y: inf, Z: inf}}
+	}
+
+	box := bounded.Bounds()
+	if box.IsEmpty() {
+		return box
+	}
+
+	start := shape.offset(shape.StartTime)
+	end := shape.offset(shape.EndTime)
+
+	return UnionAABB(
+		AABB{Min: Add(box.Min, start), Max: Add(box.Max, start)},
+		AABB{Min: Add(box.Min, end), Max: Add(box.Max, end)})
+}
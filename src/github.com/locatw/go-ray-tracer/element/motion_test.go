@@ -0,0 +1,104 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestMovingShapeIntersect(t *testing.T) {
+	sphere := &Sphere{Center: CreateZeroVector(), Radius: 1.0, Material: CreateDefaultMaterial()}
+	animated := &AnimatedShape{
+		Shape: sphere,
+		Motion: transform.CreateAnimatedTransform(
+			transform.CreateIdentityTransform(), 0.0,
+			transform.CreateTranslation(Vector{X: 4.0, Y: 0.0, Z: 0.0}), 1.0),
+	}
+	linear := &LinearMotionShape{Shape: sphere, Velocity: Vector{X: 4.0, Y: 0.0, Z: 0.0}, StartTime: 0.0, EndTime: 1.0}
+
+	patterns := []struct {
+		name     string
+		shape    Shape
+		time     float64
+		expected bool
+	}{
+		{name: "animated shape at the start", shape: animated, time: 0.0, expected: false},
+		{name: "animated shape in the middle", shape: animated, time: 0.75, expected: true},
+		{name: "linear motion shape at the start", shape: linear, time: 0.0, expected: false},
+		{name: "linear motion shape in the middle", shape: linear, time: 0.75, expected: true},
+		{name: "linear motion shape before the start", shape: linear, time: -1.0, expected: false},
+	}
+
+	for _, pattern := range patterns {
+		// the sphere is at x = 3 at time 0.75
+		ray := CreateRay(Vector{X: 3.0, Y: 0.0, Z: 5.0}, Vector{X: 0.0, Y: 0.0, Z: -1.0})
+		ray.Time = pattern.time
+
+		hitInfo := pattern.shape.Intersect(ray)
+		if (hitInfo != nil) != pattern.expected {
+			t.Errorf("Intersect(%v) of %s must return hit %t, actual %v", ray, pattern.name, pattern.expected, hitInfo)
+			continue
+		}
+
+		if hitInfo != nil {
+			expected := Vector{X: 3.0, Y: 0.0, Z: 1.0}
+			if !hitInfo.Position.NearlyEqual(expected) || 1e-9 < math.Abs(hitInfo.T-4.0) {
+				t.Errorf("Intersect(%v) of %s must return hit at %v, actual %v", ray, pattern.name, expected, hitInfo.Position)
+			}
+		}
+	}
+}
+
+func TestMovingShapeBounds(t *testing.T) {
+	sphere := &Sphere{Center: Vector{X: 2.0, Y: 0.0, Z: 0.0}, Radius: 0.5, Material: CreateDefaultMaterial()}
+
+	t.Run("When a shape rotates", func(t *testing.T) {
+		shape := &AnimatedShape{
+			Shape: sphere,
+			Motion: transform.CreateAnimatedTransform(
+				transform.CreateIdentityTransform(), 0.0,
+				transform.CreateRotation(Vector{X: 0.0, Y: 1.0, Z: 0.0}, math.Pi), 1.0),
+		}
+
+		t.Run("it returns bounds which contain the shape during the motion", func(t *testing.T) {
+			bounds := shape.Bounds()
+
+			for i := 0; i <= 100; i++ {
+				center := shape.Motion.At(float64(i) / 100.0).TransformPoint(sphere.Center)
+				extent := Vector{X: 0.5, Y: 0.5, Z: 0.5}
+				if !bounds.Contains(Add(center, extent)) || !bounds.Contains(Subtract(center, extent)) {
+					t.Fatalf("got: %v, want: bounds containing the sphere at %v", bounds, center)
+				}
+			}
+		})
+	})
+
+	t.Run("When a shape which has no triangles rotates", func(t *testing.T) {
+		shape := &AnimatedShape{
+			Shape: CreateMesh("empty", []*Triangle{}, CreateDefaultMaterial()),
+			Motion: transform.CreateAnimatedTransform(
+				transform.CreateIdentityTransform(), 0.0,
+				transform.CreateRotation(Vector{X: 0.0, Y: 1.0, Z: 0.0}, math.Pi), 1.0),
+		}
+
+		t.Run("it returns empty bounds", func(t *testing.T) {
+			if bounds := shape.Bounds(); !bounds.IsEmpty() {
+				t.Errorf("got: %v, want: empty bounds", bounds)
+			}
+		})
+	})
+
+	t.Run("When a shape moves linearly", func(t *testing.T) {
+		shape := &LinearMotionShape{Shape: sphere, Velocity: Vector{X: 0.0, Y: 2.0, Z: 0.0}, StartTime: 1.0, EndTime: 2.0}
+
+		t.Run("it returns bounds which contain both ends", func(t *testing.T) {
+			expected := AABB{Min: Vector{X: 1.5, Y: -0.5, Z: -0.5}, Max: Vector{X: 2.5, Y: 2.5, Z: 0.5}}
+
+			if bounds := shape.Bounds(); !bounds.Min.NearlyEqual(expected.Min) || !bounds.Max.NearlyEqual(expected.Max) {
+				t.Errorf("got: %v, want: %v", bounds, expected)
+			}
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Step of UV to take finite differences of bump maps.
+const bumpDelta = 1e-3
+
+// Perturb the shading normal of a hit by the normal or bump map of the material.
+// Dpdu is also updated so that it stays tangent to the perturbed surface.
+func (material *Material) PerturbShadingNormal(hitInfo *HitInfo) {
+	textures := material.Textures
+	if textures == nil || (textures.Normal == nil && textures.Bump == nil) {
+		return
+	}
+
+	var normal Vector
+	if textures.Normal != nil {
+		normal = normalMappedNormal(textures.Normal, hitInfo)
+	} else {
+		normal = bumpMappedNormal(textures.Bump, textures.BumpScale, hitInfo)
+	}
+
+	// degenerate tangents or colors give no normal
+	if math.IsNaN(normal.X) || math.IsNaN(normal.Y) || math.IsNaN(normal.Z) {
+		return
+	}
+
+	hitInfo.ShadingNormal = normal
+	hitInfo.Dpdu = CreateFrameFromTangent(normal, hitInfo.Dpdu).Tangent
+}
+
+func normalMappedNormal(texture Texture, hitInfo *HitInfo) Vector {
+	color := texture.Evaluate(hitInfo.TexturePoint())
+	local := Vector{X: 2.0*float64(color.R) - 1.0, Y: 2.0*float64(color.G) - 1.0, Z: 2.0*float64(color.B) - 1.0}
+
+	return Normalize(hitInfo.ShadingFrame().ToWorld(local))
+}
+
+// Normal of the surface displaced along the shading normal by the height map, ignoring the change of the normal itself.
+func bumpMappedNormal(texture Texture, scale float64, hitInfo *HitInfo) Vector {
+	point := hitInfo.TexturePoint()
+	height := EvaluateFloatTexture(texture, point)
+
+	shiftedU := TexturePoint{
+		UV:       UV{U: point.UV.U + bumpDelta, V: point.UV.V},
+		Position: Add(point.Position, Multiply(bumpDelta, hitInfo.Dpdu)),
+	}
+	shiftedV := TexturePoint{
+		UV:       UV{U: point.UV.U, V: point.UV.V + bumpDelta},
+		Position: Add(point.Position, Multiply(bumpDelta, hitInfo.Dpdv)),
+	}
+
+	dhdu := scale * (EvaluateFloatTexture(texture, shiftedU) - height) / bumpDelta
+	dhdv := scale * (EvaluateFloatTexture(texture, shiftedV) - height) / bumpDelta
+
+	n := hitInfo.ShadingNormal
+	dpdu := Add(hitInfo.Dpdu, Multiply(dhdu, n))
+	dpdv := Add(hitInfo.Dpdv, Multiply(dhdv, n))
+
+	normal := Normalize(Cross(dpdu, dpdv))
+	if Dot(normal, n) < 0.0 {
+		normal = Multiply(-1.0, normal)
+	}
+
+	return normal
+}
@@ -0,0 +1,68 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Height which increases linearly along U.
+type slopeTexture struct {
+	slope float64
+}
+
+func (texture *slopeTexture) Evaluate(point TexturePoint) Color {
+	h := float32(texture.slope * point.UV.U)
+	return Color{R: h, G: h, B: h}
+}
+
+func TestMaterialPerturbShadingNormal(t *testing.T) {
+	plane := &Plane{Center: CreateZeroVector(), Normal: CreateAxisVector(YAxis)}
+	hitInfo := plane.Intersect(CreateRay(Vector{X: 0.3, Y: 1.0, Z: 0.2}, Multiply(-1.0, CreateAxisVector(YAxis))))
+	frame := hitInfo.ShadingFrame()
+
+	patterns := []struct {
+		name     string
+		textures *MaterialTextures
+		expected Vector
+	}{
+		{name: "no textures", textures: nil, expected: CreateAxisVector(YAxis)},
+		{
+			name:     "flat normal map",
+			textures: &MaterialTextures{Normal: &ConstantTexture{Color: Color{R: 0.5, G: 0.5, B: 1.0}}},
+			expected: CreateAxisVector(YAxis),
+		},
+		{
+			// (1, 0, 1) in tangent space
+			name:     "tilted normal map",
+			textures: &MaterialTextures{Normal: &ConstantTexture{Color: Color{R: 1.0, G: 0.5, B: 1.0}}},
+			expected: Normalize(Add(frame.Tangent, frame.Normal)),
+		},
+		{
+			// the surface rises along the tangent by 1 per unit, so the normal tilts back by 45 degrees
+			name:     "bump map",
+			textures: &MaterialTextures{Bump: &slopeTexture{slope: 0.5}, BumpScale: 2.0},
+			expected: Normalize(Subtract(frame.Normal, frame.Tangent)),
+		},
+	}
+
+	for _, pattern := range patterns {
+		material := CreateDefaultMaterial()
+		material.Textures = pattern.textures
+
+		perturbed := *hitInfo
+		material.PerturbShadingNormal(&perturbed)
+
+		if difference := Subtract(perturbed.ShadingNormal, pattern.expected); 1e-4 < difference.Length() {
+			t.Errorf("PerturbShadingNormal with %s must return %v, actual %v", pattern.name, pattern.expected, perturbed.ShadingNormal)
+		}
+		if dot := Dot(perturbed.Dpdu, perturbed.ShadingNormal); 1e-9 < math.Abs(dot) {
+			t.Errorf("PerturbShadingNormal with %s must keep Dpdu tangent, actual %v", pattern.name, perturbed.Dpdu)
+		}
+		if !perturbed.Normal.NearlyEqual(hitInfo.Normal) {
+			t.Errorf("PerturbShadingNormal with %s must not change the geometric normal, actual %v", pattern.name, perturbed.Normal)
+		}
+	}
+}
@@ -20,7 +20,21 @@ func (plane *Plane) Intersect(ray Ray) *HitInfo {
 	if 0.0 < t {
 		pos := Add(Multiply(t, ray.Direction), ray.Origin)
 
-		return &HitInfo{Object: plane, Position: pos, Normal: plane.Normal, T: t}
+		// the plane is parameterized by distances along tangents of the normal from Center
+		frame := CreateFrame(plane.Normal)
+		d := Subtract(pos, plane.Center)
+		uv := UV{U: Dot(d, frame.Tangent), V: Dot(d, frame.Bitangent)}
+
+		return &HitInfo{
+			Object:        plane,
+			Position:      pos,
+			Normal:        plane.Normal,
+			ShadingNormal: plane.Normal,
+			T:             t,
+			UV:            uv,
+			Dpdu:          frame.Tangent,
+			Dpdv:          frame.Bitangent,
+		}
 	} else {
 		return nil
 	}
@@ -0,0 +1,377 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Parameters of the principled BSDF (Burley, 2012). Values other than BaseColor are in [0, 1].
+type PrincipledParameters struct {
+	BaseColor Color
+	// Blend from a dielectric to a metal whose reflectance is BaseColor.
+	Metallic  float64
+	Roughness float64
+	// Reflectance of dielectrics at normal incidence, where 0.5 is 4% of an index of refraction 1.5.
+	Specular float64
+	// Tint of dielectric reflection toward the hue of BaseColor.
+	SpecularTint float64
+	// Retroreflective grazing light for cloth.
+	Sheen     float64
+	SheenTint float64
+	// Strength of a clear dielectric coat of an index of refraction 1.5.
+	Clearcoat float64
+	// Smoothness of the coat.
+	ClearcoatGloss float64
+	// Blend from an opaque dielectric to glass, whose transmission is tinted by BaseColor.
+	Transmission float64
+	// Stretch of highlights along the tangent.
+	Anisotropic float64
+}
+
+func CreateDefaultPrincipledParameters() PrincipledParameters {
+	return PrincipledParameters{
+		BaseColor:      Color{R: 0.8, G: 0.8, B: 0.8},
+		Roughness:      0.5,
+		Specular:       0.5,
+		SheenTint:      0.5,
+		ClearcoatGloss: 1.0,
+	}
+}
+
+// Principled BSDF, which is the sum of diffuse, specular, transmission and clearcoat lobes whose
+// weights are chosen so that the surface does not reflect more light than it receives.
+type PrincipledBSDF struct {
+	Parameters PrincipledParameters
+
+	lobes *MixtureBSDF
+}
+
+func CreatePrincipledBSDF(parameters PrincipledParameters) *PrincipledBSDF {
+	p := parameters
+
+	tint := CreateDefaultColor(White)
+	if luminance := Luminance(p.BaseColor); 0.0 < luminance {
+		tint = DivideScalar(p.BaseColor, luminance)
+	}
+
+	dielectricF0 := 0.08 * p.Specular
+	ior := math.Max(1.01, (1.0+math.Sqrt(dielectricF0))/(1.0-math.Sqrt(dielectricF0)))
+
+	diffuseWeight := (1.0 - p.Metallic) * (1.0 - p.Transmission)
+	transmissionWeight := (1.0 - p.Metallic) * p.Transmission
+	specularWeight := 1.0 - transmissionWeight
+
+	aspect := math.Sqrt(1.0 - 0.9*p.Anisotropic)
+	alpha := p.Roughness * p.Roughness
+	distribution := GGXDistribution{
+		AlphaX: math.Max(minimumRoughnessAlpha, alpha/aspect),
+		AlphaY: math.Max(minimumRoughnessAlpha, alpha*aspect),
+	}
+
+	// reflectance of metals and opaque dielectrics, averaged by their weights
+	specularF0 := CreateDefaultColor(Black)
+	if 0.0 < specularWeight {
+		dielectric := MultiplyScalar(dielectricF0, LerpColor(p.SpecularTint, CreateDefaultColor(White), tint))
+		specularF0 = DivideScalar(AddColor(MultiplyScalar(p.Metallic, p.BaseColor), MultiplyScalar(diffuseWeight, dielectric)), specularWeight)
+	}
+
+	coat := func(bsdf BSDF, scale Color) BSDF {
+		return &coatedLobe{BSDF: bsdf, Scale: scale, Clearcoat: p.Clearcoat}
+	}
+
+	components := []BSDF{
+		coat(&principledDiffuse{
+			Color:      p.BaseColor,
+			SheenColor: LerpColor(p.SheenTint, CreateDefaultColor(White), tint),
+			Sheen:      p.Sheen,
+			F0:         dielectricF0,
+		}, MultiplyScalar(diffuseWeight, CreateDefaultColor(White))),
+		coat(&schlickMicrofacetReflection{Distribution: distribution, F0: specularF0}, MultiplyScalar(specularWeight, CreateDefaultColor(White))),
+		coat(&tintedTransmission{
+			BSDF: &RoughDielectricBSDF{Distribution: distribution, IndexOfRefraction: ior},
+			Tint: p.BaseColor,
+		}, MultiplyScalar(transmissionWeight, CreateDefaultColor(White))),
+		&clearcoatLobe{Distribution: CreateGTR1Distribution(0.1 + (0.001-0.1)*p.ClearcoatGloss), Weight: p.Clearcoat},
+	}
+	weights := []float64{
+		diffuseWeight * math.Max(Luminance(p.BaseColor), p.Sheen),
+		specularWeight * math.Max(0.1, maxComponent(specularF0)),
+		transmissionWeight,
+		0.25 * p.Clearcoat,
+	}
+
+	return &PrincipledBSDF{Parameters: parameters, lobes: CreateMixtureBSDF(components, weights)}
+}
+
+func (bsdf *PrincipledBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	return bsdf.lobes.Evaluate(outgoing, incoming)
+}
+
+func (bsdf *PrincipledBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	return bsdf.lobes.Sample(rnd, outgoing)
+}
+
+func (bsdf *PrincipledBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	return bsdf.lobes.Pdf(outgoing, incoming)
+}
+
+func (bsdf *PrincipledBSDF) Albedo() Color {
+	return bsdf.Parameters.BaseColor
+}
+
+func (bsdf *PrincipledBSDF) ApplyTextures(textures *MaterialTextures, point TexturePoint) BSDF {
+	if textures.BaseColor == nil && textures.Metallic == nil && textures.Roughness == nil {
+		return bsdf
+	}
+
+	p := bsdf.Parameters
+	if textures.BaseColor != nil {
+		p.BaseColor = textures.BaseColor.Evaluate(point)
+	}
+	if textures.Metallic != nil {
+		p.Metallic = EvaluateFloatTexture(textures.Metallic, point)
+	}
+	if textures.Roughness != nil {
+		p.Roughness = EvaluateFloatTexture(textures.Roughness, point)
+	}
+
+	return CreatePrincipledBSDF(p)
+}
+
+func schlickWeight(cosTheta float64) float64 {
+	m := math.Max(0.0, math.Min(1.0, 1.0-cosTheta))
+
+	return m * m * m * m * m
+}
+
+// Lambertian diffuse under a dielectric interface, which only receives light transmitted through it.
+// Sheen blends the color toward SheenColor as the angle between the directions and their half vector grows.
+type principledDiffuse struct {
+	Color      Color
+	SheenColor Color
+	Sheen      float64
+	// Reflectance of the interface at normal incidence.
+	F0 float64
+}
+
+func (lobe *principledDiffuse) Evaluate(outgoing Vector, incoming Vector) Color {
+	if !sameHemisphere(outgoing, incoming) {
+		return CreateDefaultColor(Black)
+	}
+
+	transmitted := (1.0 - schlickFresnel(math.Abs(outgoing.Z), lobe.F0)) * (1.0 - schlickFresnel(math.Abs(incoming.Z), lobe.F0))
+
+	color := lobe.Color
+	if 0.0 < lobe.Sheen {
+		h := Normalize(Add(outgoing, incoming))
+		color = LerpColor(lobe.Sheen*schlickWeight(Dot(incoming, h)), color, lobe.SheenColor)
+	}
+
+	return MultiplyScalar(transmitted/math.Pi, color)
+}
+
+func (lobe *principledDiffuse) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	incoming := sampleCosineHemisphere(rnd)
+	if outgoing.Z < 0.0 {
+		incoming.Z = -incoming.Z
+	}
+
+	pdf := lobe.Pdf(outgoing, incoming)
+	if pdf <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	return BSDFSample{Incoming: incoming, Weight: MultiplyScalar(math.Abs(incoming.Z)/pdf, lobe.Evaluate(outgoing, incoming)), Pdf: pdf}, true
+}
+
+func (lobe *principledDiffuse) Pdf(outgoing Vector, incoming Vector) float64 {
+	if !sameHemisphere(outgoing, incoming) {
+		return 0.0
+	}
+
+	return math.Abs(incoming.Z) / math.Pi
+}
+
+func (lobe *principledDiffuse) Albedo() Color {
+	return lobe.Color
+}
+
+func schlickFresnel(cosTheta float64, f0 float64) float64 {
+	return f0 + (1.0-f0)*schlickWeight(cosTheta)
+}
+
+// Microfacet reflection whose reflectance is given by Schlick's approximation.
+type schlickMicrofacetReflection struct {
+	Distribution GGXDistribution
+	F0           Color
+}
+
+func (lobe *schlickMicrofacetReflection) fresnel(cosTheta float64) Color {
+	return LerpColor(schlickWeight(cosTheta), lobe.F0, CreateDefaultColor(White))
+}
+
+func (lobe *schlickMicrofacetReflection) Evaluate(outgoing Vector, incoming Vector) Color {
+	return evaluateMicrofacetReflection(lobe.Distribution, lobe.fresnel, outgoing, incoming)
+}
+
+func (lobe *schlickMicrofacetReflection) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	return sampleMicrofacetReflection(lobe.Distribution, lobe.fresnel, rnd, outgoing)
+}
+
+func (lobe *schlickMicrofacetReflection) Pdf(outgoing Vector, incoming Vector) float64 {
+	return microfacetReflectionPdf(lobe.Distribution, outgoing, incoming)
+}
+
+func (lobe *schlickMicrofacetReflection) Albedo() Color {
+	return lobe.F0
+}
+
+// BSDF whose transmitted light is tinted.
+type tintedTransmission struct {
+	BSDF
+	Tint Color
+}
+
+func (lobe *tintedTransmission) Evaluate(outgoing Vector, incoming Vector) Color {
+	f := lobe.BSDF.Evaluate(outgoing, incoming)
+	if !sameHemisphere(outgoing, incoming) {
+		f = MultiplyColor(lobe.Tint, f)
+	}
+
+	return f
+}
+
+func (lobe *tintedTransmission) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	sample, ok := lobe.BSDF.Sample(rnd, outgoing)
+	if ok && !sameHemisphere(outgoing, sample.Incoming) {
+		sample.Weight = MultiplyColor(lobe.Tint, sample.Weight)
+	}
+
+	return sample, ok
+}
+
+// Lobe under the clearcoat, scaled by Scale and attenuated by light reflected by the coat in both directions.
+type coatedLobe struct {
+	BSDF
+	Scale     Color
+	Clearcoat float64
+}
+
+func (lobe *coatedLobe) attenuation(outgoing Vector, incoming Vector) float64 {
+	coat := func(v Vector) float64 {
+		return 1.0 - lobe.Clearcoat*schlickFresnel(math.Abs(v.Z), clearcoatF0)
+	}
+
+	return coat(outgoing) * coat(incoming)
+}
+
+func (lobe *coatedLobe) Evaluate(outgoing Vector, incoming Vector) Color {
+	f := MultiplyColor(lobe.Scale, lobe.BSDF.Evaluate(outgoing, incoming))
+
+	return MultiplyScalar(lobe.attenuation(outgoing, incoming), f)
+}
+
+func (lobe *coatedLobe) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	sample, ok := lobe.BSDF.Sample(rnd, outgoing)
+	if ok {
+		sample.Weight = MultiplyScalar(lobe.attenuation(outgoing, sample.Incoming), MultiplyColor(lobe.Scale, sample.Weight))
+	}
+
+	return sample, ok
+}
+
+func (lobe *coatedLobe) Albedo() Color {
+	return MultiplyColor(lobe.Scale, lobe.BSDF.Albedo())
+}
+
+// Reflectance of the clearcoat at normal incidence, which is of an index of refraction 1.5.
+const clearcoatF0 = 0.04
+
+// Generalized Trowbridge-Reitz distribution of gamma 1 (Burley, 2012), which has a longer tail than GGX.
+type GTR1Distribution struct {
+	Alpha float64
+}
+
+func CreateGTR1Distribution(alpha float64) GTR1Distribution {
+	return GTR1Distribution{Alpha: math.Max(minimumRoughnessAlpha, alpha)}
+}
+
+func (distribution GTR1Distribution) D(m Vector) float64 {
+	if m.Z <= 0.0 {
+		return 0.0
+	}
+
+	a2 := distribution.Alpha * distribution.Alpha
+
+	return (a2 - 1.0) / (math.Pi * math.Log(a2) * (1.0 + (a2-1.0)*m.Z*m.Z))
+}
+
+// Sample a microfacet normal with density D(m) cos.
+func (distribution GTR1Distribution) Sample(u1 float64, u2 float64) Vector {
+	a2 := distribution.Alpha * distribution.Alpha
+	cos2Theta := (1.0 - math.Pow(a2, 1.0-u1)) / (1.0 - a2)
+	cosTheta := math.Sqrt(math.Max(0.0, cos2Theta))
+	sinTheta := math.Sqrt(math.Max(0.0, 1.0-cos2Theta))
+	phi := 2.0 * math.Pi * u2
+
+	return Vector{X: sinTheta * math.Cos(phi), Y: sinTheta * math.Sin(phi), Z: cosTheta}
+}
+
+// Clear coat of the principled BSDF, whose masking is of GGX of a fixed roughness.
+type clearcoatLobe struct {
+	Distribution GTR1Distribution
+	Weight       float64
+}
+
+var clearcoatMasking = GGXDistribution{AlphaX: 0.25, AlphaY: 0.25}
+
+func (lobe *clearcoatLobe) Evaluate(outgoing Vector, incoming Vector) Color {
+	if !sameHemisphere(outgoing, incoming) {
+		return CreateDefaultColor(Black)
+	}
+	outgoing, incoming = toUpperHemisphere(outgoing, incoming)
+
+	m := Normalize(Add(outgoing, incoming))
+	d := lobe.Distribution.D(m)
+	g := clearcoatMasking.G(outgoing, incoming)
+	f := schlickFresnel(Dot(outgoing, m), clearcoatF0)
+
+	return MultiplyScalar(lobe.Weight*d*g*f/(4.0*outgoing.Z*incoming.Z), CreateDefaultColor(White))
+}
+
+func (lobe *clearcoatLobe) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	sign := 1.0
+	if outgoing.Z < 0.0 {
+		sign = -1.0
+	}
+
+	m := lobe.Distribution.Sample(rnd.Float64(), rnd.Float64())
+	incoming := Multiply(sign, reflectAbout(Multiply(sign, outgoing), m))
+
+	pdf := lobe.Pdf(outgoing, incoming)
+	if pdf <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	weight := MultiplyScalar(math.Abs(incoming.Z)/pdf, lobe.Evaluate(outgoing, incoming))
+
+	return BSDFSample{Incoming: incoming, Weight: weight, Pdf: pdf}, true
+}
+
+func (lobe *clearcoatLobe) Pdf(outgoing Vector, incoming Vector) float64 {
+	if !sameHemisphere(outgoing, incoming) {
+		return 0.0
+	}
+	outgoing, incoming = toUpperHemisphere(outgoing, incoming)
+
+	m := Normalize(Add(outgoing, incoming))
+
+	return lobe.Distribution.D(m) * m.Z / (4.0 * Dot(outgoing, m))
+}
+
+func (lobe *clearcoatLobe) Albedo() Color {
+	return MultiplyScalar(lobe.Weight*clearcoatF0, CreateDefaultColor(White))
+}
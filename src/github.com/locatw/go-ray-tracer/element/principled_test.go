@@ -0,0 +1,111 @@
+package element
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestPrincipledBSDF(t *testing.T) {
+	outgoings := []Vector{
+		{X: 0.0, Y: 0.0, Z: 1.0},
+		Normalize(Vector{X: 1.0, Y: 0.5, Z: 1.0}),
+		Normalize(Vector{X: 1.0, Y: 0.0, Z: 0.1}),
+	}
+
+	t.Run("When a white surface is lit uniformly", func(t *testing.T) {
+		modifiers := map[string]func(p *PrincipledParameters){
+			"dielectric":  func(p *PrincipledParameters) {},
+			"smooth":      func(p *PrincipledParameters) { p.Roughness = 0.05 },
+			"rough":       func(p *PrincipledParameters) { p.Roughness = 1.0; p.Specular = 1.0 },
+			"metal":       func(p *PrincipledParameters) { p.Metallic = 1.0; p.Roughness = 0.3 },
+			"anisotropic": func(p *PrincipledParameters) { p.Metallic = 1.0; p.Anisotropic = 1.0 },
+			"sheen":       func(p *PrincipledParameters) { p.Sheen = 1.0; p.Roughness = 1.0 },
+			"clearcoat":   func(p *PrincipledParameters) { p.Clearcoat = 1.0; p.ClearcoatGloss = 0.5; p.Metallic = 0.5 },
+			"glass":       func(p *PrincipledParameters) { p.Transmission = 1.0; p.Roughness = 0.2 },
+			"all": func(p *PrincipledParameters) {
+				*p = PrincipledParameters{
+					BaseColor: CreateDefaultColor(White), Metallic: 0.3, Roughness: 0.4, Specular: 1.0, SpecularTint: 1.0,
+					Sheen: 1.0, SheenTint: 1.0, Clearcoat: 1.0, ClearcoatGloss: 0.2, Transmission: 0.5, Anisotropic: 0.5,
+				}
+			},
+		}
+
+		for name, modify := range modifiers {
+			parameters := CreateDefaultPrincipledParameters()
+			parameters.BaseColor = CreateDefaultColor(White)
+			modify(&parameters)
+			bsdf := CreatePrincipledBSDF(parameters)
+
+			for _, outgoing := range outgoings {
+				albedo := checkBSDFSamples(t, bsdf, outgoing, 40000)
+
+				t.Run(fmt.Sprintf("it does not reflect more light than received (%s, %v)", name, outgoing), func(t *testing.T) {
+					if 1.01 < maxComponent(albedo) {
+						t.Errorf("got: %v, want: at most 1", albedo)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("When a surface is a smooth metal", func(t *testing.T) {
+		parameters := CreateDefaultPrincipledParameters()
+		parameters.BaseColor = Color{R: 0.9, G: 0.6, B: 0.2}
+		parameters.Metallic = 1.0
+		parameters.Roughness = 0.1
+		bsdf := CreatePrincipledBSDF(parameters)
+
+		albedo := checkBSDFSamples(t, bsdf, outgoings[0], 10000)
+
+		t.Run("it reflects base color at normal incidence", func(t *testing.T) {
+			if 0.03 < math.Abs(float64(albedo.R-0.9)) || 0.03 < math.Abs(float64(albedo.B-0.2)) {
+				t.Errorf("got: %v, want: %v", albedo, parameters.BaseColor)
+			}
+		})
+	})
+
+	t.Run("When a surface is diffuse", func(t *testing.T) {
+		parameters := CreateDefaultPrincipledParameters()
+		parameters.Specular = 0.0
+		parameters.SheenTint = 0.0
+		bsdf := CreatePrincipledBSDF(parameters)
+
+		albedo := checkBSDFSamples(t, bsdf, outgoings[1], 40000)
+
+		// light reflected by the interface at grazing angles does not reach the diffuse layer
+		t.Run("it reflects nearly base color", func(t *testing.T) {
+			if albedo.G < 0.9*0.8 || 0.8 < albedo.G {
+				t.Errorf("got: %v, want: %v", albedo, parameters.BaseColor)
+			}
+		})
+	})
+
+	t.Run("When samples are rejected", func(t *testing.T) {
+		parameters := CreateDefaultPrincipledParameters()
+		parameters.Metallic = 0.5
+		parameters.Clearcoat = 1.0
+		parameters.Transmission = 0.5
+		bsdf := CreatePrincipledBSDF(parameters)
+
+		accepted := 0
+		count := 100000
+		rnd := rand.New(rand.NewSource(3))
+		for i := 0; i < count; i++ {
+			if _, ok := bsdf.Sample(rnd, outgoings[1]); ok {
+				accepted++
+			}
+		}
+
+		t.Run("Pdf integrates to the rate of accepted samples", func(t *testing.T) {
+			expected := float64(accepted) / float64(count)
+			if integral := integratePdf(bsdf, outgoings[1], 400000); 0.02 < math.Abs(integral-expected) {
+				t.Errorf("got: %f, want: %f", integral, expected)
+			}
+		})
+	})
+}
@@ -0,0 +1,177 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Coordinates which procedural textures use.
+type TextureMapping int
+
+const (
+	// (U, V, 0) of the surface.
+	UVMapping TextureMapping = iota
+	// Position in world space, which does not depend on the parameterization of the surface.
+	PositionMapping
+)
+
+func (mapping TextureMapping) apply(point TexturePoint) Vector {
+	if mapping == PositionMapping {
+		return point.Position
+	}
+
+	return Vector{X: point.UV.U, Y: point.UV.V, Z: 0.0}
+}
+
+// Alternating Color1 and Color2 in cells whose size is 1 / Frequency.
+type CheckerboardTexture struct {
+	Color1    Color
+	Color2    Color
+	Frequency float64
+	Mapping   TextureMapping
+}
+
+func (texture *CheckerboardTexture) Evaluate(point TexturePoint) Color {
+	p := Multiply(texture.Frequency, texture.Mapping.apply(point))
+
+	sum := int(math.Floor(p.X)) + int(math.Floor(p.Y))
+	if texture.Mapping == PositionMapping {
+		sum += int(math.Floor(p.Z))
+	}
+
+	if sum%2 == 0 {
+		return texture.Color1
+	}
+
+	return texture.Color2
+}
+
+// Fractal Brownian motion of Perlin noise, which blends Color1 and Color2.
+type NoiseTexture struct {
+	Color1    Color
+	Color2    Color
+	Frequency float64
+	// Number of octaves, where each octave doubles the frequency and halves the amplitude.
+	Octaves int
+	Mapping TextureMapping
+}
+
+func (texture *NoiseTexture) Evaluate(point TexturePoint) Color {
+	p := Multiply(texture.Frequency, texture.Mapping.apply(point))
+	t := 0.5 + 0.5*FractalNoise(p, texture.Octaves)
+
+	return LerpColor(math.Max(0.0, math.Min(1.0, t)), texture.Color1, texture.Color2)
+}
+
+// Sum of octaves of Perlin noise, normalized into about [-1, 1].
+func FractalNoise(p Vector, octaves int) float64 {
+	sum := 0.0
+	amplitude := 1.0
+	total := 0.0
+	for i := 0; i < octaves; i++ {
+		sum += amplitude * PerlinNoise(p)
+		total += amplitude
+		amplitude *= 0.5
+		p = Multiply(2.0, p)
+	}
+
+	if total == 0.0 {
+		return 0.0
+	}
+
+	return sum / total
+}
+
+// Improved Perlin noise (Perlin, 2002), which is 0 on integer lattice points and in about [-1, 1].
+func PerlinNoise(p Vector) float64 {
+	xf := math.Floor(p.X)
+	yf := math.Floor(p.Y)
+	zf := math.Floor(p.Z)
+	x := p.X - xf
+	y := p.Y - yf
+	z := p.Z - zf
+
+	xi := int(xf) & 255
+	yi := int(yf) & 255
+	zi := int(zf) & 255
+
+	u := fade(x)
+	v := fade(y)
+	w := fade(z)
+
+	a := perlinPermutation[xi] + yi
+	aa := perlinPermutation[a] + zi
+	ab := perlinPermutation[a+1] + zi
+	b := perlinPermutation[xi+1] + yi
+	ba := perlinPermutation[b] + zi
+	bb := perlinPermutation[b+1] + zi
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, grad(perlinPermutation[aa], x, y, z), grad(perlinPermutation[ba], x-1, y, z)),
+			lerp(u, grad(perlinPermutation[ab], x, y-1, z), grad(perlinPermutation[bb], x-1, y-1, z))),
+		lerp(v,
+			lerp(u, grad(perlinPermutation[aa+1], x, y, z-1), grad(perlinPermutation[ba+1], x-1, y, z-1)),
+			lerp(u, grad(perlinPermutation[ab+1], x, y-1, z-1), grad(perlinPermutation[bb+1], x-1, y-1, z-1))))
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6.0-15.0) + 10.0)
+}
+
+func lerp(t float64, a float64, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// Dot product of a distance vector and one of 12 gradients on edges of a cube.
+func grad(hash int, x float64, y float64, z float64) float64 {
+	h := hash & 15
+
+	u := y
+	if h < 8 {
+		u = x
+	}
+
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+
+	return u + v
+}
+
+// Permutation of Perlin's reference implementation, repeated twice to avoid wrapping indices.
+var perlinPermutation = func() [512]int {
+	p := [256]int{
+		151, 160, 137, 91, 90, 15, 131, 13, 201, 95, 96, 53, 194, 233, 7, 225, 140, 36, 103, 30, 69, 142,
+		8, 99, 37, 240, 21, 10, 23, 190, 6, 148, 247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117,
+		35, 11, 32, 57, 177, 33, 88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175, 74, 165, 71,
+		134, 139, 48, 27, 166, 77, 146, 158, 231, 83, 111, 229, 122, 60, 211, 133, 230, 220, 105, 92, 41,
+		55, 46, 245, 40, 244, 102, 143, 54, 65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89,
+		18, 169, 200, 196, 135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64, 52, 217, 226,
+		250, 124, 123, 5, 202, 38, 147, 118, 126, 255, 82, 85, 212, 207, 206, 59, 227, 47, 16, 58, 17, 182,
+		189, 28, 42, 223, 183, 170, 213, 119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43,
+		172, 9, 129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104, 218, 246, 97,
+		228, 251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241, 81, 51, 145, 235, 249, 14, 239,
+		107, 49, 192, 214, 31, 181, 199, 106, 157, 184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254,
+		138, 236, 205, 93, 222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180,
+	}
+
+	var permutation [512]int
+	for i := range permutation {
+		permutation[i] = p[i%256]
+	}
+
+	return permutation
+}()
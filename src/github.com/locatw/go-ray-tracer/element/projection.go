@@ -0,0 +1,97 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Projection of a camera, which maps a point on the film to a ray in the camera space, where X is the
+// right, Y is the up and Z is the direction of the camera. The film coordinates are in [0, 1] from the
+// top left, and aspect is the ratio of the width of the film to its height.
+// It returns false if the point is not seen by the projection.
+type Projection interface {
+	CreateLocalRay(camera *Camera, filmX float64, filmY float64, aspect float64) (origin Vector, direction Vector, ok bool)
+}
+
+// Pinhole projection whose vertical field of view is Fov of the camera.
+type PerspectiveProjection struct{}
+
+func (projection PerspectiveProjection) CreateLocalRay(camera *Camera, filmX float64, filmY float64, aspect float64) (Vector, Vector, bool) {
+	x, y := toNormalizedFilm(filmX, filmY)
+	scale := math.Tan(camera.Fov / 2.0)
+
+	return CreateZeroVector(), Vector{X: x * scale * aspect, Y: y * scale, Z: 1.0}, true
+}
+
+// Projection of parallel rays, which sees the region of Height and its width by aspect.
+type OrthographicProjection struct {
+	Height float64
+}
+
+func (projection OrthographicProjection) CreateLocalRay(camera *Camera, filmX float64, filmY float64, aspect float64) (Vector, Vector, bool) {
+	x, y := toNormalizedFilm(filmX, filmY)
+	halfHeight := projection.Height / 2.0
+
+	return Vector{X: x * halfHeight * aspect, Y: y * halfHeight, Z: 0.0}, Vector{X: 0.0, Y: 0.0, Z: 1.0}, true
+}
+
+// Equidistant fisheye projection, whose angle from the direction is proportional to the distance from
+// the center of the film. The image circle fits the height of the film, and its diameter covers Fov of
+// the camera, which can be up to 2 pi. Points outside the circle are not seen.
+type FisheyeProjection struct{}
+
+func (projection FisheyeProjection) CreateLocalRay(camera *Camera, filmX float64, filmY float64, aspect float64) (Vector, Vector, bool) {
+	x, y := toNormalizedFilm(filmX, filmY)
+	x *= aspect
+
+	r := math.Sqrt(x*x + y*y)
+	if 1.0 < r {
+		return Vector{}, Vector{}, false
+	}
+
+	theta := r * camera.Fov / 2.0
+	if r == 0.0 {
+		return CreateZeroVector(), Vector{X: 0.0, Y: 0.0, Z: 1.0}, true
+	}
+
+	sinTheta := math.Sin(theta)
+
+	return CreateZeroVector(), Vector{X: sinTheta * x / r, Y: sinTheta * y / r, Z: math.Cos(theta)}, true
+}
+
+// 360-degree projection whose film spans the longitude horizontally and the latitude vertically, with the
+// direction of the camera at the center. Stereo projection puts the left eye on the top half of the
+// film and the right eye on the bottom half, whose eyes are apart by EyeSeparation and rotate with the
+// longitude (omni-directional stereo).
+type EquirectangularProjection struct {
+	Stereo        bool
+	EyeSeparation float64
+}
+
+func (projection EquirectangularProjection) CreateLocalRay(camera *Camera, filmX float64, filmY float64, aspect float64) (Vector, Vector, bool) {
+	eye := 0.0
+	if projection.Stereo {
+		if filmY < 0.5 {
+			eye = -1.0
+			filmY = 2.0 * filmY
+		} else {
+			eye = 1.0
+			filmY = 2.0*filmY - 1.0
+		}
+	}
+
+	phi := 2.0 * math.Pi * (filmX - 0.5)
+	latitude := math.Pi * (0.5 - filmY)
+
+	direction := Vector{X: math.Sin(phi) * math.Cos(latitude), Y: math.Sin(latitude), Z: math.Cos(phi) * math.Cos(latitude)}
+	// offset toward the right of the longitude
+	origin := Vector{X: eye * projection.EyeSeparation / 2.0 * math.Cos(phi), Y: 0.0, Z: -eye * projection.EyeSeparation / 2.0 * math.Sin(phi)}
+
+	return origin, direction, true
+}
+
+// Map film coordinates to [-1, 1] from the bottom left.
+func toNormalizedFilm(filmX float64, filmY float64) (float64, float64) {
+	return 2.0*filmX - 1.0, 1.0 - 2.0*filmY
+}
@@ -0,0 +1,116 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestProjectionCreateLocalRay(t *testing.T) {
+	camera := CreateCamera(CreateZeroVector(), CreateAxisVector(ZAxis), CreateAxisVector(YAxis), math.Pi)
+	forward := Vector{X: 0.0, Y: 0.0, Z: 1.0}
+
+	patterns := []struct {
+		name              string
+		projection        Projection
+		filmX, filmY      float64
+		aspect            float64
+		expectedOrigin    Vector
+		expectedDirection Vector
+		expectedOk        bool
+	}{
+		{
+			name:              "perspective at the center",
+			projection:        PerspectiveProjection{},
+			filmX:             0.5,
+			filmY:             0.5,
+			aspect:            1.0,
+			expectedOrigin:    CreateZeroVector(),
+			expectedDirection: forward,
+			expectedOk:        true,
+		},
+		{
+			name:              "orthographic at the top right",
+			projection:        OrthographicProjection{Height: 4.0},
+			filmX:             1.0,
+			filmY:             0.0,
+			aspect:            2.0,
+			expectedOrigin:    Vector{X: 4.0, Y: 2.0, Z: 0.0},
+			expectedDirection: forward,
+			expectedOk:        true,
+		},
+		{
+			name:              "fisheye at the top of the image circle",
+			projection:        FisheyeProjection{},
+			filmX:             0.5,
+			filmY:             0.0,
+			aspect:            2.0,
+			expectedOrigin:    CreateZeroVector(),
+			expectedDirection: Vector{X: 0.0, Y: 1.0, Z: 0.0},
+			expectedOk:        true,
+		},
+		{
+			name:       "fisheye outside the image circle",
+			projection: FisheyeProjection{},
+			filmX:      0.0,
+			filmY:      0.5,
+			aspect:     2.0,
+			expectedOk: false,
+		},
+		{
+			name:              "equirectangular at a quarter of the width",
+			projection:        EquirectangularProjection{},
+			filmX:             0.75,
+			filmY:             0.5,
+			aspect:            2.0,
+			expectedOrigin:    CreateZeroVector(),
+			expectedDirection: Vector{X: 1.0, Y: 0.0, Z: 0.0},
+			expectedOk:        true,
+		},
+		{
+			name:              "equirectangular at the top",
+			projection:        EquirectangularProjection{},
+			filmX:             0.3,
+			filmY:             0.0,
+			aspect:            2.0,
+			expectedOrigin:    CreateZeroVector(),
+			expectedDirection: Vector{X: 0.0, Y: 1.0, Z: 0.0},
+			expectedOk:        true,
+		},
+		{
+			name:              "left eye of stereo equirectangular looking right",
+			projection:        EquirectangularProjection{Stereo: true, EyeSeparation: 0.2},
+			filmX:             0.75,
+			filmY:             0.25,
+			aspect:            1.0,
+			expectedOrigin:    Vector{X: 0.0, Y: 0.0, Z: 0.1},
+			expectedDirection: Vector{X: 1.0, Y: 0.0, Z: 0.0},
+			expectedOk:        true,
+		},
+		{
+			name:              "right eye of stereo equirectangular looking forward",
+			projection:        EquirectangularProjection{Stereo: true, EyeSeparation: 0.2},
+			filmX:             0.5,
+			filmY:             0.75,
+			aspect:            1.0,
+			expectedOrigin:    Vector{X: 0.1, Y: 0.0, Z: 0.0},
+			expectedDirection: forward,
+			expectedOk:        true,
+		},
+	}
+
+	for _, pattern := range patterns {
+		origin, direction, ok := pattern.projection.CreateLocalRay(&camera, pattern.filmX, pattern.filmY, pattern.aspect)
+
+		if ok != pattern.expectedOk {
+			t.Errorf("CreateLocalRay(%f, %f) of %s must return %t, actual is %t", pattern.filmX, pattern.filmY, pattern.name, pattern.expectedOk, ok)
+			continue
+		}
+
+		if ok && (!origin.NearlyEqual(pattern.expectedOrigin) || !Normalize(direction).NearlyEqual(pattern.expectedDirection)) {
+			t.Errorf("CreateLocalRay(%f, %f) of %s must return %v and %v, actual is %v and %v",
+				pattern.filmX, pattern.filmY, pattern.name, pattern.expectedOrigin, pattern.expectedDirection, origin, direction)
+		}
+	}
+}
@@ -1,9 +1,6 @@
 package element
 
 import (
-	"math"
-	"math/rand"
-
 	mathex "github.com/locatw/go-ray-tracer/math"
 	. "github.com/locatw/go-ray-tracer/vector"
 )
@@ -11,75 +8,88 @@ import (
 type Ray struct {
 	Origin    Vector
 	Direction Vector
+	// Time in the shutter interval of the camera, where moving shapes are intersected.
+	Time float64
 }
 
 type HitInfo struct {
 	Object   Shape
 	Position Vector
-	Normal   Vector
-	T        float64
+	// Geometric normal of the surface, which decides the sides of the surface.
+	Normal Vector
+	// Normal used for shading, such as normals interpolated over a triangle.
+	ShadingNormal Vector
+	T             float64
+	// Surface parameterization and partial derivatives of the position with respect to it.
+	UV   UV
+	Dpdu Vector
+	Dpdv Vector
+	// Only set by shapes which are made of triangles.
+	Barycentric Barycentric
+	// Time of the ray, which rays scattered from the hit inherit. It is set by Scene, not by shapes.
+	Time float64
 }
 
-func CreateRay(origin Vector, direction Vector) Ray {
-	return Ray{Origin: origin, Direction: Normalize(direction)}
+// Return the point where textures are evaluated.
+func (hitInfo *HitInfo) TexturePoint() TexturePoint {
+	return TexturePoint{UV: hitInfo.UV, Position: hitInfo.Position}
 }
 
-func CreateDiffuseRay(rnd *rand.Rand, ray Ray, hitInfo *HitInfo) Ray {
-	// base vectors in tangent space
-	u := Normalize(Cross(hitInfo.Normal, ray.Direction))
-	v := Normalize(Cross(u, hitInfo.Normal))
-	n := hitInfo.Normal
-
-	r := math.Sqrt(rnd.Float64())
-	theta := 2.0 * math.Pi * rnd.Float64()
-	x := r * math.Cos(theta)
-	y := r * math.Sin(theta)
-	z := math.Sqrt(math.Max(0.0, 1.0-x*x-y*y))
-
-	origin := Add(hitInfo.Position, Multiply(10000.0*mathex.Epsilon(), hitInfo.Normal))
-	dir := AddAll(Multiply(x, u), Multiply(y, v), Multiply(z, n))
+// Return the geometric normal flipped to the side of the shading normal.
+func (hitInfo *HitInfo) orientedNormal() Vector {
+	if Dot(hitInfo.Normal, hitInfo.ShadingNormal) < 0.0 {
+		return Multiply(-1.0, hitInfo.Normal)
+	}
 
-	return CreateRay(origin, dir)
+	return hitInfo.Normal
 }
 
-func CreateReflectRay(ray Ray, hitInfo *HitInfo) Ray {
-	dir := Multiply(2.0*Dot(Multiply(-1.0, ray.Direction), hitInfo.Normal), hitInfo.Normal)
-	dir = Subtract(dir, Multiply(-1.0, ray.Direction))
+// Return the shading frame to scatter light toward outgoing in world space. If the shading normal and the
+// geometric normal disagree on the side of outgoing, the geometric normal is used so that the viewer never
+// sees the back of the shading surface.
+func (hitInfo *HitInfo) ShadingFrameToward(outgoing Vector) Frame {
+	frame := hitInfo.ShadingFrame()
 
-	origin := Add(hitInfo.Position, Multiply(10000.0*mathex.Epsilon(), hitInfo.Normal))
+	normal := hitInfo.orientedNormal()
+	if Dot(outgoing, frame.Normal)*Dot(outgoing, normal) <= 0.0 {
+		return CreateFrameFromTangent(normal, hitInfo.Dpdu)
+	}
 
-	return CreateRay(origin, dir)
+	return frame
 }
 
-func CreateRefractRay(ray Ray, hitInfo *HitInfo) (Ray, bool) {
-	if hitInfo.Object.GetMaterial().IndexOfRefraction == nil {
-		panic("cannot create refract ray because object does not have index of refraction.")
-	}
+// Whether a pair of directions in world space is on the same sides of the geometric surface as of the shading
+// surface of frame. Otherwise, light would leak through the surface, so the pair must not contribute.
+func (hitInfo *HitInfo) IsConsistentScattering(frame Frame, outgoing Vector, incoming Vector) bool {
+	shadingSameSide := 0.0 < Dot(outgoing, frame.Normal)*Dot(incoming, frame.Normal)
+	geometricSameSide := 0.0 < Dot(outgoing, hitInfo.Normal)*Dot(incoming, hitInfo.Normal)
+
+	return shadingSameSide == geometricSameSide
+}
 
-	inObject := Dot(Multiply(-1.0, ray.Direction), hitInfo.Normal) < 0.0
-	iot := *hitInfo.Object.GetMaterial().IndexOfRefraction
+// Return the shading frame, whose tangent follows Dpdu so that anisotropic materials are aligned to the surface.
+func (hitInfo *HitInfo) ShadingFrame() Frame {
+	return CreateFrameFromTangent(hitInfo.ShadingNormal, hitInfo.Dpdu)
+}
 
-	normal := hitInfo.Normal
-	if inObject {
-		normal = Multiply(-1.0, hitInfo.Normal)
-	}
+func CreateRay(origin Vector, direction Vector) Ray {
+	return Ray{Origin: origin, Direction: Normalize(direction)}
+}
 
-	eta := 1.0 / iot
-	if inObject {
-		eta = iot
+// Create a ray from a hit position, offset to the side of the surface where direction points.
+func CreateScatteredRay(hitInfo *HitInfo, direction Vector) Ray {
+	offset := Multiply(10000.0*mathex.Epsilon(), hitInfo.Normal)
+	if Dot(direction, hitInfo.Normal) < 0.0 {
+		offset = Multiply(-1.0, offset)
 	}
 
-	a := Dot(Multiply(-1.0, ray.Direction), normal)
-	d := 1.0 - math.Pow(eta, 2.0)*(1.0-math.Pow(a, 2.0))
-
-	if 0.0 <= d {
-		dir := Multiply(-eta, Subtract(Multiply(-1.0, ray.Direction), Multiply(a, normal)))
-		dir = Subtract(dir, Multiply(math.Sqrt(d), normal))
+	ray := CreateRay(Add(hitInfo.Position, offset), direction)
+	ray.Time = hitInfo.Time
 
-		origin := Subtract(hitInfo.Position, Multiply(10000.0*mathex.Epsilon(), normal))
+	return ray
+}
 
-		return CreateRay(origin, dir), false
-	} else {
-		return Ray{}, true
-	}
+// Create a ray from a hit position toward a light.
+func CreateShadowRay(hitInfo *HitInfo, direction Vector) Ray {
+	return CreateScatteredRay(hitInfo, direction)
 }
@@ -27,36 +27,58 @@ func TestCreateRay(t *testing.T) {
 	}
 }
 
-func TestCreateReflectRay(t *testing.T) {
-	inRay := CreateRay(Vector{X: -1.0, Y: 1.0, Z: -1.0}, Vector{X: 1.0, Y: -1.0, Z: 1.0})
-	plane :=
-		Plane{
-			Center:   CreateZeroVector(),
-			Normal:   CreateAxisVector(YAxis),
-			Material: CreateDefaultMaterial(),
-		}
-	hitInfo :=
-		HitInfo{
-			Object:   &plane,
-			Position: CreateZeroVector(),
-			Normal:   CreateAxisVector(YAxis),
-			T:        1.0,
-		}
-	expected :=
-		Ray{
-			Origin:    Vector{X: 0.0, Y: 10000.0 * epsilon, Z: 0.0},
-			Direction: Normalize(Vector{X: 1.0, Y: 1.0, Z: 1.0}),
+func TestHitInfoIsConsistentScattering(t *testing.T) {
+	// the shading normal is tilted by 45 degrees from the geometric normal
+	hitInfo := HitInfo{
+		Normal:        CreateAxisVector(YAxis),
+		ShadingNormal: Normalize(Vector{X: 1.0, Y: 1.0, Z: 0.0}),
+		Dpdu:          CreateAxisVector(ZAxis),
+	}
+	outgoing := Normalize(Vector{X: 0.0, Y: 1.0, Z: 1.0})
+	frame := hitInfo.ShadingFrameToward(outgoing)
+
+	patterns := []struct {
+		incoming Vector
+		expected bool
+	}{
+		// above both surfaces
+		{incoming: Normalize(Vector{X: 0.0, Y: 1.0, Z: -1.0}), expected: true},
+		// above the shading surface, but below the geometric surface
+		{incoming: Normalize(Vector{X: 1.0, Y: -0.5, Z: 0.0}), expected: false},
+		// below both surfaces
+		{incoming: Normalize(Vector{X: -1.0, Y: -1.5, Z: 0.0}), expected: true},
+	}
+
+	for _, pattern := range patterns {
+		actual := hitInfo.IsConsistentScattering(frame, outgoing, pattern.incoming)
+
+		if actual != pattern.expected {
+			t.Errorf("IsConsistentScattering(%v, %v) must return %t, actual %t", outgoing, pattern.incoming, pattern.expected, actual)
 		}
+	}
+}
 
-	reflectedRay := CreateReflectRay(inRay, &hitInfo)
+func TestHitInfoShadingFrameToward(t *testing.T) {
+	hitInfo := HitInfo{
+		Normal:        CreateAxisVector(YAxis),
+		ShadingNormal: Normalize(Vector{X: 1.0, Y: 1.0, Z: 0.0}),
+		Dpdu:          CreateAxisVector(ZAxis),
+	}
 
-	if !reflectedRay.Origin.NearlyEqual(expected.Origin) {
-		t.Errorf("CreateReflectRay(%v, %v) must return reflected ray which origin is %v, actual origin is %v",
-			inRay, hitInfo, expected.Origin, reflectedRay.Origin)
+	patterns := []struct {
+		outgoing Vector
+		expected Vector
+	}{
+		{outgoing: Normalize(Vector{X: 0.0, Y: 1.0, Z: 1.0}), expected: hitInfo.ShadingNormal},
+		// the viewer is above the geometric surface, but behind the shading surface
+		{outgoing: Normalize(Vector{X: -1.0, Y: 0.5, Z: 0.0}), expected: hitInfo.Normal},
 	}
 
-	if !reflectedRay.Direction.NearlyEqual(expected.Direction) {
-		t.Errorf("CreateReflectRay(%v, %v) must return reflected ray which direction is %v, actual direction is %v",
-			inRay, hitInfo, expected.Direction, reflectedRay.Direction)
+	for _, pattern := range patterns {
+		frame := hitInfo.ShadingFrameToward(pattern.outgoing)
+
+		if !frame.Normal.NearlyEqual(pattern.expected) {
+			t.Errorf("ShadingFrameToward(%v) must return a frame around %v, actual %v", pattern.outgoing, pattern.expected, frame.Normal)
+		}
 	}
 }
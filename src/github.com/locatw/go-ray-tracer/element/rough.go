@@ -0,0 +1,249 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Rough metal made of GGX microfacets, which reflects on the same side of the surface as the viewer.
+type RoughConductorBSDF struct {
+	Distribution GGXDistribution
+	// Complex index of refraction.
+	Eta Color
+	K   Color
+}
+
+// Create a rough conductor from one of ConductorPresets. It returns false if the name is unknown.
+func CreateConductorPreset(name string, roughnessX float64, roughnessY float64) (*RoughConductorBSDF, bool) {
+	ior, ok := ConductorPresets[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &RoughConductorBSDF{Distribution: CreateGGXDistribution(roughnessX, roughnessY), Eta: ior.Eta, K: ior.K}, true
+}
+
+// Flip a pair of directions to the upper hemisphere, so that both sides of the surface look the same.
+func toUpperHemisphere(outgoing Vector, incoming Vector) (Vector, Vector) {
+	if outgoing.Z < 0.0 {
+		return Multiply(-1.0, outgoing), Multiply(-1.0, incoming)
+	}
+
+	return outgoing, incoming
+}
+
+func (bsdf *RoughConductorBSDF) fresnel(cosTheta float64) Color {
+	return FresnelConductor(cosTheta, bsdf.Eta, bsdf.K)
+}
+
+func (bsdf *RoughConductorBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	return evaluateMicrofacetReflection(bsdf.Distribution, bsdf.fresnel, outgoing, incoming)
+}
+
+func (bsdf *RoughConductorBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	return sampleMicrofacetReflection(bsdf.Distribution, bsdf.fresnel, rnd, outgoing)
+}
+
+func (bsdf *RoughConductorBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	return microfacetReflectionPdf(bsdf.Distribution, outgoing, incoming)
+}
+
+func (bsdf *RoughConductorBSDF) Albedo() Color {
+	return FresnelConductor(1.0, bsdf.Eta, bsdf.K)
+}
+
+// Reflection by microfacets of distribution, whose reflectance is given by fresnel for the cosine between
+// the incident direction and the microfacet normal.
+func evaluateMicrofacetReflection(distribution GGXDistribution, fresnel func(float64) Color, outgoing Vector, incoming Vector) Color {
+	if !sameHemisphere(outgoing, incoming) {
+		return CreateDefaultColor(Black)
+	}
+	outgoing, incoming = toUpperHemisphere(outgoing, incoming)
+
+	m := Normalize(Add(outgoing, incoming))
+	d := distribution.D(m)
+	g := distribution.G(outgoing, incoming)
+
+	return MultiplyScalar(d*g/(4.0*outgoing.Z*incoming.Z), fresnel(Dot(outgoing, m)))
+}
+
+func sampleMicrofacetReflection(distribution GGXDistribution, fresnel func(float64) Color, rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	sign := 1.0
+	if outgoing.Z < 0.0 {
+		sign = -1.0
+	}
+	wo := Multiply(sign, outgoing)
+	if wo.Z == 0.0 {
+		return BSDFSample{}, false
+	}
+
+	m := distribution.SampleVisibleNormal(wo, rnd.Float64(), rnd.Float64())
+	wi := reflectAbout(wo, m)
+	if wi.Z <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	pdf := distribution.VisiblePdf(wo, m) / (4.0 * Dot(wo, m))
+	// D and G1 of the density cancel the ones of the BSDF
+	scale := distribution.G(wo, wi) / distribution.G1(wo)
+
+	return BSDFSample{Incoming: Multiply(sign, wi), Weight: MultiplyScalar(scale, fresnel(Dot(wo, m))), Pdf: pdf}, true
+}
+
+func microfacetReflectionPdf(distribution GGXDistribution, outgoing Vector, incoming Vector) float64 {
+	if !sameHemisphere(outgoing, incoming) {
+		return 0.0
+	}
+	outgoing, incoming = toUpperHemisphere(outgoing, incoming)
+
+	m := Normalize(Add(outgoing, incoming))
+
+	return distribution.VisiblePdf(outgoing, m) / (4.0 * Dot(outgoing, m))
+}
+
+// Rough boundary of a transparent object made of GGX microfacets (Walter et al., 2007).
+// The normal points to the outside of the object, where the index of refraction is 1.
+type RoughDielectricBSDF struct {
+	Distribution      GGXDistribution
+	IndexOfRefraction float64
+}
+
+// Microfacet normal of a pair of directions, and the relative index of refraction for transmission.
+// It returns false if the pair is not connected by a microfacet facing both directions.
+func (bsdf *RoughDielectricBSDF) halfVector(outgoing Vector, incoming Vector) (Vector, float64, bool) {
+	if outgoing.Z == 0.0 || incoming.Z == 0.0 {
+		return Vector{}, 1.0, false
+	}
+
+	etap := 1.0
+	if !sameHemisphere(outgoing, incoming) {
+		etap = bsdf.IndexOfRefraction
+		if outgoing.Z < 0.0 {
+			etap = 1.0 / bsdf.IndexOfRefraction
+		}
+	}
+
+	m := Add(Multiply(etap, incoming), outgoing)
+	if m.Length() == 0.0 {
+		return Vector{}, etap, false
+	}
+	m = Normalize(m)
+	if m.Z < 0.0 {
+		m = Multiply(-1.0, m)
+	}
+
+	// discard microfacets which face away from either direction
+	if Dot(m, incoming)*incoming.Z < 0.0 || Dot(m, outgoing)*outgoing.Z < 0.0 {
+		return Vector{}, etap, false
+	}
+
+	return m, etap, true
+}
+
+func (bsdf *RoughDielectricBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	value, _ := bsdf.evaluate(outgoing, incoming)
+
+	return MultiplyScalar(value, CreateDefaultColor(White))
+}
+
+func (bsdf *RoughDielectricBSDF) evaluate(outgoing Vector, incoming Vector) (float64, float64) {
+	m, etap, ok := bsdf.halfVector(outgoing, incoming)
+	if !ok {
+		return 0.0, 0.0
+	}
+
+	d := bsdf.Distribution.D(m)
+	g := bsdf.Distribution.G(outgoing, incoming)
+	r := FresnelDielectric(Dot(outgoing, m), bsdf.IndexOfRefraction)
+	visiblePdf := bsdf.Distribution.VisiblePdf(outgoing, m)
+
+	if sameHemisphere(outgoing, incoming) {
+		value := d * g * r / math.Abs(4.0*outgoing.Z*incoming.Z)
+		pdf := visiblePdf / (4.0 * math.Abs(Dot(outgoing, m))) * r
+
+		return value, pdf
+	}
+
+	denominator := Dot(incoming, m) + Dot(outgoing, m)/etap
+	denominator *= denominator
+
+	// radiance is compressed into the smaller solid angle in the denser medium
+	value := d * (1.0 - r) * g * math.Abs(Dot(incoming, m)*Dot(outgoing, m)/(incoming.Z*outgoing.Z*denominator)) / (etap * etap)
+	pdf := visiblePdf * math.Abs(Dot(incoming, m)) / denominator * (1.0 - r)
+
+	return value, pdf
+}
+
+func (bsdf *RoughDielectricBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	if outgoing.Z == 0.0 {
+		return BSDFSample{}, false
+	}
+
+	m := bsdf.Distribution.SampleVisibleNormal(outgoing, rnd.Float64(), rnd.Float64())
+	r := FresnelDielectric(Dot(outgoing, m), bsdf.IndexOfRefraction)
+
+	var incoming Vector
+	if rnd.Float64() < r {
+		incoming = reflectAbout(outgoing, m)
+		if !sameHemisphere(outgoing, incoming) {
+			return BSDFSample{}, false
+		}
+	} else {
+		var ok bool
+		incoming, ok = refractAbout(outgoing, m, bsdf.IndexOfRefraction)
+		if !ok || sameHemisphere(outgoing, incoming) || incoming.Z == 0.0 {
+			return BSDFSample{}, false
+		}
+	}
+
+	value, pdf := bsdf.evaluate(outgoing, incoming)
+	if pdf <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	weight := MultiplyScalar(value*math.Abs(incoming.Z)/pdf, CreateDefaultColor(White))
+
+	return BSDFSample{Incoming: incoming, Weight: weight, Pdf: pdf}, true
+}
+
+func (bsdf *RoughDielectricBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	_, pdf := bsdf.evaluate(outgoing, incoming)
+
+	return pdf
+}
+
+func (bsdf *RoughDielectricBSDF) Albedo() Color {
+	return CreateDefaultColor(White)
+}
+
+func (bsdf *RoughConductorBSDF) ApplyTextures(textures *MaterialTextures, point TexturePoint) BSDF {
+	if textures.Roughness == nil {
+		return bsdf
+	}
+
+	textured := *bsdf
+	textured.Distribution = texturedDistribution(bsdf.Distribution, EvaluateFloatTexture(textures.Roughness, point))
+
+	return &textured
+}
+
+func (bsdf *RoughDielectricBSDF) ApplyTextures(textures *MaterialTextures, point TexturePoint) BSDF {
+	if textures.Roughness == nil {
+		return bsdf
+	}
+
+	textured := *bsdf
+	textured.Distribution = texturedDistribution(bsdf.Distribution, EvaluateFloatTexture(textures.Roughness, point))
+
+	return &textured
+}
+
+// Replace the roughness along the tangent of a distribution, keeping the ratio of the roughness along the bitangent.
+func texturedDistribution(distribution GGXDistribution, roughness float64) GGXDistribution {
+	ratio := math.Sqrt(distribution.AlphaY / distribution.AlphaX)
+
+	return CreateGGXDistribution(roughness, math.Min(1.0, roughness*ratio))
+}
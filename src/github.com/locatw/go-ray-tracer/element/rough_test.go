@@ -0,0 +1,186 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestGGXDistribution(t *testing.T) {
+	distributions := []GGXDistribution{
+		CreateGGXDistribution(0.5, 0.5),
+		CreateGGXDistribution(0.8, 0.3),
+	}
+
+	for _, distribution := range distributions {
+		rnd := rand.New(rand.NewSource(1))
+		w := Normalize(Vector{X: 0.5, Y: -0.3, Z: 0.6})
+
+		// projected areas of microfacets and of visible microfacets by uniform sampling of the hemisphere
+		area := 0.0
+		visibleArea := 0.0
+		count := 200000
+		for i := 0; i < count; i++ {
+			z := rnd.Float64()
+			r := math.Sqrt(1.0 - z*z)
+			phi := 2.0 * math.Pi * rnd.Float64()
+			m := Vector{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}
+
+			area += distribution.D(m) * m.Z * 2.0 * math.Pi
+			visibleArea += distribution.VisiblePdf(w, m) * 2.0 * math.Pi
+		}
+
+		if result := area / float64(count); 0.02 < math.Abs(result-1.0) {
+			t.Errorf("D of %v must integrate to 1 over projected area, actual %f", distribution, result)
+		}
+		if result := visibleArea / float64(count); 0.02 < math.Abs(result-1.0) {
+			t.Errorf("VisiblePdf of %v must integrate to 1, actual %f", distribution, result)
+		}
+
+		t.Run("When visible normals are sampled", func(t *testing.T) {
+			m := distribution.SampleVisibleNormal(w, 0.3, 0.7)
+
+			t.Run("it returns a unit normal facing w", func(t *testing.T) {
+				if 1e-9 < math.Abs(m.Length()-1.0) || m.Z <= 0.0 || Dot(m, w) <= 0.0 {
+					t.Errorf("got: %v, want: a unit normal facing %v", m, w)
+				}
+			})
+		})
+	}
+}
+
+func TestFresnelDielectric(t *testing.T) {
+	patterns := []struct {
+		cosTheta float64
+		eta      float64
+		expected float64
+	}{
+		{cosTheta: 1.0, eta: 1.5, expected: 0.04},
+		{cosTheta: -1.0, eta: 1.5, expected: 0.04},
+		{cosTheta: 0.0, eta: 1.5, expected: 1.0},
+		// beyond the critical angle inside the object
+		{cosTheta: -0.5, eta: 1.5, expected: 1.0},
+		{cosTheta: 0.5, eta: 1.0, expected: 0.0},
+	}
+
+	for _, pattern := range patterns {
+		result := FresnelDielectric(pattern.cosTheta, pattern.eta)
+		if 1e-9 < math.Abs(result-pattern.expected) {
+			t.Errorf("FresnelDielectric(%f, %f) must return %f, actual %f", pattern.cosTheta, pattern.eta, pattern.expected, result)
+		}
+	}
+
+	t.Run("When extinction of a conductor is zero", func(t *testing.T) {
+		eta := Color{R: 1.5, G: 1.5, B: 1.5}
+		result := FresnelConductor(0.6, eta, CreateDefaultColor(Black))
+
+		t.Run("it returns reflectance of a dielectric", func(t *testing.T) {
+			expected := FresnelDielectric(0.6, 1.5)
+			if 1e-6 < math.Abs(float64(result.R)-expected) {
+				t.Errorf("got: %v, want: %f", result, expected)
+			}
+		})
+	})
+}
+
+func TestRoughConductorBSDF(t *testing.T) {
+	for _, name := range []string{"gold", "copper", "aluminium", "silver"} {
+		bsdf, ok := CreateConductorPreset(name, 0.4, 0.2)
+		if !ok {
+			t.Fatalf("CreateConductorPreset(%q) must return a conductor", name)
+		}
+
+		for _, outgoing := range []Vector{Normalize(Vector{X: 0.3, Y: 0.1, Z: 1.0}), Normalize(Vector{X: -0.8, Y: 0.2, Z: -0.3})} {
+			albedo := checkBSDFSamples(t, bsdf, outgoing, 10000)
+
+			normal := bsdf.Albedo()
+			if 1.0 < maxComponent(albedo) || maxComponent(albedo) < 0.5*float64(normal.G) {
+				t.Errorf("albedo of %s for %v must be in [%f, 1], actual %v", name, outgoing, 0.5*normal.G, albedo)
+			}
+		}
+	}
+
+	t.Run("When a preset is unknown", func(t *testing.T) {
+		_, ok := CreateConductorPreset("brass", 0.5, 0.5)
+
+		t.Run("it returns false", func(t *testing.T) {
+			if ok {
+				t.Errorf("got: true, want: false")
+			}
+		})
+	})
+
+	t.Run("When samples below the surface are rejected", func(t *testing.T) {
+		bsdf := &RoughConductorBSDF{Distribution: CreateGGXDistribution(0.7, 0.7), Eta: CreateDefaultColor(White), K: CreateDefaultColor(White)}
+		outgoing := Normalize(Vector{X: 1.0, Y: 0.0, Z: 0.3})
+
+		rnd := rand.New(rand.NewSource(3))
+		accepted := 0
+		count := 100000
+		for i := 0; i < count; i++ {
+			if _, ok := bsdf.Sample(rnd, outgoing); ok {
+				accepted++
+			}
+		}
+
+		t.Run("Pdf integrates to the rate of accepted samples", func(t *testing.T) {
+			expected := float64(accepted) / float64(count)
+			if integral := integratePdf(bsdf, outgoing, 200000); 0.02 < math.Abs(integral-expected) {
+				t.Errorf("got: %f, want: %f", integral, expected)
+			}
+		})
+	})
+}
+
+func TestRoughDielectricBSDF(t *testing.T) {
+	bsdf := &RoughDielectricBSDF{Distribution: CreateGGXDistribution(0.5, 0.3), IndexOfRefraction: 1.5}
+
+	for _, outgoing := range []Vector{Normalize(Vector{X: 0.3, Y: 0.1, Z: 1.0}), Normalize(Vector{X: 0.6, Y: 0.2, Z: -1.0})} {
+		rnd := rand.New(rand.NewSource(3))
+		accepted := 0
+		count := 100000
+		for i := 0; i < count; i++ {
+			if _, ok := bsdf.Sample(rnd, outgoing); ok {
+				accepted++
+			}
+		}
+
+		checkBSDFSamples(t, bsdf, outgoing, 10000)
+
+		expected := float64(accepted) / float64(count)
+		if integral := integratePdf(bsdf, outgoing, 400000); 0.03 < math.Abs(integral-expected) {
+			t.Errorf("Pdf for %v must integrate to the rate of accepted samples %f, actual %f", outgoing, expected, integral)
+		}
+	}
+
+	t.Run("When light enters the object", func(t *testing.T) {
+		albedo := checkBSDFSamples(t, bsdf, Vector{X: 0.0, Y: 0.0, Z: 1.0}, 10000)
+
+		t.Run("it does not create energy", func(t *testing.T) {
+			if 1.0 < albedo.G {
+				t.Errorf("got: %v, want: at most 1", albedo)
+			}
+		})
+	})
+
+	t.Run("When roughness is small", func(t *testing.T) {
+		smooth := &RoughDielectricBSDF{Distribution: CreateGGXDistribution(0.0, 0.0), IndexOfRefraction: 1.5}
+		rnd := rand.New(rand.NewSource(1))
+		outgoing := Normalize(Vector{X: 1.0, Y: 0.0, Z: 1.0})
+
+		sample, ok := smooth.Sample(rnd, outgoing)
+		for ok && 0.0 < sample.Incoming.Z {
+			sample, ok = smooth.Sample(rnd, outgoing)
+		}
+
+		t.Run("it refracts by Snell's law", func(t *testing.T) {
+			sinT := math.Hypot(sample.Incoming.X, sample.Incoming.Y)
+			if !ok || 1e-3 < math.Abs(sinT-math.Sqrt(0.5)/1.5) {
+				t.Errorf("got: %v, want: sin %f", sample.Incoming, math.Sqrt(0.5)/1.5)
+			}
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package element
+
+import (
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestShapeParameterization(t *testing.T) {
+	uvs := [3]UV{{U: 0.2, V: 0.1}, {U: 0.9, V: 0.3}, {U: 0.4, V: 0.8}}
+
+	patterns := []struct {
+		name  string
+		shape Shape
+	}{
+		{name: "sphere", shape: &Sphere{Center: Vector{X: 0.1, Y: 0.2, Z: -3.0}, Radius: 1.5}},
+		{name: "plane", shape: &Plane{Center: Vector{X: 0.0, Y: 0.0, Z: -3.0}, Normal: Normalize(Vector{X: 0.2, Y: 0.1, Z: 1.0})}},
+		{
+			name: "triangle",
+			shape: &Triangle{
+				Vertices: [3]Vector{{X: -2.0, Y: -2.0, Z: -3.0}, {X: 2.0, Y: -1.0, Z: -3.5}, {X: 0.0, Y: 2.0, Z: -2.5}},
+				UVs:      &uvs,
+			},
+		},
+		{
+			name: "transformed sphere",
+			shape: &TransformedShape{
+				Shape: &Sphere{Center: CreateZeroVector(), Radius: 1.0},
+				Transform: transform.Compose(
+					transform.CreateScaling(Vector{X: 1.0, Y: 2.0, Z: 0.5}),
+					transform.CreateRotation(Vector{X: 1.0, Y: 1.0, Z: 0.0}, 0.5),
+					transform.CreateTranslation(Vector{X: 0.0, Y: 0.0, Z: -3.0})),
+			},
+		},
+	}
+
+	origin := CreateZeroVector()
+	direction := Normalize(Vector{X: 0.05, Y: 0.1, Z: -1.0})
+	// small change of the direction, which moves the hit point on the surface
+	delta := Vector{X: 1e-5, Y: -2e-5, Z: 0.0}
+
+	for _, pattern := range patterns {
+		hitInfo := pattern.shape.Intersect(CreateRay(origin, direction))
+		nearHitInfo := pattern.shape.Intersect(CreateRay(origin, Add(direction, delta)))
+		if hitInfo == nil || nearHitInfo == nil {
+			t.Errorf("Intersect of %s must hit", pattern.name)
+			continue
+		}
+
+		// the change of the position is explained by the change of UV
+		du := nearHitInfo.UV.U - hitInfo.UV.U
+		dv := nearHitInfo.UV.V - hitInfo.UV.V
+		expected := Subtract(nearHitInfo.Position, hitInfo.Position)
+		actual := Add(Multiply(du, hitInfo.Dpdu), Multiply(dv, hitInfo.Dpdv))
+		if difference := Subtract(actual, expected); 1e-3*expected.Length() < difference.Length() {
+			t.Errorf("Dpdu and Dpdv of %s must give the change of the position %v, actual %v", pattern.name, expected, actual)
+		}
+
+		// the parameterization has the orientation of the normal
+		cross := Normalize(Cross(hitInfo.Dpdu, hitInfo.Dpdv))
+		if difference := Subtract(cross, hitInfo.Normal); 1e-6 < difference.Length() {
+			t.Errorf("Dpdu x Dpdv of %s must be along the normal %v, actual %v", pattern.name, hitInfo.Normal, cross)
+		}
+
+		if difference := Subtract(hitInfo.ShadingNormal, hitInfo.Normal); 1e-9 < difference.Length() {
+			t.Errorf("ShadingNormal of %s must be the geometric normal %v, actual %v", pattern.name, hitInfo.Normal, hitInfo.ShadingNormal)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Clear sky of the Preetham model (Preetham, Shirley and Smits, 1999), whose radiance is in kcd/m^2.
+// The sun disk is not included, and is given by SunLight. Below the horizon, the ground reflects
+// light of the sky and the sun diffusely.
+type PreethamSky struct {
+	// Unit direction toward the sun, which is above the horizon.
+	SunDirection Vector
+	// Haziness of the atmosphere in [2, 10], where 2 is a very clear sky.
+	Turbidity    float64
+	GroundAlbedo Color
+	// Scale of the radiance.
+	Intensity Color
+
+	// Perez coefficients, zenith values and normalization of luminance Y and chromaticity x and y.
+	perez  [3][5]float64
+	zenith [3]float64
+	scale  [3]float64
+	ground Color
+	// Tabulated sky to sample directions.
+	sampler *EnvironmentLight
+}
+
+// Resolution of the table of the sky to sample directions.
+const (
+	skySamplerWidth  = 128
+	skySamplerHeight = 64
+)
+
+func CreatePreethamSky(sunDirection Vector, turbidity float64, groundAlbedo Color, intensity Color) *PreethamSky {
+	sunDirection = Normalize(sunDirection)
+	// the model is not defined for the sun below the horizon
+	thetaS := math.Min(math.Acos(math.Max(-1.0, math.Min(1.0, sunDirection.Y))), 0.5*math.Pi-1e-3)
+	t := turbidity
+
+	sky := &PreethamSky{
+		SunDirection: sunDirection,
+		Turbidity:    turbidity,
+		GroundAlbedo: groundAlbedo,
+		Intensity:    intensity,
+		perez: [3][5]float64{
+			{0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703},
+			{-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452},
+			{-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529},
+		},
+	}
+
+	chi := (4.0/9.0 - t/120.0) * (math.Pi - 2.0*thetaS)
+	theta2 := thetaS * thetaS
+	theta3 := theta2 * thetaS
+	sky.zenith = [3]float64{
+		math.Max(0.0, (4.0453*t-4.9710)*math.Tan(chi)-0.2155*t+2.4192),
+		t*t*(0.00166*theta3-0.00375*theta2+0.00209*thetaS) +
+			t*(-0.02903*theta3+0.06377*theta2-0.03202*thetaS+0.00394) +
+			(0.11693*theta3 - 0.21196*theta2 + 0.06052*thetaS + 0.25886),
+		t*t*(0.00275*theta3-0.00610*theta2+0.00317*thetaS) +
+			t*(-0.04214*theta3+0.08970*theta2-0.04153*thetaS+0.00516) +
+			(0.15346*theta3 - 0.26756*theta2 + 0.06670*thetaS + 0.26688),
+	}
+	for i := range sky.scale {
+		sky.scale[i] = sky.zenith[i] / perezFunction(sky.perez[i], 1.0, thetaS)
+	}
+
+	sky.ground = sky.groundRadiance()
+	sky.sampler = sky.createSampler()
+
+	return sky
+}
+
+// Perez formula of the sky distribution at a zenith angle of cosine cosTheta and an angle gamma to the sun.
+func perezFunction(c [5]float64, cosTheta float64, gamma float64) float64 {
+	cosGamma := math.Cos(gamma)
+
+	return (1.0 + c[0]*math.Exp(c[1]/cosTheta)) * (1.0 + c[2]*math.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+// Radiance of the sky above the horizon without the intensity scale.
+func (sky *PreethamSky) skyRadiance(direction Vector) Color {
+	// avoid the singularity of the Perez formula at the horizon
+	cosTheta := math.Max(direction.Y, 1e-3)
+	gamma := math.Acos(math.Max(-1.0, math.Min(1.0, Dot(direction, sky.SunDirection))))
+
+	var values [3]float64
+	for i := range values {
+		values[i] = sky.scale[i] * perezFunction(sky.perez[i], cosTheta, gamma)
+	}
+
+	color := XyYToLinearSRGB(values[1], values[2], values[0])
+
+	// chromaticities far from the sun can give slightly negative components
+	return Color{R: float32(math.Max(0.0, float64(color.R))), G: float32(math.Max(0.0, float64(color.G))), B: float32(math.Max(0.0, float64(color.B)))}
+}
+
+// Radiance of the diffuse ground lit by the upper hemisphere of the sky and the sun.
+func (sky *PreethamSky) groundRadiance() Color {
+	irradiance := CreateDefaultColor(Black)
+
+	n := 64
+	dTheta := 0.5 * math.Pi / float64(n)
+	dPhi := 2.0 * math.Pi / float64(2*n)
+	for i := 0; i < n; i++ {
+		theta := (float64(i) + 0.5) * dTheta
+		for j := 0; j < 2*n; j++ {
+			phi := (float64(j) + 0.5) * dPhi
+			direction := Vector{X: math.Sin(theta) * math.Cos(phi), Y: math.Cos(theta), Z: math.Sin(theta) * math.Sin(phi)}
+			irradiance = AddColor(irradiance, MultiplyScalar(math.Cos(theta)*math.Sin(theta)*dTheta*dPhi, sky.skyRadiance(direction)))
+		}
+	}
+
+	sun := sunRadiance(sky.SunDirection, sky.Turbidity)
+	sunIrradiance := MultiplyScalar(2.0*math.Pi*(1.0-math.Cos(sunAngularRadius))*math.Max(0.0, sky.SunDirection.Y), sun)
+
+	return MultiplyColor(sky.GroundAlbedo, MultiplyScalar(1.0/math.Pi, AddColor(irradiance, sunIrradiance)))
+}
+
+func (sky *PreethamSky) createSampler() *EnvironmentLight {
+	img := CreateImage(skySamplerWidth, skySamplerHeight)
+
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			direction, _ := equirectangularDirection((float64(x)+0.5)/float64(img.Width), (float64(y)+0.5)/float64(img.Height))
+			img.Pixels[y*img.Width+x].Color = sky.Radiance(direction)
+		}
+	}
+
+	return CreateEnvironmentLight(img, 0.0, CreateDefaultColor(White))
+}
+
+func (sky *PreethamSky) Radiance(direction Vector) Color {
+	if direction.Y <= 0.0 {
+		return MultiplyColor(sky.Intensity, sky.ground)
+	}
+
+	return MultiplyColor(sky.Intensity, sky.skyRadiance(direction))
+}
+
+func (sky *PreethamSky) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	sample, ok := sky.sampler.Sample(rnd, position)
+	if !ok {
+		return LightSample{}, false
+	}
+
+	sample.Radiance = sky.Radiance(sample.Direction)
+
+	return sample, true
+}
+
+func (sky *PreethamSky) Pdf(direction Vector) float64 {
+	return sky.sampler.Pdf(direction)
+}
+
+// Angular radius of the sun disk in radians.
+const sunAngularRadius = 0.004653
+
+// Luminance of the sun outside of the atmosphere in kcd/m^2.
+const extraterrestrialSunLuminance = 2.0e6
+
+// Radiance of the sun disk attenuated by Rayleigh scattering and aerosols of turbidity, evaluated at
+// wavelengths of red, green and blue. Absorption by ozone and water vapor is ignored.
+func sunRadiance(direction Vector, turbidity float64) Color {
+	cosTheta := direction.Y
+	if cosTheta <= 0.0 {
+		return CreateDefaultColor(Black)
+	}
+
+	// relative optical mass (Kasten, 1966)
+	thetaDegree := math.Acos(cosTheta) * 180.0 / math.Pi
+	m := 1.0 / (cosTheta + 0.15*math.Pow(93.885-thetaDegree, -1.253))
+
+	alpha := 1.3
+	beta := 0.04608*turbidity - 0.04586
+
+	var transmittance [3]float64
+	for i, lambda := range [3]float64{0.65, 0.55, 0.45} {
+		rayleigh := math.Exp(-0.008735 * math.Pow(lambda, -4.08) * m)
+		aerosol := math.Exp(-beta * math.Pow(lambda, -alpha) * m)
+		transmittance[i] = rayleigh * aerosol
+	}
+
+	return Color{
+		R: float32(extraterrestrialSunLuminance * transmittance[0]),
+		G: float32(extraterrestrialSunLuminance * transmittance[1]),
+		B: float32(extraterrestrialSunLuminance * transmittance[2]),
+	}
+}
+
+// Disk of the sun seen through the atmosphere, which is sampled uniformly in its cone.
+type SunLight struct {
+	// Unit direction toward the sun.
+	Direction Vector
+	// Radiance of the disk, which is uniform.
+	DiskRadiance Color
+
+	cosAngularRadius float64
+	frame            Frame
+}
+
+// Create the sun matching PreethamSky of the same direction, turbidity and intensity.
+func CreateSunLight(direction Vector, turbidity float64, intensity Color) *SunLight {
+	direction = Normalize(direction)
+
+	return &SunLight{
+		Direction:        direction,
+		DiskRadiance:     MultiplyColor(intensity, sunRadiance(direction, turbidity)),
+		cosAngularRadius: math.Cos(sunAngularRadius),
+		frame:            CreateFrame(direction),
+	}
+}
+
+func (light *SunLight) Radiance(direction Vector) Color {
+	if Dot(direction, light.Direction) < light.cosAngularRadius {
+		return CreateDefaultColor(Black)
+	}
+
+	return light.DiskRadiance
+}
+
+func (light *SunLight) Sample(rnd *rand.Rand, position Vector) (LightSample, bool) {
+	cosTheta := 1.0 - rnd.Float64()*(1.0-light.cosAngularRadius)
+	sinTheta := math.Sqrt(math.Max(0.0, 1.0-cosTheta*cosTheta))
+	phi := 2.0 * math.Pi * rnd.Float64()
+
+	direction := light.frame.ToWorld(Vector{X: sinTheta * math.Cos(phi), Y: sinTheta * math.Sin(phi), Z: cosTheta})
+
+	return LightSample{
+		Direction: direction,
+		Distance:  math.Inf(1),
+		Radiance:  light.DiskRadiance,
+		Pdf:       light.conePdf(),
+		IsDelta:   false,
+	}, true
+}
+
+func (light *SunLight) Pdf(direction Vector) float64 {
+	if Dot(direction, light.Direction) < light.cosAngularRadius {
+		return 0.0
+	}
+
+	return light.conePdf()
+}
+
+func (light *SunLight) conePdf() float64 {
+	return 1.0 / (2.0 * math.Pi * (1.0 - light.cosAngularRadius))
+}
+
+// Create the sky and the sun as lights which are seen as the background of a scene.
+func CreateDaylight(sunDirection Vector, turbidity float64, groundAlbedo Color, intensity Color) []Light {
+	return []Light{
+		CreatePreethamSky(sunDirection, turbidity, groundAlbedo, intensity),
+		CreateSunLight(sunDirection, turbidity, intensity),
+	}
+}
@@ -0,0 +1,98 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestPreethamSky(t *testing.T) {
+	sunDirection := Normalize(Vector{X: 1.0, Y: 1.0, Z: 0.0})
+	sky := CreatePreethamSky(sunDirection, 3.0, Color{R: 0.2, G: 0.2, B: 0.2}, CreateDefaultColor(White))
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("it returns the zenith luminance of the model toward the zenith", func(t *testing.T) {
+		luminance := Luminance(sky.Radiance(Vector{X: 0.0, Y: 1.0, Z: 0.0}))
+
+		if 0.01*sky.zenith[0] < math.Abs(luminance-sky.zenith[0]) {
+			t.Errorf("got: %f, want: %f", luminance, sky.zenith[0])
+		}
+	})
+
+	t.Run("it returns brighter radiance near the sun", func(t *testing.T) {
+		near := Luminance(sky.Radiance(Normalize(Vector{X: 1.0, Y: 0.9, Z: 0.1})))
+		far := Luminance(sky.Radiance(Normalize(Vector{X: -1.0, Y: 1.0, Z: 0.0})))
+
+		if near <= far {
+			t.Errorf("got: %f near the sun and %f away, want: brighter near the sun", near, far)
+		}
+	})
+
+	t.Run("it returns bluish radiance away from the sun", func(t *testing.T) {
+		radiance := sky.Radiance(Normalize(Vector{X: -1.0, Y: 2.0, Z: 0.0}))
+
+		if radiance.B <= radiance.R {
+			t.Errorf("got: %v, want: blue larger than red", radiance)
+		}
+	})
+
+	t.Run("it returns uniform radiance of the ground lit by the sky and the sun", func(t *testing.T) {
+		first := sky.Radiance(Normalize(Vector{X: 1.0, Y: -1.0, Z: 0.0}))
+		second := sky.Radiance(Vector{X: 0.0, Y: -1.0, Z: 0.0})
+
+		if !first.NearlyEqual(second) || Luminance(first) <= 0.0 {
+			t.Errorf("got: %v and %v, want: the same positive radiance", first, second)
+		}
+	})
+
+	t.Run("it returns samples consistent with Radiance and Pdf", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			sample, ok := sky.Sample(rnd, CreateZeroVector())
+			if !ok {
+				continue
+			}
+
+			radiance := sky.Radiance(sample.Direction)
+			pdf := sky.Pdf(sample.Direction)
+			if !sample.Radiance.NearlyEqual(radiance) || 1e-6*pdf < math.Abs(sample.Pdf-pdf) || !math.IsInf(sample.Distance, 1) {
+				t.Fatalf("got: %v, want: radiance %v and pdf %f at infinity", sample, radiance, pdf)
+			}
+		}
+	})
+}
+
+func TestSunLight(t *testing.T) {
+	noon := CreateSunLight(Vector{X: 0.0, Y: 1.0, Z: 0.0}, 3.0, CreateDefaultColor(White))
+	sunset := CreateSunLight(Normalize(Vector{X: 1.0, Y: 0.05, Z: 0.0}), 3.0, CreateDefaultColor(White))
+	rnd := rand.New(rand.NewSource(1))
+
+	t.Run("it returns samples inside the sun disk", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			sample, ok := noon.Sample(rnd, CreateZeroVector())
+
+			if !ok || noon.Pdf(sample.Direction) != sample.Pdf || !noon.Radiance(sample.Direction).NearlyEqual(sample.Radiance) {
+				t.Fatalf("got: %v, want: sample inside the sun disk", sample)
+			}
+		}
+	})
+
+	t.Run("it returns no light outside the sun disk", func(t *testing.T) {
+		direction := Normalize(Vector{X: 0.1, Y: 1.0, Z: 0.0})
+
+		if noon.Pdf(direction) != 0.0 || !noon.Radiance(direction).NearlyEqual(CreateDefaultColor(Black)) {
+			t.Errorf("got: pdf %f and radiance %v, want: no light", noon.Pdf(direction), noon.Radiance(direction))
+		}
+	})
+
+	t.Run("it returns darker and redder radiance near the horizon", func(t *testing.T) {
+		if Luminance(noon.DiskRadiance) <= Luminance(sunset.DiskRadiance) {
+			t.Errorf("got: %v at noon and %v at sunset, want: darker at sunset", noon.DiskRadiance, sunset.DiskRadiance)
+		}
+		if sunset.DiskRadiance.R/sunset.DiskRadiance.B <= noon.DiskRadiance.R/noon.DiskRadiance.B {
+			t.Errorf("got: %v at noon and %v at sunset, want: redder at sunset", noon.DiskRadiance, sunset.DiskRadiance)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Perfect mirror.
+type MirrorBSDF struct {
+	Reflectance Color
+}
+
+func (bsdf *MirrorBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	return CreateDefaultColor(Black)
+}
+
+func (bsdf *MirrorBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	return BSDFSample{Incoming: reflectLocal(outgoing), Weight: bsdf.Reflectance, Pdf: 1.0, IsDelta: true}, true
+}
+
+func (bsdf *MirrorBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	return 0.0
+}
+
+func (bsdf *MirrorBSDF) Albedo() Color {
+	return bsdf.Reflectance
+}
+
+// Smooth boundary of a transparent object, whose reflectance is given by Schlick's approximation.
+// The normal points to the outside of the object, where the index of refraction is 1.
+type SchlickDielectricBSDF struct {
+	// Color of reflected light. Transmitted light is not tinted.
+	Reflectance       Color
+	IndexOfRefraction float64
+}
+
+func (bsdf *SchlickDielectricBSDF) Evaluate(outgoing Vector, incoming Vector) Color {
+	return CreateDefaultColor(Black)
+}
+
+func (bsdf *SchlickDielectricBSDF) Sample(rnd *rand.Rand, outgoing Vector) (BSDFSample, bool) {
+	eta := 1.0 / bsdf.IndexOfRefraction
+	if cosTheta(outgoing) < 0.0 {
+		eta = bsdf.IndexOfRefraction
+	}
+
+	reflection := BSDFSample{Incoming: reflectLocal(outgoing), Weight: bsdf.Reflectance, Pdf: 1.0, IsDelta: true}
+
+	incoming, ok := refractLocal(outgoing, eta)
+	if !ok {
+		return reflection, true
+	}
+
+	kr := schlickReflectance(math.Abs(cosTheta(outgoing)), 1.0, bsdf.IndexOfRefraction)
+	if rnd.Float64() < kr {
+		reflection.Pdf = kr
+		return reflection, true
+	}
+
+	return BSDFSample{Incoming: incoming, Weight: CreateDefaultColor(White), Pdf: 1.0 - kr, IsDelta: true}, true
+}
+
+func (bsdf *SchlickDielectricBSDF) Pdf(outgoing Vector, incoming Vector) float64 {
+	return 0.0
+}
+
+func (bsdf *SchlickDielectricBSDF) Albedo() Color {
+	return bsdf.Reflectance
+}
+
+// Schlick's approximation of Fresnel reflectance between media of n1 and n2.
+func schlickReflectance(cosTheta float64, n1 float64, n2 float64) float64 {
+	r := math.Pow((n1-n2)/(n1+n2), 2.0)
+
+	return r + (1.0-r)*math.Pow(1.0-cosTheta, 5)
+}
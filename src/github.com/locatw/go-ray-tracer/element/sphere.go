@@ -42,13 +42,42 @@ func (sphere *Sphere) Intersect(ray Ray) *HitInfo {
 	if intersected {
 		pos := Add(ray.Origin, Multiply(t, ray.Direction))
 		n := Normalize(Subtract(pos, sphere.Center))
+		uv, dpdu, dpdv := sphere.parameterize(pos)
 
-		return &HitInfo{Object: sphere, Position: pos, Normal: n, T: t}
+		return &HitInfo{Object: sphere, Position: pos, Normal: n, ShadingNormal: n, T: t, UV: uv, Dpdu: dpdu, Dpdv: dpdv}
 	} else {
 		return nil
 	}
 }
 
+// Return spherical coordinates of a point on the sphere, where U goes around the Y axis
+// counterclockwise seen from above starting from +X, and V goes from the bottom to the top.
+func (sphere *Sphere) parameterize(position Vector) (UV, Vector, Vector) {
+	p := Subtract(position, sphere.Center)
+
+	phi := math.Atan2(-p.Z, p.X)
+	if phi < 0.0 {
+		phi += 2.0 * math.Pi
+	}
+	theta := math.Acos(math.Max(-1.0, math.Min(1.0, p.Y/sphere.Radius)))
+
+	// p = r (sin(theta) cos(phi), cos(theta), -sin(theta) sin(phi)), where phi = 2 pi u and theta = pi (1 - v)
+	dpdu := Vector{X: 2.0 * math.Pi * p.Z, Y: 0.0, Z: -2.0 * math.Pi * p.X}
+	dpdv := Multiply(-math.Pi, Vector{
+		X: sphere.Radius * math.Cos(theta) * math.Cos(phi),
+		Y: -sphere.Radius * math.Sin(theta),
+		Z: -sphere.Radius * math.Cos(theta) * math.Sin(phi),
+	})
+
+	return UV{U: phi / (2.0 * math.Pi), V: 1.0 - theta/math.Pi}, dpdu, dpdv
+}
+
 func (sphere *Sphere) GetMaterial() Material {
 	return sphere.Material
 }
+
+func (sphere *Sphere) Bounds() AABB {
+	r := Vector{X: sphere.Radius, Y: sphere.Radius, Z: sphere.Radius}
+
+	return AABB{Min: Subtract(sphere.Center, r), Max: Add(sphere.Center, r)}
+}
@@ -0,0 +1,220 @@
+package element
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Point sampled on the surface of a shape.
+type SurfaceSample struct {
+	Position Vector
+	Normal   Vector
+	// Probability density with respect to surface area.
+	Pdf float64
+}
+
+// Shape whose surface can be sampled, which is required by area lights.
+type SampleableShape interface {
+	Shape
+	// Sample a point on the surface to illuminate reference.
+	Sample(rnd *rand.Rand, reference Vector) SurfaceSample
+	// Probability density with respect to surface area that Sample returns position with normal.
+	Pdf(reference Vector, position Vector, normal Vector) float64
+}
+
+// Convert a probability density with respect to area into solid angle seen from reference.
+// It returns zero if the surface is seen edge-on.
+func AreaToSolidAnglePdf(pdf float64, reference Vector, position Vector, normal Vector) float64 {
+	v := Subtract(position, reference)
+	distance2 := Dot(v, v)
+	cosTheta := math.Abs(Dot(normal, v)) / math.Sqrt(distance2)
+
+	if cosTheta == 0.0 {
+		return 0.0
+	}
+
+	return pdf * distance2 / cosTheta
+}
+
+func uniformSampleSphere(u1 float64, u2 float64) Vector {
+	z := 1.0 - 2.0*u1
+	r := math.Sqrt(math.Max(0.0, 1.0-z*z))
+	phi := 2.0 * math.Pi * u2
+
+	return Vector{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}
+}
+
+// Sample the part of the sphere visible from reference uniformly in solid angle.
+// If reference is inside the sphere, the whole surface is sampled uniformly in area.
+func (sphere *Sphere) Sample(rnd *rand.Rand, reference Vector) SurfaceSample {
+	toCenter := Subtract(sphere.Center, reference)
+	dc2 := Dot(toCenter, toCenter)
+	r2 := sphere.Radius * sphere.Radius
+
+	if dc2 <= r2 {
+		n := uniformSampleSphere(rnd.Float64(), rnd.Float64())
+
+		return SurfaceSample{
+			Position: Add(sphere.Center, Multiply(sphere.Radius, n)),
+			Normal:   n,
+			Pdf:      1.0 / (4.0 * math.Pi * r2),
+		}
+	}
+
+	dc := math.Sqrt(dc2)
+	cosThetaMax := math.Sqrt(math.Max(0.0, 1.0-r2/dc2))
+
+	u := rnd.Float64()
+	cosTheta := (1.0 - u) + u*cosThetaMax
+	sinTheta2 := math.Max(0.0, 1.0-cosTheta*cosTheta)
+	phi := 2.0 * math.Pi * rnd.Float64()
+
+	// angle between the direction to reference and the normal at the sampled point
+	ds := dc*cosTheta - math.Sqrt(math.Max(0.0, r2-dc2*sinTheta2))
+	cosAlpha := math.Min(1.0, (dc2+r2-ds*ds)/(2.0*dc*sphere.Radius))
+	sinAlpha := math.Sqrt(math.Max(0.0, 1.0-cosAlpha*cosAlpha))
+
+	wc := Multiply(1.0/dc, toCenter)
+	wcX, wcY := CreateOrthonormalBasis(wc)
+
+	n := AddAll(
+		Multiply(-sinAlpha*math.Cos(phi), wcX),
+		Multiply(-sinAlpha*math.Sin(phi), wcY),
+		Multiply(-cosAlpha, wc))
+	position := Add(sphere.Center, Multiply(sphere.Radius, n))
+
+	return SurfaceSample{Position: position, Normal: n, Pdf: sphere.Pdf(reference, position, n)}
+}
+
+func (sphere *Sphere) Pdf(reference Vector, position Vector, normal Vector) float64 {
+	toCenter := Subtract(sphere.Center, reference)
+	dc2 := Dot(toCenter, toCenter)
+	r2 := sphere.Radius * sphere.Radius
+
+	if dc2 <= r2 {
+		return 1.0 / (4.0 * math.Pi * r2)
+	}
+
+	cosThetaMax := math.Sqrt(math.Max(0.0, 1.0-r2/dc2))
+	solidAnglePdf := 1.0 / (2.0 * math.Pi * (1.0 - cosThetaMax))
+
+	v := Subtract(reference, position)
+	distance2 := Dot(v, v)
+	cosTheta := math.Abs(Dot(normal, v)) / math.Sqrt(distance2)
+
+	return solidAnglePdf * cosTheta / distance2
+}
+
+func (triangle *Triangle) Area() float64 {
+	n := Cross(Subtract(triangle.Vertices[1], triangle.Vertices[0]), Subtract(triangle.Vertices[2], triangle.Vertices[0]))
+
+	return 0.5 * n.Length()
+}
+
+// Sample the triangle uniformly in area.
+func (triangle *Triangle) Sample(rnd *rand.Rand, reference Vector) SurfaceSample {
+	su := math.Sqrt(rnd.Float64())
+	w1 := 1.0 - su
+	w2 := rnd.Float64() * su
+
+	position := AddAll(
+		Multiply(1.0-w1-w2, triangle.Vertices[0]),
+		Multiply(w1, triangle.Vertices[1]),
+		Multiply(w2, triangle.Vertices[2]))
+
+	return SurfaceSample{Position: position, Normal: triangle.GeometricNormal(), Pdf: 1.0 / triangle.Area()}
+}
+
+func (triangle *Triangle) Pdf(reference Vector, position Vector, normal Vector) float64 {
+	return 1.0 / triangle.Area()
+}
+
+// Sample the mesh uniformly in area.
+func (mesh *Mesh) Sample(rnd *rand.Rand, reference Vector) SurfaceSample {
+	cdf := mesh.areaCdf
+	if cdf == nil {
+		cdf = createAreaCdf(mesh.Triangles)
+	}
+
+	total := cdf[len(cdf)-1]
+	u := rnd.Float64() * total
+	index := sort.SearchFloat64s(cdf, u)
+	if len(mesh.Triangles) <= index {
+		index = len(mesh.Triangles) - 1
+	}
+
+	sample := mesh.Triangles[index].Sample(rnd, reference)
+	sample.Pdf = 1.0 / total
+
+	return sample
+}
+
+func (mesh *Mesh) Pdf(reference Vector, position Vector, normal Vector) float64 {
+	cdf := mesh.areaCdf
+	if cdf == nil {
+		cdf = createAreaCdf(mesh.Triangles)
+	}
+
+	return 1.0 / cdf[len(cdf)-1]
+}
+
+// Cumulative areas of triangles.
+func createAreaCdf(triangles []*Triangle) []float64 {
+	cdf := make([]float64, len(triangles))
+
+	sum := 0.0
+	for i, triangle := range triangles {
+		sum += triangle.Area()
+		cdf[i] = sum
+	}
+
+	return cdf
+}
+
+// Sample the inner shape in object space. The density is converted by the change of area
+// caused by the transform, so it is correct for non-uniform scaling as well.
+func (shape *TransformedShape) Sample(rnd *rand.Rand, reference Vector) SurfaceSample {
+	inner := shape.Shape.(SampleableShape)
+
+	sample := inner.Sample(rnd, shape.Transform.Invert().TransformPoint(reference))
+
+	return SurfaceSample{
+		Position: shape.Transform.TransformPoint(sample.Position),
+		Normal:   Normalize(shape.Transform.TransformNormal(sample.Normal)),
+		Pdf:      sample.Pdf / shape.areaScale(sample.Normal),
+	}
+}
+
+func (shape *TransformedShape) Pdf(reference Vector, position Vector, normal Vector) float64 {
+	inner := shape.Shape.(SampleableShape)
+	inverse := shape.Transform.Invert()
+
+	objectNormal := Normalize(inverse.TransformNormal(normal))
+	pdf := inner.Pdf(inverse.TransformPoint(reference), inverse.TransformPoint(position), objectNormal)
+
+	return pdf / shape.areaScale(objectNormal)
+}
+
+// Ratio of an area element in world space to that in object space at a point with a unit normal.
+func (shape *TransformedShape) areaScale(objectNormal Vector) float64 {
+	n := shape.Transform.TransformNormal(objectNormal)
+
+	return math.Abs(shape.Transform.Matrix.Determinant3()) * n.Length()
+}
+
+// Whether a shape can be sampled by area lights.
+func IsSampleableShape(shape Shape) bool {
+	switch s := shape.(type) {
+	case *TransformedShape:
+		return IsSampleableShape(s.Shape)
+	case *Mesh:
+		return 0 < len(s.Triangles)
+	case SampleableShape:
+		return true
+	default:
+		return false
+	}
+}
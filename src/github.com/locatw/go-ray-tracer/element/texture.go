@@ -0,0 +1,105 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Point on a surface where textures are evaluated.
+type TexturePoint struct {
+	UV       UV
+	Position Vector
+}
+
+type Texture interface {
+	Evaluate(point TexturePoint) Color
+}
+
+// Evaluate a texture which gives a scalar such as roughness, which is read from the red channel.
+func EvaluateFloatTexture(texture Texture, point TexturePoint) float64 {
+	return float64(texture.Evaluate(point).R)
+}
+
+type ConstantTexture struct {
+	Color Color
+}
+
+func (texture *ConstantTexture) Evaluate(point TexturePoint) Color {
+	return texture.Color
+}
+
+// Handling of texture coordinates outside [0, 1].
+type WrapMode int
+
+const (
+	// Tile the image.
+	RepeatWrap WrapMode = iota
+	// Extend the pixels on the edges.
+	ClampWrap
+)
+
+// Texture of an image mapped to UV, where V = 0 is the bottom row. Colors are bilinearly interpolated.
+type ImageTexture struct {
+	// Image in linear sRGB, or non-color data.
+	Image Image
+	Wrap  WrapMode
+}
+
+// Create a texture of an image, which is converted into linear sRGB unless it is non-color data.
+func CreateImageTexture(img Image, wrap WrapMode) *ImageTexture {
+	if img.ColorSpace != NonColor {
+		img = ConvertImage(img, LinearSRGB)
+	}
+
+	return &ImageTexture{Image: img, Wrap: wrap}
+}
+
+func (texture *ImageTexture) Evaluate(point TexturePoint) Color {
+	img := &texture.Image
+	if img.Width == 0 || img.Height == 0 {
+		return CreateDefaultColor(Black)
+	}
+
+	// centers of pixels are at half-integer positions
+	x := point.UV.U*float64(img.Width) - 0.5
+	y := (1.0-point.UV.V)*float64(img.Height) - 0.5
+
+	x0 := math.Floor(x)
+	y0 := math.Floor(y)
+	dx := x - x0
+	dy := y - y0
+
+	c00 := texture.pixel(int(x0), int(y0))
+	c10 := texture.pixel(int(x0)+1, int(y0))
+	c01 := texture.pixel(int(x0), int(y0)+1)
+	c11 := texture.pixel(int(x0)+1, int(y0)+1)
+
+	return LerpColor(dy, LerpColor(dx, c00, c10), LerpColor(dx, c01, c11))
+}
+
+func (texture *ImageTexture) pixel(x int, y int) Color {
+	img := &texture.Image
+
+	return img.Pixels[wrapIndex(y, img.Height, texture.Wrap)*img.Width+wrapIndex(x, img.Width, texture.Wrap)].Color
+}
+
+func wrapIndex(i int, size int, wrap WrapMode) int {
+	if wrap == ClampWrap {
+		if i < 0 {
+			return 0
+		}
+		if size <= i {
+			return size - 1
+		}
+		return i
+	}
+
+	i %= size
+	if i < 0 {
+		i += size
+	}
+
+	return i
+}
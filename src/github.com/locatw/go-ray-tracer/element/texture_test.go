@@ -0,0 +1,142 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestImageTextureEvaluate(t *testing.T) {
+	// 2x2 image whose top row is black and white, and bottom row is red and green
+	img := CreateImage(2, 2)
+	img.Pixels[1].Color = Color{R: 1.0, G: 1.0, B: 1.0}
+	img.Pixels[2].Color = Color{R: 1.0, G: 0.0, B: 0.0}
+	img.Pixels[3].Color = Color{R: 0.0, G: 1.0, B: 0.0}
+
+	repeat := CreateImageTexture(img, RepeatWrap)
+	clamp := CreateImageTexture(img, ClampWrap)
+
+	patterns := []struct {
+		texture  *ImageTexture
+		uv       UV
+		expected Color
+	}{
+		// centers of pixels
+		{texture: repeat, uv: UV{U: 0.25, V: 0.75}, expected: Color{R: 0.0, G: 0.0, B: 0.0}},
+		{texture: repeat, uv: UV{U: 0.75, V: 0.75}, expected: Color{R: 1.0, G: 1.0, B: 1.0}},
+		{texture: repeat, uv: UV{U: 0.25, V: 0.25}, expected: Color{R: 1.0, G: 0.0, B: 0.0}},
+		// between all pixels
+		{texture: repeat, uv: UV{U: 0.5, V: 0.5}, expected: Color{R: 0.5, G: 0.5, B: 0.25}},
+		// the left edge is blended with the right column by repeating, and not by clamping
+		{texture: repeat, uv: UV{U: 0.0, V: 0.75}, expected: Color{R: 0.5, G: 0.5, B: 0.5}},
+		{texture: clamp, uv: UV{U: 0.0, V: 0.75}, expected: Color{R: 0.0, G: 0.0, B: 0.0}},
+		{texture: repeat, uv: UV{U: 1.25, V: -0.75}, expected: Color{R: 1.0, G: 0.0, B: 0.0}},
+		{texture: clamp, uv: UV{U: 1.25, V: -0.25}, expected: Color{R: 0.0, G: 1.0, B: 0.0}},
+	}
+
+	for _, pattern := range patterns {
+		actual := pattern.texture.Evaluate(TexturePoint{UV: pattern.uv})
+
+		if !actual.NearlyEqual(pattern.expected) {
+			t.Errorf("Evaluate(%v) with wrap %d must return %v, actual %v", pattern.uv, pattern.texture.Wrap, pattern.expected, actual)
+		}
+	}
+}
+
+func TestCreateImageTexture(t *testing.T) {
+	img := CreateImage(1, 1)
+	img.ColorSpace = SRGB
+	img.Pixels[0].Color = Color{R: 0.5, G: 0.5, B: 0.5}
+
+	patterns := []struct {
+		colorSpace ColorSpace
+		expected   Color
+	}{
+		{colorSpace: SRGB, expected: ConvertColor(Color{R: 0.5, G: 0.5, B: 0.5}, SRGB, LinearSRGB)},
+		{colorSpace: NonColor, expected: Color{R: 0.5, G: 0.5, B: 0.5}},
+	}
+
+	for _, pattern := range patterns {
+		img.ColorSpace = pattern.colorSpace
+		actual := CreateImageTexture(img, RepeatWrap).Evaluate(TexturePoint{UV: UV{U: 0.5, V: 0.5}})
+
+		if !actual.NearlyEqual(pattern.expected) {
+			t.Errorf("texture of an image in %s must return %v, actual %v", pattern.colorSpace, pattern.expected, actual)
+		}
+	}
+}
+
+func TestCheckerboardTextureEvaluate(t *testing.T) {
+	black := CreateDefaultColor(Black)
+	white := CreateDefaultColor(White)
+
+	patterns := []struct {
+		mapping  TextureMapping
+		point    TexturePoint
+		expected Color
+	}{
+		{mapping: UVMapping, point: TexturePoint{UV: UV{U: 0.1, V: 0.1}}, expected: black},
+		{mapping: UVMapping, point: TexturePoint{UV: UV{U: 0.6, V: 0.1}}, expected: white},
+		{mapping: UVMapping, point: TexturePoint{UV: UV{U: 0.6, V: 0.6}}, expected: black},
+		{mapping: UVMapping, point: TexturePoint{UV: UV{U: -0.1, V: 0.1}}, expected: white},
+		{mapping: PositionMapping, point: TexturePoint{Position: Vector{X: 0.1, Y: 0.1, Z: 0.6}}, expected: white},
+		{mapping: PositionMapping, point: TexturePoint{Position: Vector{X: 0.6, Y: 0.1, Z: 0.6}}, expected: black},
+	}
+
+	for _, pattern := range patterns {
+		texture := &CheckerboardTexture{Color1: black, Color2: white, Frequency: 2.0, Mapping: pattern.mapping}
+		actual := texture.Evaluate(pattern.point)
+
+		if actual != pattern.expected {
+			t.Errorf("Evaluate(%v) must return %v, actual %v", pattern.point, pattern.expected, actual)
+		}
+	}
+}
+
+func TestPerlinNoise(t *testing.T) {
+	t.Run("When a point is on the lattice", func(t *testing.T) {
+		t.Run("it returns zero", func(t *testing.T) {
+			p := Vector{X: 3.0, Y: -2.0, Z: 7.0}
+			if actual := PerlinNoise(p); actual != 0.0 {
+				t.Errorf("PerlinNoise(%v) must return 0, actual %f", p, actual)
+			}
+		})
+	})
+
+	t.Run("When points are random", func(t *testing.T) {
+		minimum := math.Inf(1)
+		maximum := math.Inf(-1)
+		for i := 0; i < 10000; i++ {
+			p := Vector{X: float64(i) * 0.37, Y: float64(i%97) * 0.13, Z: float64(i%13) * 0.71}
+			n := FractalNoise(p, 4)
+			minimum = math.Min(minimum, n)
+			maximum = math.Max(maximum, n)
+		}
+
+		t.Run("it returns values in [-1, 1] spread over the range", func(t *testing.T) {
+			if minimum < -1.0 || 1.0 < maximum || -0.3 < minimum || maximum < 0.3 {
+				t.Errorf("got: [%f, %f], want: values spread in [-1, 1]", minimum, maximum)
+			}
+		})
+	})
+}
+
+func TestNoiseTextureEvaluate(t *testing.T) {
+	texture := &NoiseTexture{
+		Color1:    CreateDefaultColor(Black),
+		Color2:    CreateDefaultColor(White),
+		Frequency: 4.0,
+		Octaves:   3,
+		Mapping:   PositionMapping,
+	}
+	point := TexturePoint{Position: Vector{X: 0.3, Y: 0.7, Z: 0.1}}
+
+	expected := float32(0.5 + 0.5*FractalNoise(Vector{X: 1.2, Y: 2.8, Z: 0.4}, 3))
+	actual := texture.Evaluate(point)
+
+	if math.Abs(float64(actual.R-expected)) > 1e-6 || actual.R != actual.G {
+		t.Errorf("Evaluate(%v) must return gray %f, actual %v", point, expected, actual)
+	}
+}
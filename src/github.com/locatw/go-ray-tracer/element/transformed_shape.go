@@ -0,0 +1,87 @@
+package element
+
+import (
+	"math"
+
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Shape placed in the world by a transform. Rays are transformed into the object space of Shape,
+// so the same shape can be shared by many instances without copying it.
+type TransformedShape struct {
+	Shape     Shape
+	Transform transform.Transform
+}
+
+func (shape *TransformedShape) Intersect(ray Ray) *HitInfo {
+	dir := shape.Transform.Invert().TransformVector(ray.Direction)
+	scale := dir.Length()
+
+	objectRay := Ray{
+		Origin:    shape.Transform.Invert().TransformPoint(ray.Origin),
+		Direction: Multiply(1.0/scale, dir),
+		Time:      ray.Time,
+	}
+
+	hitInfo := shape.Shape.Intersect(objectRay)
+	if hitInfo == nil {
+		return nil
+	}
+
+	// distance in object space is scaled by the length of the transformed direction
+	t := hitInfo.T / scale
+
+	return &HitInfo{
+		Object:        hitInfo.Object,
+		Position:      Add(ray.Origin, Multiply(t, ray.Direction)),
+		Normal:        Normalize(shape.Transform.TransformNormal(hitInfo.Normal)),
+		ShadingNormal: Normalize(shape.Transform.TransformNormal(hitInfo.ShadingNormal)),
+		T:             t,
+		UV:            hitInfo.UV,
+		Dpdu:          shape.Transform.TransformVector(hitInfo.Dpdu),
+		Dpdv:          shape.Transform.TransformVector(hitInfo.Dpdv),
+		Barycentric:   hitInfo.Barycentric,
+	}
+}
+
+func (shape *TransformedShape) GetMaterial() Material {
+	return shape.Shape.GetMaterial()
+}
+
+// Return the bounds of the transformed shape.
+// If the shape is not bounded, the box is infinite and IsFinite returns false.
+func (shape *TransformedShape) Bounds() AABB {
+	bounded, ok := shape.Shape.(Bounded)
+	if !ok {
+		inf := math.Inf(1)
+		return AABB{Min: Vector{X: -inf, Y: -inf, Z: -inf}, Max: Vector{X: inf, Y: inf, Z: inf}}
+	}
+
+	return TransformAABB(shape.Transform, bounded.Bounds())
+}
+
+func TransformAABB(t transform.Transform, box AABB) AABB {
+	if box.IsEmpty() {
+		return box
+	}
+
+	result := CreateEmptyAABB()
+
+	for i := 0; i < 8; i++ {
+		corner := box.Min
+		if i&1 != 0 {
+			corner.X = box.Max.X
+		}
+		if i&2 != 0 {
+			corner.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = box.Max.Z
+		}
+
+		result = UnionAABBPoint(result, t.TransformPoint(corner))
+	}
+
+	return result
+}
@@ -0,0 +1,108 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func distance(v1 Vector, v2 Vector) float64 {
+	v := Subtract(v1, v2)
+
+	return v.Length()
+}
+
+func TestTransformedShapeIntersect(t *testing.T) {
+	sphere := &Sphere{Center: CreateZeroVector(), Radius: 1.0, Material: CreateDefaultMaterial()}
+
+	t.Run("When a shape is scaled and translated", func(t *testing.T) {
+		shape := &TransformedShape{
+			Shape: sphere,
+			Transform: transform.Compose(
+				transform.CreateScaling(Vector{X: 1.0, Y: 2.0, Z: 1.0}),
+				transform.CreateTranslation(Vector{X: 0.0, Y: 0.0, Z: -5.0})),
+		}
+
+		t.Run("it returns hit info in world space", func(t *testing.T) {
+			ray := CreateRay(Vector{X: 0.0, Y: 0.0, Z: 5.0}, Multiply(-1.0, CreateAxisVector(ZAxis)))
+
+			hitInfo := shape.Intersect(ray)
+			if hitInfo == nil {
+				t.Fatalf("got: %v, want: not nil", hitInfo)
+			}
+
+			if hitInfo.Object != sphere {
+				t.Errorf("got: %v, want: %v", hitInfo.Object, sphere)
+			}
+			if 1.0e-9 < math.Abs(hitInfo.T-9.0) {
+				t.Errorf("got: %f, want: %f", hitInfo.T, 9.0)
+			}
+			expectedPos := Vector{X: 0.0, Y: 0.0, Z: -4.0}
+			if 1.0e-9 < distance(hitInfo.Position, expectedPos) {
+				t.Errorf("got: %v, want: %v", hitInfo.Position, expectedPos)
+			}
+		})
+
+		t.Run("it returns normal transformed by the inverse transpose", func(t *testing.T) {
+			// hit the stretched sphere at 45 degrees in object space
+			ray := CreateRay(Vector{X: 10.0, Y: math.Sqrt(2.0), Z: -5.0}, Multiply(-1.0, CreateAxisVector(XAxis)))
+
+			hitInfo := shape.Intersect(ray)
+			if hitInfo == nil {
+				t.Fatalf("got: %v, want: not nil", hitInfo)
+			}
+
+			expected := Normalize(Vector{X: 1.0, Y: 0.5, Z: 0.0})
+			if 1.0e-9 < distance(hitInfo.Normal, expected) {
+				t.Errorf("got: %v, want: %v", hitInfo.Normal, expected)
+			}
+		})
+	})
+
+	t.Run("When a shape is instanced twice", func(t *testing.T) {
+		left := &TransformedShape{Shape: sphere, Transform: transform.CreateTranslation(Vector{X: -3.0, Y: 0.0, Z: 0.0})}
+		right := &TransformedShape{Shape: sphere, Transform: transform.CreateTranslation(Vector{X: 3.0, Y: 0.0, Z: 0.0})}
+		ray := CreateRay(Vector{X: 3.0, Y: 0.0, Z: 5.0}, Multiply(-1.0, CreateAxisVector(ZAxis)))
+
+		t.Run("each instance is placed by its own transform", func(t *testing.T) {
+			if hitInfo := left.Intersect(ray); hitInfo != nil {
+				t.Errorf("got: %v, want: nil", hitInfo)
+			}
+			if hitInfo := right.Intersect(ray); hitInfo == nil {
+				t.Errorf("got: nil, want: not nil")
+			}
+		})
+	})
+}
+
+func TestTransformedShapeBounds(t *testing.T) {
+	t.Run("When a shape is bounded", func(t *testing.T) {
+		shape := &TransformedShape{
+			Shape:     &Sphere{Center: CreateZeroVector(), Radius: 1.0},
+			Transform: transform.CreateTranslation(Vector{X: 1.0, Y: 2.0, Z: 3.0}),
+		}
+		expected := AABB{Min: Vector{X: 0.0, Y: 1.0, Z: 2.0}, Max: Vector{X: 2.0, Y: 3.0, Z: 4.0}}
+
+		t.Run("it returns transformed bounds", func(t *testing.T) {
+			result := shape.Bounds()
+			if result != expected {
+				t.Errorf("got: %v, want: %v", result, expected)
+			}
+		})
+	})
+
+	t.Run("When a shape is not bounded", func(t *testing.T) {
+		shape := &TransformedShape{
+			Shape:     &Plane{Center: CreateZeroVector(), Normal: CreateAxisVector(YAxis)},
+			Transform: transform.CreateTranslation(Vector{X: 1.0, Y: 2.0, Z: 3.0}),
+		}
+
+		t.Run("it is treated as an unbounded shape", func(t *testing.T) {
+			if IsBoundedShape(shape) {
+				t.Errorf("got: %t, want: %t", true, false)
+			}
+		})
+	})
+}
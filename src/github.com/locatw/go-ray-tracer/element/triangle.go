@@ -0,0 +1,195 @@
+package element
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+type UV struct {
+	U, V float64
+}
+
+// Barycentric coordinates of a point on a triangle.
+// W0, W1 and W2 are the weights of the first, second and third vertex, and their sum is 1.
+type Barycentric struct {
+	W0, W1, W2 float64
+}
+
+type Triangle struct {
+	Vertices [3]Vector
+	// Per-vertex normals. If nil, the geometric normal is used.
+	Normals *[3]Vector
+	// Per-vertex texture coordinates. If nil, the barycentric parameterization is used.
+	UVs      *[3]UV
+	Material Material
+}
+
+// Intersect a ray with the triangle by the watertight ray/triangle intersection algorithm
+// (Woop, Benthin and Wald, 2013). A ray which passes through a shared edge or vertex
+// of adjacent triangles never falls through the gap between them.
+func (triangle *Triangle) Intersect(ray Ray) *HitInfo {
+	// the axis where the ray direction is maximal becomes z
+	kz := maxDimension(ray.Direction)
+	kx := (kz + 1) % 3
+	ky := (kx + 1) % 3
+	if ray.Direction.Component(kz) < 0.0 {
+		kx, ky = ky, kx
+	}
+
+	// shear constants to transform the ray direction into +z
+	dz := ray.Direction.Component(kz)
+	sx := ray.Direction.Component(kx) / dz
+	sy := ray.Direction.Component(ky) / dz
+	sz := 1.0 / dz
+
+	a := Subtract(triangle.Vertices[0], ray.Origin)
+	b := Subtract(triangle.Vertices[1], ray.Origin)
+	c := Subtract(triangle.Vertices[2], ray.Origin)
+
+	ax := a.Component(kx) - sx*a.Component(kz)
+	ay := a.Component(ky) - sy*a.Component(kz)
+	bx := b.Component(kx) - sx*b.Component(kz)
+	by := b.Component(ky) - sy*b.Component(kz)
+	cx := c.Component(kx) - sx*c.Component(kz)
+	cy := c.Component(ky) - sy*c.Component(kz)
+
+	// scaled barycentric coordinates
+	u := cx*by - cy*bx
+	v := ax*cy - ay*cx
+	w := bx*ay - by*ax
+
+	if (u < 0.0 || v < 0.0 || w < 0.0) && (0.0 < u || 0.0 < v || 0.0 < w) {
+		return nil
+	}
+
+	det := u + v + w
+	if det == 0.0 {
+		return nil
+	}
+
+	// scaled distance to the hit point
+	az := sz * a.Component(kz)
+	bz := sz * b.Component(kz)
+	cz := sz * c.Component(kz)
+	scaledT := u*az + v*bz + w*cz
+
+	if (det < 0.0 && 0.0 <= scaledT) || (0.0 < det && scaledT <= 0.0) {
+		return nil
+	}
+
+	invDet := 1.0 / det
+	t := scaledT * invDet
+	barycentric := Barycentric{W0: u * invDet, W1: v * invDet, W2: w * invDet}
+
+	pos := Add(ray.Origin, Multiply(t, ray.Direction))
+	dpdu, dpdv := triangle.PositionDerivatives()
+
+	return &HitInfo{
+		Object:        triangle,
+		Position:      pos,
+		Normal:        triangle.GeometricNormal(),
+		ShadingNormal: triangle.InterpolateNormal(barycentric),
+		T:             t,
+		UV:            triangle.InterpolateUV(barycentric),
+		Dpdu:          dpdu,
+		Dpdv:          dpdv,
+		Barycentric:   barycentric,
+	}
+}
+
+func (triangle *Triangle) GetMaterial() Material {
+	return triangle.Material
+}
+
+func (triangle *Triangle) Bounds() AABB {
+	bounds := CreateEmptyAABB()
+
+	for _, vertex := range triangle.Vertices {
+		bounds = UnionAABBPoint(bounds, vertex)
+	}
+
+	return bounds
+}
+
+func (triangle *Triangle) GeometricNormal() Vector {
+	e1 := Subtract(triangle.Vertices[1], triangle.Vertices[0])
+	e2 := Subtract(triangle.Vertices[2], triangle.Vertices[0])
+
+	return Normalize(Cross(e1, e2))
+}
+
+func (triangle *Triangle) InterpolateNormal(barycentric Barycentric) Vector {
+	if triangle.Normals == nil {
+		return triangle.GeometricNormal()
+	}
+
+	normals := triangle.Normals
+	n := AddAll(
+		Multiply(barycentric.W0, normals[0]),
+		Multiply(barycentric.W1, normals[1]),
+		Multiply(barycentric.W2, normals[2]))
+
+	return Normalize(n)
+}
+
+// Return partial derivatives of the position with respect to UV, which are constant over the triangle.
+// If UVs are degenerate, tangents of the geometric normal are returned.
+func (triangle *Triangle) PositionDerivatives() (Vector, Vector) {
+	uvs := triangle.uvs()
+
+	du1 := uvs[1].U - uvs[0].U
+	dv1 := uvs[1].V - uvs[0].V
+	du2 := uvs[2].U - uvs[0].U
+	dv2 := uvs[2].V - uvs[0].V
+	det := du1*dv2 - dv1*du2
+
+	e1 := Subtract(triangle.Vertices[1], triangle.Vertices[0])
+	e2 := Subtract(triangle.Vertices[2], triangle.Vertices[0])
+
+	if math.Abs(det) < 1e-12 {
+		frame := CreateFrame(triangle.GeometricNormal())
+		return frame.Tangent, frame.Bitangent
+	}
+
+	invDet := 1.0 / det
+	dpdu := Multiply(invDet, Subtract(Multiply(dv2, e1), Multiply(dv1, e2)))
+	dpdv := Multiply(invDet, Subtract(Multiply(du1, e2), Multiply(du2, e1)))
+
+	return dpdu, dpdv
+}
+
+func (triangle *Triangle) uvs() [3]UV {
+	if triangle.UVs != nil {
+		return *triangle.UVs
+	}
+
+	return [3]UV{{U: 0.0, V: 0.0}, {U: 1.0, V: 0.0}, {U: 1.0, V: 1.0}}
+}
+
+func (triangle *Triangle) InterpolateUV(barycentric Barycentric) UV {
+	uvs := triangle.uvs()
+
+	return UV{
+		U: barycentric.W0*uvs[0].U + barycentric.W1*uvs[1].U + barycentric.W2*uvs[2].U,
+		V: barycentric.W0*uvs[0].V + barycentric.W1*uvs[1].V + barycentric.W2*uvs[2].V,
+	}
+}
+
+func maxDimension(v Vector) Axis {
+	x := math.Abs(v.X)
+	y := math.Abs(v.Y)
+	z := math.Abs(v.Z)
+
+	if y < x {
+		if z < x {
+			return XAxis
+		}
+		return ZAxis
+	}
+
+	if z < y {
+		return YAxis
+	}
+	return ZAxis
+}
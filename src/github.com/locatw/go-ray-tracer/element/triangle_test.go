@@ -0,0 +1,181 @@
+package element
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func createTestTriangle() Triangle {
+	return Triangle{
+		Vertices: [3]Vector{
+			{X: 0.0, Y: 0.0, Z: 0.0},
+			{X: 1.0, Y: 0.0, Z: 0.0},
+			{X: 0.0, Y: 1.0, Z: 0.0},
+		},
+		Material: CreateDefaultMaterial(),
+	}
+}
+
+func TestTriangleIntersect(t *testing.T) {
+	t.Run("When a ray hits inside of a triangle", func(t *testing.T) {
+		triangle := createTestTriangle()
+		ray := CreateRay(Vector{X: 0.25, Y: 0.5, Z: 2.0}, Multiply(-1.0, CreateAxisVector(ZAxis)))
+
+		hitInfo := triangle.Intersect(ray)
+
+		t.Run("it returns hit info", func(t *testing.T) {
+			if hitInfo == nil {
+				t.Fatalf("got: %v, want: not nil", hitInfo)
+			}
+
+			if hitInfo.Object != &triangle {
+				t.Errorf("got: %v, want: %v", hitInfo.Object, &triangle)
+			}
+
+			if math.Abs(hitInfo.T-2.0) > epsilon {
+				t.Errorf("got: %f, want: %f", hitInfo.T, 2.0)
+			}
+
+			expectedPos := Vector{X: 0.25, Y: 0.5, Z: 0.0}
+			if !hitInfo.Position.NearlyEqual(expectedPos) {
+				t.Errorf("got: %v, want: %v", hitInfo.Position, expectedPos)
+			}
+
+			expectedNormal := CreateAxisVector(ZAxis)
+			if !hitInfo.Normal.NearlyEqual(expectedNormal) {
+				t.Errorf("got: %v, want: %v", hitInfo.Normal, expectedNormal)
+			}
+		})
+
+		t.Run("it returns barycentric coordinates of the hit position", func(t *testing.T) {
+			expected := Barycentric{W0: 0.25, W1: 0.25, W2: 0.5}
+			b := hitInfo.Barycentric
+			if math.Abs(b.W0-expected.W0) > epsilon ||
+				math.Abs(b.W1-expected.W1) > epsilon ||
+				math.Abs(b.W2-expected.W2) > epsilon {
+				t.Errorf("got: %v, want: %v", b, expected)
+			}
+		})
+	})
+
+	t.Run("When a triangle has vertex normals", func(t *testing.T) {
+		triangle := createTestTriangle()
+		triangle.Normals = &[3]Vector{
+			CreateAxisVector(XAxis),
+			CreateAxisVector(YAxis),
+			CreateAxisVector(ZAxis),
+		}
+		ray := CreateRay(Vector{X: 0.25, Y: 0.5, Z: 2.0}, Multiply(-1.0, CreateAxisVector(ZAxis)))
+
+		hitInfo := triangle.Intersect(ray)
+
+		t.Run("it returns the geometric normal and the interpolated shading normal", func(t *testing.T) {
+			expectedShadingNormal := triangle.InterpolateNormal(hitInfo.Barycentric)
+			if !hitInfo.Normal.NearlyEqual(CreateAxisVector(ZAxis)) || !hitInfo.ShadingNormal.NearlyEqual(expectedShadingNormal) {
+				t.Errorf("got: %v and %v, want: %v and %v", hitInfo.Normal, hitInfo.ShadingNormal, CreateAxisVector(ZAxis), expectedShadingNormal)
+			}
+		})
+	})
+
+	t.Run("When a ray hits a back face of a triangle", func(t *testing.T) {
+		triangle := createTestTriangle()
+		ray := CreateRay(Vector{X: 0.25, Y: 0.25, Z: -1.0}, CreateAxisVector(ZAxis))
+
+		t.Run("it returns hit info", func(t *testing.T) {
+			hitInfo := triangle.Intersect(ray)
+
+			if hitInfo == nil || math.Abs(hitInfo.T-1.0) > epsilon {
+				t.Errorf("got: %v, want: hit at T = %f", hitInfo, 1.0)
+			}
+		})
+	})
+
+	patterns := []struct {
+		name string
+		ray  Ray
+	}{
+		{
+			name: "When a ray passes outside of a triangle",
+			ray:  CreateRay(Vector{X: 1.0, Y: 1.0, Z: 1.0}, Multiply(-1.0, CreateAxisVector(ZAxis))),
+		},
+		{
+			name: "When a triangle is behind a ray",
+			ray:  CreateRay(Vector{X: 0.25, Y: 0.25, Z: 1.0}, CreateAxisVector(ZAxis)),
+		},
+		{
+			name: "When a ray is parallel to a triangle",
+			ray:  CreateRay(Vector{X: -1.0, Y: 0.25, Z: 0.0}, CreateAxisVector(XAxis)),
+		},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			triangle := createTestTriangle()
+
+			t.Run("it returns nil", func(t *testing.T) {
+				hitInfo := triangle.Intersect(pattern.ray)
+
+				if hitInfo != nil {
+					t.Errorf("got: %v, want: %v", hitInfo, nil)
+				}
+			})
+		})
+	}
+
+	t.Run("When a ray passes through a shared edge of two triangles", func(t *testing.T) {
+		triangle1 := createTestTriangle()
+		triangle2 := Triangle{
+			Vertices: [3]Vector{
+				{X: 1.0, Y: 0.0, Z: 0.0},
+				{X: 1.0, Y: 1.0, Z: 0.0},
+				{X: 0.0, Y: 1.0, Z: 0.0},
+			},
+			Material: CreateDefaultMaterial(),
+		}
+
+		t.Run("it hits at least one of them", func(t *testing.T) {
+			for i := 1; i < 100; i++ {
+				s := float64(i) / 100.0
+				edgePoint := Vector{X: s, Y: 1.0 - s, Z: 0.0}
+				offset := Vector{X: -0.1, Y: 0.3, Z: 3.0}
+				ray := CreateRay(Add(edgePoint, offset), Multiply(-1.0, offset))
+
+				if triangle1.Intersect(ray) == nil && triangle2.Intersect(ray) == nil {
+					t.Errorf("ray %v falls through the shared edge", ray)
+				}
+			}
+		})
+	})
+}
+
+func TestTriangleInterpolateNormal(t *testing.T) {
+	triangle := createTestTriangle()
+	triangle.Normals = &[3]Vector{
+		CreateAxisVector(XAxis),
+		CreateAxisVector(YAxis),
+		CreateAxisVector(ZAxis),
+	}
+	barycentric := Barycentric{W0: 0.5, W1: 0.5, W2: 0.0}
+	expected := Normalize(Vector{X: 1.0, Y: 1.0, Z: 0.0})
+
+	result := triangle.InterpolateNormal(barycentric)
+
+	if !result.NearlyEqual(expected) {
+		t.Errorf("InterpolateNormal(%v) must return %v, actual is %v", barycentric, expected, result)
+	}
+}
+
+func TestTriangleInterpolateUV(t *testing.T) {
+	triangle := createTestTriangle()
+	triangle.UVs = &[3]UV{{U: 0.0, V: 0.0}, {U: 1.0, V: 0.0}, {U: 0.0, V: 1.0}}
+	barycentric := Barycentric{W0: 0.5, W1: 0.25, W2: 0.25}
+	expected := UV{U: 0.25, V: 0.25}
+
+	result := triangle.InterpolateUV(barycentric)
+
+	if math.Abs(result.U-expected.U) > epsilon || math.Abs(result.V-expected.V) > epsilon {
+		t.Errorf("InterpolateUV(%v) must return %v, actual is %v", barycentric, expected, result)
+	}
+}
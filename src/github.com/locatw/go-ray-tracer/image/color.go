@@ -78,3 +78,13 @@ func DivideScalar(color Color, scalar float64) Color {
 	a := float32(scalar)
 	return Color{R: color.R / a, G: color.G / a, B: color.B / a}
 }
+
+// Relative luminance of a color in linear sRGB (Rec. 709).
+func Luminance(color Color) float64 {
+	return 0.2126*float64(color.R) + 0.7152*float64(color.G) + 0.0722*float64(color.B)
+}
+
+// Interpolate linearly from color1 at t = 0 to color2 at t = 1.
+func LerpColor(t float64, color1 Color, color2 Color) Color {
+	return AddColor(MultiplyScalar(1.0-t, color1), MultiplyScalar(t, color2))
+}
@@ -0,0 +1,223 @@
+package image
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Color space of values stored in an Image.
+// The zero value is LinearSRGB, which is what the renderer produces.
+type ColorSpace int
+
+const (
+	// sRGB primaries with D65 white point and linear values.
+	LinearSRGB ColorSpace = iota
+	// sRGB primaries with values encoded by the sRGB transfer function.
+	SRGB
+	// ITU-R BT.2020 primaries with D65 white point and linear values.
+	Rec2020
+	// ACES AP1 primaries with D60 white point and linear values.
+	ACEScg
+	// Values which are not colors such as normals and depth. They are never converted.
+	NonColor
+)
+
+var colorSpaceNames = map[ColorSpace]string{
+	LinearSRGB: "linear-srgb",
+	SRGB:       "srgb",
+	Rec2020:    "rec2020",
+	ACEScg:     "acescg",
+	NonColor:   "non-color",
+}
+
+func (space ColorSpace) String() string {
+	if name, ok := colorSpaceNames[space]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("ColorSpace(%d)", int(space))
+}
+
+func ParseColorSpace(name string) (ColorSpace, error) {
+	lower := strings.ToLower(name)
+
+	for space, spaceName := range colorSpaceNames {
+		if spaceName == lower {
+			return space, nil
+		}
+	}
+
+	return LinearSRGB, fmt.Errorf("unknown color space: %s", name)
+}
+
+// Whether values are proportional to radiance.
+func (space ColorSpace) IsLinear() bool {
+	return space != SRGB && space != NonColor
+}
+
+// Matrix which converts linear sRGB to linear values of each color space.
+// ACEScg uses Bradford chromatic adaptation from D65 to D60.
+var fromLinearSRGB = map[ColorSpace]Matrix3{
+	LinearSRGB: IdentityMatrix3(),
+	SRGB:       IdentityMatrix3(),
+	Rec2020: {
+		{0.6274039, 0.3292830, 0.0433131},
+		{0.0690973, 0.9195404, 0.0113623},
+		{0.0163914, 0.0880133, 0.8955953},
+	},
+	ACEScg: {
+		{0.6130974, 0.3395231, 0.0473795},
+		{0.0701937, 0.9163539, 0.0134524},
+		{0.0206156, 0.1095698, 0.8698151},
+	},
+}
+
+// Pair of color spaces which a color is converted between.
+type conversion struct {
+	from, to ColorSpace
+}
+
+// Matrix which converts linear values between each pair of color spaces, computed from fromLinearSRGB.
+var conversionMatrices = createConversionMatrices()
+
+func createConversionMatrices() map[conversion]Matrix3 {
+	matrices := make(map[conversion]Matrix3)
+
+	for from, fromMatrix := range fromLinearSRGB {
+		inverse, ok := InvertMatrix3(fromMatrix)
+		if !ok {
+			panic(fmt.Sprintf("singular matrix of color space: %v", from))
+		}
+
+		for to, toMatrix := range fromLinearSRGB {
+			matrices[conversion{from: from, to: to}] = MultiplyMatrices3(toMatrix, inverse)
+		}
+	}
+
+	return matrices
+}
+
+// Matrix which converts CIE XYZ to linear sRGB.
+var xyzToLinearSRGB = Matrix3{
+	{3.2404542, -1.5371385, -0.4985314},
+	{-0.9692660, 1.8760108, 0.0415560},
+	{0.0556434, -0.2040259, 1.0572252},
+}
+
+// Convert a color given by CIE xy chromaticity and luminance Y to linear sRGB.
+func XyYToLinearSRGB(x float64, y float64, luminance float64) Color {
+	if y == 0.0 {
+		return CreateDefaultColor(Black)
+	}
+
+	xyz := Color{
+		R: float32(x / y * luminance),
+		G: float32(luminance),
+		B: float32((1.0 - x - y) / y * luminance),
+	}
+
+	return MultiplyMatrix3(xyzToLinearSRGB, xyz)
+}
+
+// Chromaticities of red, green, blue and white, used by formats which declare primaries.
+type Chromaticities struct {
+	Red, Green, Blue, White [2]float32
+}
+
+var chromaticities = map[ColorSpace]Chromaticities{
+	LinearSRGB: {Red: [2]float32{0.64, 0.33}, Green: [2]float32{0.30, 0.60}, Blue: [2]float32{0.15, 0.06}, White: [2]float32{0.3127, 0.3290}},
+	SRGB:       {Red: [2]float32{0.64, 0.33}, Green: [2]float32{0.30, 0.60}, Blue: [2]float32{0.15, 0.06}, White: [2]float32{0.3127, 0.3290}},
+	Rec2020:    {Red: [2]float32{0.708, 0.292}, Green: [2]float32{0.170, 0.797}, Blue: [2]float32{0.131, 0.046}, White: [2]float32{0.3127, 0.3290}},
+	ACEScg:     {Red: [2]float32{0.713, 0.293}, Green: [2]float32{0.165, 0.830}, Blue: [2]float32{0.128, 0.044}, White: [2]float32{0.32168, 0.33767}},
+}
+
+// Return chromaticities of a color space. ok is false for NonColor.
+func (space ColorSpace) Chromaticities() (Chromaticities, bool) {
+	c, ok := chromaticities[space]
+	return c, ok
+}
+
+// Return the linear color space whose chromaticities are nearly equal to c.
+func LinearColorSpaceFromChromaticities(c Chromaticities) (ColorSpace, bool) {
+	nearlyEqual := func(a [2]float32, b [2]float32) bool {
+		return math.Abs(float64(a[0]-b[0])) <= 0.001 && math.Abs(float64(a[1]-b[1])) <= 0.001
+	}
+
+	for _, space := range []ColorSpace{LinearSRGB, Rec2020, ACEScg} {
+		known := chromaticities[space]
+		if nearlyEqual(c.Red, known.Red) && nearlyEqual(c.Green, known.Green) &&
+			nearlyEqual(c.Blue, known.Blue) && nearlyEqual(c.White, known.White) {
+			return space, true
+		}
+	}
+
+	return LinearSRGB, false
+}
+
+// sRGB transfer function which encodes a linear value.
+func EncodeSRGB(value float32) float32 {
+	v := float64(value)
+	if v <= 0.0031308 {
+		return float32(12.92 * v)
+	}
+
+	return float32(1.055*math.Pow(v, 1.0/2.4) - 0.055)
+}
+
+// Inverse of the sRGB transfer function.
+func DecodeSRGB(value float32) float32 {
+	v := float64(value)
+	if v <= 0.04045 {
+		return float32(v / 12.92)
+	}
+
+	return float32(math.Pow((v+0.055)/1.055, 2.4))
+}
+
+// Convert a color from a color space to another.
+// Colors are not converted if either of color spaces is NonColor.
+func ConvertColor(color Color, from ColorSpace, to ColorSpace) Color {
+	if from == to || from == NonColor || to == NonColor {
+		return color
+	}
+
+	if from == SRGB {
+		color = Color{R: DecodeSRGB(color.R), G: DecodeSRGB(color.G), B: DecodeSRGB(color.B)}
+	}
+
+	if _, ok := fromLinearSRGB[from]; !ok {
+		panic(fmt.Sprintf("unknown color space: %d", from))
+	}
+	if _, ok := fromLinearSRGB[to]; !ok {
+		panic(fmt.Sprintf("unknown color space: %d", to))
+	}
+
+	if fromLinearSRGB[from] != fromLinearSRGB[to] {
+		color = MultiplyMatrix3(conversionMatrices[conversion{from: from, to: to}], color)
+	}
+
+	if to == SRGB {
+		color = Color{R: EncodeSRGB(color.R), G: EncodeSRGB(color.G), B: EncodeSRGB(color.B)}
+	}
+
+	return color
+}
+
+// Return a copy of an image whose pixels are converted to a color space.
+func ConvertImage(img Image, to ColorSpace) Image {
+	result := Image{Width: img.Width, Height: img.Height, Pixels: make([]Pixel, len(img.Pixels)), ColorSpace: to}
+
+	copy(result.Pixels, img.Pixels)
+
+	if img.ColorSpace == NonColor {
+		result.ColorSpace = NonColor
+		return result
+	}
+
+	for i := range result.Pixels {
+		result.Pixels[i].Color = ConvertColor(img.Pixels[i].Color, img.ColorSpace, to)
+	}
+
+	return result
+}
@@ -0,0 +1,159 @@
+package image
+
+import (
+	"math"
+	"testing"
+)
+
+func nearlyEqualColor(c1 Color, c2 Color, tolerance float64) bool {
+	return math.Abs(float64(c1.R-c2.R)) <= tolerance &&
+		math.Abs(float64(c1.G-c2.G)) <= tolerance &&
+		math.Abs(float64(c1.B-c2.B)) <= tolerance
+}
+
+func TestEncodeSRGB(t *testing.T) {
+	patterns := []struct {
+		value    float32
+		expected float32
+	}{
+		{value: 0.0, expected: 0.0},
+		{value: 1.0, expected: 1.0},
+		{value: 0.0031308, expected: 0.04045},
+		{value: 0.001, expected: 0.01292},
+		{value: 0.5, expected: 0.7353569},
+		{value: 0.18, expected: 0.4613561},
+	}
+
+	for _, pattern := range patterns {
+		actual := EncodeSRGB(pattern.value)
+		if 1e-5 < math.Abs(float64(actual-pattern.expected)) {
+			t.Errorf("EncodeSRGB(%f) must return %f, actual %f", pattern.value, pattern.expected, actual)
+		}
+
+		decoded := DecodeSRGB(actual)
+		if 1e-5 < math.Abs(float64(decoded-pattern.value)) {
+			t.Errorf("DecodeSRGB(%f) must return %f, actual %f", actual, pattern.value, decoded)
+		}
+	}
+}
+
+func TestXyYToLinearSRGB(t *testing.T) {
+	patterns := []struct {
+		x, y, luminance float64
+		expected        Color
+	}{
+		// D65 white point
+		{x: 0.3127, y: 0.3290, luminance: 2.0, expected: Color{R: 2.0, G: 2.0, B: 2.0}},
+		// red primary, whose luminance is 0.2126 at full intensity
+		{x: 0.64, y: 0.33, luminance: 0.2126, expected: Color{R: 1.0, G: 0.0, B: 0.0}},
+		{x: 0.3, y: 0.0, luminance: 1.0, expected: Color{R: 0.0, G: 0.0, B: 0.0}},
+	}
+
+	for _, pattern := range patterns {
+		actual := XyYToLinearSRGB(pattern.x, pattern.y, pattern.luminance)
+		if !nearlyEqualColor(actual, pattern.expected, 1e-3) {
+			t.Errorf("XyYToLinearSRGB(%f, %f, %f) must return %v, actual %v", pattern.x, pattern.y, pattern.luminance, pattern.expected, actual)
+		}
+	}
+}
+
+func TestConvertColor(t *testing.T) {
+	spaces := []ColorSpace{LinearSRGB, SRGB, Rec2020, ACEScg}
+	color := Color{R: 0.8, G: 0.3, B: 0.1}
+
+	for _, from := range spaces {
+		for _, to := range spaces {
+			converted := ConvertColor(color, from, to)
+			restored := ConvertColor(converted, to, from)
+
+			if !nearlyEqualColor(restored, color, 1e-5) {
+				t.Errorf("ConvertColor must restore %v from %s via %s, actual %v", color, from, to, restored)
+			}
+		}
+	}
+
+	white := Color{R: 1.0, G: 1.0, B: 1.0}
+	for _, to := range spaces {
+		actual := ConvertColor(white, LinearSRGB, to)
+
+		if !nearlyEqualColor(actual, white, 1e-4) {
+			t.Errorf("ConvertColor(%v, %s, %s) must keep white, actual %v", white, LinearSRGB, to, actual)
+		}
+	}
+
+	red := ConvertColor(Color{R: 1.0, G: 0.0, B: 0.0}, LinearSRGB, Rec2020)
+	expected := Color{R: 0.6274039, G: 0.0690973, B: 0.0163914}
+	if !nearlyEqualColor(red, expected, 1e-6) {
+		t.Errorf("ConvertColor must convert sRGB red to %v in %s, actual %v", expected, Rec2020, red)
+	}
+
+	if actual := ConvertColor(color, ACEScg, NonColor); actual != color {
+		t.Errorf("ConvertColor must not convert to %s, actual %v", NonColor, actual)
+	}
+}
+
+func TestConvertImage(t *testing.T) {
+	t.Run("When an image is non-color", func(t *testing.T) {
+		img := CreateImage(1, 1)
+		img.ColorSpace = NonColor
+		img.Pixels[0].Color = Color{R: -1.0, G: 0.5, B: 0.0}
+
+		converted := ConvertImage(img, SRGB)
+
+		t.Run("it keeps values and color space", func(t *testing.T) {
+			if converted.ColorSpace != NonColor || converted.Pixels[0].Color != img.Pixels[0].Color {
+				t.Errorf("got: %v in %s, want: %v in %s", converted.Pixels[0].Color, converted.ColorSpace, img.Pixels[0].Color, NonColor)
+			}
+		})
+	})
+
+	t.Run("When an image is linear", func(t *testing.T) {
+		img := CreateImage(1, 1)
+		img.Pixels[0].Color = Color{R: 0.5, G: 0.5, B: 0.5}
+
+		converted := ConvertImage(img, SRGB)
+
+		t.Run("it converts values and keeps the original image", func(t *testing.T) {
+			encoded := EncodeSRGB(0.5)
+			if converted.ColorSpace != SRGB || converted.Pixels[0].Color.R != encoded || img.Pixels[0].Color.R != 0.5 {
+				t.Errorf("got: %v in %s, want: %f in %s", converted.Pixels[0].Color, converted.ColorSpace, encoded, SRGB)
+			}
+		})
+	})
+}
+
+func TestParseColorSpace(t *testing.T) {
+	for space := range colorSpaceNames {
+		actual, err := ParseColorSpace(space.String())
+		if err != nil || actual != space {
+			t.Errorf("ParseColorSpace(%s) must return %d, actual %d (error: %v)", space, space, actual, err)
+		}
+	}
+
+	if _, err := ParseColorSpace("adobe-rgb"); err == nil {
+		t.Errorf("ParseColorSpace(adobe-rgb) must return an error")
+	}
+}
+
+func TestInvertMatrix3(t *testing.T) {
+	m := fromLinearSRGB[ACEScg]
+
+	inverse, ok := InvertMatrix3(m)
+	if !ok {
+		t.Fatalf("InvertMatrix3 must invert %v", m)
+	}
+
+	product := MultiplyMatrices3(m, inverse)
+	identity := IdentityMatrix3()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if 1e-9 < math.Abs(product[i][j]-identity[i][j]) {
+				t.Errorf("product of a matrix and its inverse must be identity, actual %v", product)
+			}
+		}
+	}
+
+	if _, ok := InvertMatrix3(Matrix3{}); ok {
+		t.Errorf("InvertMatrix3 must fail for a singular matrix")
+	}
+}
@@ -144,3 +144,34 @@ func TestDivideScalar(t *testing.T) {
 		t.Errorf("DivideScalar(%f, %v) must return %v, actual is %v", scalar, color, expected, result)
 	}
 }
+
+func TestLuminance(t *testing.T) {
+	patterns := []struct {
+		color    Color
+		expected float64
+	}{
+		{color: CreateDefaultColor(White), expected: 1.0},
+		{color: Color{R: 0.0, G: 1.0, B: 0.0}, expected: 0.7152},
+	}
+
+	for _, pattern := range patterns {
+		result := Luminance(pattern.color)
+		if 1e-6 < math.Abs(result-pattern.expected) {
+			t.Errorf("Luminance(%v) must return %f, actual is %f", pattern.color, pattern.expected, result)
+		}
+	}
+}
+
+func TestLerpColor(t *testing.T) {
+	color1 := Color{R: 0.0, G: 0.2, B: 1.0}
+	color2 := Color{R: 1.0, G: 0.4, B: 1.0}
+	expected := Color{R: 0.25, G: 0.25, B: 1.0}
+
+	result := LerpColor(0.25, color1, color2)
+
+	if epsilon < mathex.Abs32(result.R-expected.R) ||
+		epsilon < mathex.Abs32(result.G-expected.G) ||
+		epsilon < mathex.Abs32(result.B-expected.B) {
+		t.Errorf("LerpColor(%f, %v, %v) must return %v, actual is %v", 0.25, color1, color2, expected, result)
+	}
+}
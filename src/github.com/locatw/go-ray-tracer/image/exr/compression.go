@@ -0,0 +1,89 @@
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+)
+
+const (
+	minRunLength = 3
+	maxRunLength = 127
+)
+
+// Reorder bytes so that the first and second halves of each value are separated,
+// and replace bytes by differences from the previous ones.
+// Both RLE and ZIP compression of OpenEXR apply it before compressing.
+func predict(data []byte) []byte {
+	result := make([]byte, len(data))
+
+	half := (len(data) + 1) / 2
+	for i := range data {
+		if i%2 == 0 {
+			result[i/2] = data[i]
+		} else {
+			result[half+i/2] = data[i]
+		}
+	}
+
+	previous := result[0]
+	for i := 1; i < len(result); i++ {
+		current := result[i]
+		result[i] = byte(int(current) - int(previous) + 128 + 256)
+		previous = current
+	}
+
+	return result
+}
+
+// Run length encoding of OpenEXR. A run is stored as (length - 1, value),
+// and a literal sequence as (-length, values...) with signed lengths.
+func compressRle(data []byte) []byte {
+	result := make([]byte, 0, len(data))
+
+	runStart := 0
+	runEnd := 1
+	for runStart < len(data) {
+		for runEnd < len(data) && data[runStart] == data[runEnd] && runEnd-runStart-1 < maxRunLength {
+			runEnd++
+		}
+
+		if minRunLength <= runEnd-runStart {
+			result = append(result, byte(runEnd-runStart-1), data[runStart])
+			runStart = runEnd
+		} else {
+			for runEnd < len(data) &&
+				(runEnd+1 >= len(data) || data[runEnd] != data[runEnd+1] ||
+					runEnd+2 >= len(data) || data[runEnd+1] != data[runEnd+2]) &&
+				runEnd-runStart < maxRunLength {
+				runEnd++
+			}
+
+			result = append(result, byte(int8(runStart-runEnd)))
+			result = append(result, data[runStart:runEnd]...)
+			runStart = runEnd
+		}
+
+		runEnd++
+	}
+
+	return result
+}
+
+func compressZip(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer, err := zlib.NewWriterLevel(&buffer, zlib.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,374 @@
+package exr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+var magicNumber = []byte{0x76, 0x2f, 0x31, 0x01}
+
+// Version 2 of single-part scanline files.
+const version = 2
+
+type PixelType int
+
+const (
+	Half  PixelType = 1
+	Float PixelType = 2
+)
+
+type Compression int
+
+const (
+	NoCompression   Compression = 0
+	RleCompression  Compression = 1
+	ZipsCompression Compression = 2
+	ZipCompression  Compression = 3
+)
+
+func (compression Compression) linesPerBlock() int {
+	if compression == ZipCompression {
+		return 16
+	}
+
+	return 1
+}
+
+// Layer of an image which is written as channels named "<Name>.<channel>".
+// The default layer, which has an empty name, is written as channels named R, G, B and A.
+type Layer struct {
+	Name  string
+	Image image.Image
+	// Subset of "RGBA". A is taken from Pixel.Alpha. If empty, "RGB" is used.
+	Channels  string
+	PixelType PixelType
+}
+
+type Options struct {
+	Compression Compression
+	// Linear color space of the file, which is declared by chromaticities attribute.
+	// Color layers are converted to it, and layers of NonColor images are written as they are.
+	ColorSpace image.ColorSpace
+}
+
+func CreateDefaultOptions() Options {
+	return Options{Compression: ZipCompression, ColorSpace: image.LinearSRGB}
+}
+
+type channel struct {
+	name       string
+	layerIndex int
+	image      *image.Image
+	component  byte
+	pixelType  PixelType
+}
+
+func (c *channel) sampleSize() int {
+	if c.pixelType == Half {
+		return 2
+	}
+
+	return 4
+}
+
+func (c *channel) value(pixel *image.Pixel) float32 {
+	switch c.component {
+	case 'R':
+		return pixel.Color.R
+	case 'G':
+		return pixel.Color.G
+	case 'B':
+		return pixel.Color.B
+	default:
+		return pixel.Alpha
+	}
+}
+
+func WriteExr(path string, layers []Layer, options Options) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	err = Encode(writer, layers, options)
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Encode layers as a scanline OpenEXR image. All layers must have the same size.
+func Encode(writer io.Writer, layers []Layer, options Options) error {
+	channels, err := createChannels(layers)
+	if err != nil {
+		return err
+	}
+
+	switch options.Compression {
+	case NoCompression, RleCompression, ZipsCompression, ZipCompression:
+	default:
+		return fmt.Errorf("unsupported compression: %d", options.Compression)
+	}
+
+	chromaticities, ok := options.ColorSpace.Chromaticities()
+	if !ok || !options.ColorSpace.IsLinear() {
+		return fmt.Errorf("unsupported color space: %s", options.ColorSpace)
+	}
+
+	// channels refer to converted copies so that images of the caller are kept
+	converted := make([]image.Image, len(layers))
+	for i := range channels {
+		layerIndex := channels[i].layerIndex
+		if converted[layerIndex].Pixels == nil {
+			converted[layerIndex] = image.ConvertImage(layers[layerIndex].Image, options.ColorSpace)
+		}
+		channels[i].image = &converted[layerIndex]
+	}
+
+	width := layers[0].Image.Width
+	height := layers[0].Image.Height
+
+	var header bytes.Buffer
+	header.Write(magicNumber)
+	writeInt32(&header, version)
+	writeHeader(&header, channels, width, height, options.Compression, chromaticities)
+
+	linesPerBlock := options.Compression.linesPerBlock()
+	blockCount := (height + linesPerBlock - 1) / linesPerBlock
+
+	blocks := make([][]byte, blockCount)
+	for i := range blocks {
+		blocks[i], err = encodeBlock(channels, width, i*linesPerBlock, linesPerBlock, height, options.Compression)
+		if err != nil {
+			return err
+		}
+	}
+
+	// offset table of chunks which consist of y, data size and data
+	offset := uint64(header.Len() + 8*blockCount)
+	for _, block := range blocks {
+		writeUint64(&header, offset)
+		offset += uint64(8 + len(block))
+	}
+
+	if _, err := writer.Write(header.Bytes()); err != nil {
+		return err
+	}
+
+	var chunkHeader bytes.Buffer
+	for i, block := range blocks {
+		chunkHeader.Reset()
+		writeInt32(&chunkHeader, int32(i*linesPerBlock))
+		writeInt32(&chunkHeader, int32(len(block)))
+
+		if _, err := writer.Write(chunkHeader.Bytes()); err != nil {
+			return err
+		}
+		if _, err := writer.Write(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createChannels(layers []Layer) ([]channel, error) {
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no layers to write")
+	}
+
+	width := layers[0].Image.Width
+	height := layers[0].Image.Height
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("cannot encode an empty image: %dx%d", width, height)
+	}
+
+	channels := make([]channel, 0)
+	names := make(map[string]bool)
+
+	for i := range layers {
+		layer := &layers[i]
+
+		if layer.Image.Width != width || layer.Image.Height != height {
+			return nil, fmt.Errorf("layer %q has size %dx%d, which differs from %dx%d",
+				layer.Name, layer.Image.Width, layer.Image.Height, width, height)
+		}
+
+		if layer.PixelType != Half && layer.PixelType != Float {
+			return nil, fmt.Errorf("layer %q has unsupported pixel type: %d", layer.Name, layer.PixelType)
+		}
+
+		components := layer.Channels
+		if components == "" {
+			components = "RGB"
+		}
+
+		for _, component := range []byte(components) {
+			if !strings.ContainsRune("RGBA", rune(component)) {
+				return nil, fmt.Errorf("layer %q has unknown channel: %c", layer.Name, component)
+			}
+
+			name := string(component)
+			if layer.Name != "" {
+				name = layer.Name + "." + name
+			}
+
+			if names[name] {
+				return nil, fmt.Errorf("channel %q is duplicated", name)
+			}
+			names[name] = true
+
+			channels = append(channels, channel{name: name, layerIndex: i, component: component, pixelType: layer.PixelType})
+		}
+	}
+
+	// channels must be sorted by name
+	sort.Slice(channels, func(i, j int) bool { return channels[i].name < channels[j].name })
+
+	return channels, nil
+}
+
+func writeHeader(buffer *bytes.Buffer, channels []channel, width int, height int, compression Compression,
+	chromaticities image.Chromaticities) {
+	var channelList bytes.Buffer
+	for _, c := range channels {
+		channelList.WriteString(c.name)
+		channelList.WriteByte(0)
+		writeInt32(&channelList, int32(c.pixelType))
+		// pLinear and reserved bytes
+		channelList.Write([]byte{0, 0, 0, 0})
+		// x and y sampling
+		writeInt32(&channelList, 1)
+		writeInt32(&channelList, 1)
+	}
+	channelList.WriteByte(0)
+	writeAttribute(buffer, "channels", "chlist", channelList.Bytes())
+
+	writeAttribute(buffer, "compression", "compression", []byte{byte(compression)})
+
+	var primaries bytes.Buffer
+	for _, xy := range [][2]float32{chromaticities.Red, chromaticities.Green, chromaticities.Blue, chromaticities.White} {
+		writeFloat32(&primaries, xy[0])
+		writeFloat32(&primaries, xy[1])
+	}
+	writeAttribute(buffer, "chromaticities", "chromaticities", primaries.Bytes())
+
+	var window bytes.Buffer
+	writeInt32(&window, 0)
+	writeInt32(&window, 0)
+	writeInt32(&window, int32(width-1))
+	writeInt32(&window, int32(height-1))
+	writeAttribute(buffer, "dataWindow", "box2i", window.Bytes())
+	writeAttribute(buffer, "displayWindow", "box2i", window.Bytes())
+
+	// increasing y
+	writeAttribute(buffer, "lineOrder", "lineOrder", []byte{0})
+
+	var value bytes.Buffer
+	writeFloat32(&value, 1.0)
+	writeAttribute(buffer, "pixelAspectRatio", "float", value.Bytes())
+
+	value.Reset()
+	writeFloat32(&value, 0.0)
+	writeFloat32(&value, 0.0)
+	writeAttribute(buffer, "screenWindowCenter", "v2f", value.Bytes())
+
+	value.Reset()
+	writeFloat32(&value, 1.0)
+	writeAttribute(buffer, "screenWindowWidth", "float", value.Bytes())
+
+	// end of header
+	buffer.WriteByte(0)
+}
+
+func writeAttribute(buffer *bytes.Buffer, name string, attributeType string, value []byte) {
+	buffer.WriteString(name)
+	buffer.WriteByte(0)
+	buffer.WriteString(attributeType)
+	buffer.WriteByte(0)
+	writeInt32(buffer, int32(len(value)))
+	buffer.Write(value)
+}
+
+// Encode scanlines [y, y+lineCount) clipped by height. In each scanline,
+// values of a channel are stored contiguously in the order of the channel list.
+func encodeBlock(channels []channel, width int, y int, lineCount int, height int, compression Compression) ([]byte, error) {
+	if height < y+lineCount {
+		lineCount = height - y
+	}
+
+	var raw bytes.Buffer
+	for line := y; line < y+lineCount; line++ {
+		for i := range channels {
+			c := &channels[i]
+			pixels := c.image.Pixels[line*width : (line+1)*width]
+
+			for j := range pixels {
+				value := c.value(&pixels[j])
+
+				if c.pixelType == Half {
+					var b [2]byte
+					binary.LittleEndian.PutUint16(b[:], FloatToHalf(value))
+					raw.Write(b[:])
+				} else {
+					writeFloat32(&raw, value)
+				}
+			}
+		}
+	}
+
+	data := raw.Bytes()
+
+	var compressed []byte
+	switch compression {
+	case NoCompression:
+		return data, nil
+	case RleCompression:
+		compressed = compressRle(predict(data))
+	case ZipsCompression, ZipCompression:
+		var err error
+		compressed, err = compressZip(predict(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// a block is stored uncompressed if compression doesn't make it smaller
+	if len(data) <= len(compressed) {
+		return data, nil
+	}
+
+	return compressed, nil
+}
+
+func writeInt32(buffer *bytes.Buffer, value int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(value))
+	buffer.Write(b[:])
+}
+
+func writeUint64(buffer *bytes.Buffer, value uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], value)
+	buffer.Write(b[:])
+}
+
+func writeFloat32(buffer *bytes.Buffer, value float32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(value))
+	buffer.Write(b[:])
+}
@@ -0,0 +1,324 @@
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+type decodedFile struct {
+	attributes map[string][]byte
+	channels   []string
+	chunks     map[int][]byte
+}
+
+// Minimal parser of files written by Encode, to check the written structure.
+func parseFile(t *testing.T, data []byte) decodedFile {
+	t.Helper()
+
+	if !bytes.Equal(data[:4], magicNumber) {
+		t.Fatalf("magic number must be %v, actual %v", magicNumber, data[:4])
+	}
+	if v := binary.LittleEndian.Uint32(data[4:8]); v != version {
+		t.Fatalf("version must be %d, actual %d", version, v)
+	}
+
+	readString := func(offset int) (string, int) {
+		end := bytes.IndexByte(data[offset:], 0)
+		return string(data[offset : offset+end]), offset + end + 1
+	}
+
+	file := decodedFile{attributes: map[string][]byte{}, chunks: map[int][]byte{}}
+
+	offset := 8
+	for data[offset] != 0 {
+		var name string
+		name, offset = readString(offset)
+		_, offset = readString(offset)
+		size := int(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+		file.attributes[name] = data[offset : offset+size]
+		offset += size
+	}
+	offset++
+
+	channelList := file.attributes["channels"]
+	for i := 0; channelList[i] != 0; {
+		end := i + bytes.IndexByte(channelList[i:], 0)
+		file.channels = append(file.channels, string(channelList[i:end]))
+		i = end + 1 + 16
+	}
+
+	window := file.attributes["dataWindow"]
+	height := int(binary.LittleEndian.Uint32(window[12:])) + 1
+	linesPerBlock := Compression(file.attributes["compression"][0]).linesPerBlock()
+	blockCount := (height + linesPerBlock - 1) / linesPerBlock
+
+	for i := 0; i < blockCount; i++ {
+		chunkOffset := int(binary.LittleEndian.Uint64(data[offset+8*i:]))
+		y := int(binary.LittleEndian.Uint32(data[chunkOffset:]))
+		size := int(binary.LittleEndian.Uint32(data[chunkOffset+4:]))
+		file.chunks[y] = data[chunkOffset+8 : chunkOffset+8+size]
+	}
+
+	return file
+}
+
+func unpredict(data []byte) []byte {
+	t := make([]byte, len(data))
+	copy(t, data)
+	for i := 1; i < len(t); i++ {
+		t[i] = byte(int(t[i-1]) + int(t[i]) - 128)
+	}
+
+	result := make([]byte, len(t))
+	half := (len(t) + 1) / 2
+	for i := range result {
+		if i%2 == 0 {
+			result[i] = t[i/2]
+		} else {
+			result[i] = t[half+i/2]
+		}
+	}
+
+	return result
+}
+
+func decompressRle(data []byte) []byte {
+	result := make([]byte, 0)
+	for i := 0; i < len(data); {
+		count := int(int8(data[i]))
+		if count < 0 {
+			result = append(result, data[i+1:i+1-count]...)
+			i += 1 - count
+		} else {
+			for j := 0; j <= count; j++ {
+				result = append(result, data[i+1])
+			}
+			i += 2
+		}
+	}
+
+	return result
+}
+
+func createTestImage(width int, height int) image.Image {
+	img := image.CreateImage(width, height)
+	for i := range img.Pixels {
+		v := float32(i)
+		img.Pixels[i].Color = image.Color{R: v, G: v * 0.5, B: 100.0}
+		img.Pixels[i].Alpha = 0.25
+	}
+
+	return img
+}
+
+func TestFloatToHalf(t *testing.T) {
+	patterns := []struct {
+		value    float32
+		expected uint16
+	}{
+		{value: 0.0, expected: 0x0000},
+		{value: float32(math.Copysign(0.0, -1.0)), expected: 0x8000},
+		{value: 1.0, expected: 0x3c00},
+		{value: -2.0, expected: 0xc000},
+		{value: 0.5, expected: 0x3800},
+		{value: 65504.0, expected: 0x7bff},
+		{value: 65536.0, expected: 0x7c00},
+		{value: float32(math.Inf(1)), expected: 0x7c00},
+		{value: float32(math.Inf(-1)), expected: 0xfc00},
+		// smallest subnormal half
+		{value: float32(math.Pow(2, -24)), expected: 0x0001},
+		{value: float32(math.Pow(2, -26)), expected: 0x0000},
+		// 1 + 2^-11 is halfway between 1 and the next half, which is rounded to even
+		{value: 1.0 + float32(math.Pow(2, -11)), expected: 0x3c00},
+		{value: 1.0 + 3.0*float32(math.Pow(2, -11)), expected: 0x3c02},
+	}
+
+	for _, pattern := range patterns {
+		actual := FloatToHalf(pattern.value)
+
+		if actual != pattern.expected {
+			t.Errorf("FloatToHalf(%v) must return %#04x, actual %#04x", pattern.value, pattern.expected, actual)
+		}
+	}
+
+	if nan := FloatToHalf(float32(math.NaN())); nan&0x7c00 != 0x7c00 || nan&0x3ff == 0 {
+		t.Errorf("FloatToHalf(NaN) must return NaN, actual %#04x", nan)
+	}
+}
+
+func TestHalfToFloat(t *testing.T) {
+	for _, value := range []float32{0.0, 1.0, -2.0, 0.5, 65504.0, float32(math.Pow(2, -24)), float32(math.Pow(2, -20))} {
+		actual := HalfToFloat(FloatToHalf(value))
+
+		if actual != value {
+			t.Errorf("HalfToFloat(FloatToHalf(%v)) must return %v, actual %v", value, value, actual)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("When layers are written without compression", func(t *testing.T) {
+		beauty := createTestImage(3, 2)
+		depth := createTestImage(3, 2)
+
+		layers := []Layer{
+			{Image: beauty, Channels: "RGBA", PixelType: Half},
+			{Name: "depth", Image: depth, Channels: "R", PixelType: Float},
+		}
+
+		var buffer bytes.Buffer
+		err := Encode(&buffer, layers, Options{Compression: NoCompression})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file := parseFile(t, buffer.Bytes())
+
+		t.Run("it writes channels sorted by name", func(t *testing.T) {
+			expected := []string{"A", "B", "G", "R", "depth.R"}
+			if len(file.channels) != len(expected) {
+				t.Fatalf("got: %v, want: %v", file.channels, expected)
+			}
+			for i := range expected {
+				if file.channels[i] != expected[i] {
+					t.Errorf("got: %v, want: %v", file.channels, expected)
+				}
+			}
+		})
+
+		t.Run("it writes scanlines per channel", func(t *testing.T) {
+			line := file.chunks[1]
+			// A, B, G and R in half, then depth.R in float
+			if len(line) != 3*2*4+3*4 {
+				t.Fatalf("scanline must have %d bytes, actual %d", 3*2*4+3*4, len(line))
+			}
+
+			alpha := HalfToFloat(binary.LittleEndian.Uint16(line[0:]))
+			red := HalfToFloat(binary.LittleEndian.Uint16(line[3*2*3+2:]))
+			depthRed := math.Float32frombits(binary.LittleEndian.Uint32(line[3*2*4+8:]))
+
+			if alpha != 0.25 || red != 4.0 || depthRed != 5.0 {
+				t.Errorf("got: alpha %v, red %v, depth %v, want: 0.25, 4, 5", alpha, red, depthRed)
+			}
+		})
+	})
+
+	compressions := []struct {
+		compression Compression
+		decompress  func([]byte) []byte
+	}{
+		{
+			compression: RleCompression,
+			decompress:  func(data []byte) []byte { return unpredict(decompressRle(data)) },
+		},
+		{
+			compression: ZipCompression,
+			decompress: func(data []byte) []byte {
+				reader, err := zlib.NewReader(bytes.NewReader(data))
+				if err != nil {
+					return nil
+				}
+				raw, _ := ioutil.ReadAll(reader)
+				return unpredict(raw)
+			},
+		},
+	}
+
+	for _, c := range compressions {
+		img := image.CreateImage(64, 20)
+		for i := range img.Pixels {
+			img.Pixels[i].Color = image.Color{R: 0.5, G: float32(i % 64), B: 0.0}
+		}
+		layers := []Layer{{Image: img, PixelType: Float}}
+
+		var uncompressed bytes.Buffer
+		var compressed bytes.Buffer
+		if err := Encode(&uncompressed, layers, Options{Compression: NoCompression}); err != nil {
+			t.Fatal(err)
+		}
+		if err := Encode(&compressed, layers, Options{Compression: c.compression}); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := parseFile(t, uncompressed.Bytes())
+		actual := parseFile(t, compressed.Bytes())
+
+		for y, chunk := range actual.chunks {
+			linesPerBlock := c.compression.linesPerBlock()
+
+			var raw bytes.Buffer
+			for line := y; line < y+linesPerBlock && line < img.Height; line++ {
+				raw.Write(expected.chunks[line])
+			}
+
+			if len(raw.Bytes()) <= len(chunk) {
+				t.Errorf("compression %d must shrink the block at %d", c.compression, y)
+			}
+
+			if !bytes.Equal(c.decompress(chunk), raw.Bytes()) {
+				t.Errorf("compression %d must restore the block at %d", c.compression, y)
+			}
+		}
+	}
+
+	t.Run("When the color space is ACEScg", func(t *testing.T) {
+		beauty := image.CreateImage(1, 1)
+		beauty.Pixels[0].Color = image.Color{R: 1.0, G: 0.0, B: 0.0}
+		normal := image.CreateImage(1, 1)
+		normal.ColorSpace = image.NonColor
+		normal.Pixels[0].Color = image.Color{R: 1.0, G: 0.0, B: 0.0}
+
+		layers := []Layer{
+			{Image: beauty, Channels: "R", PixelType: Float},
+			{Name: "normal", Image: normal, Channels: "R", PixelType: Float},
+		}
+
+		var buffer bytes.Buffer
+		err := Encode(&buffer, layers, Options{Compression: NoCompression, ColorSpace: image.ACEScg})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file := parseFile(t, buffer.Bytes())
+
+		t.Run("it declares chromaticities", func(t *testing.T) {
+			white := math.Float32frombits(binary.LittleEndian.Uint32(file.attributes["chromaticities"][24:]))
+			if white != 0.32168 {
+				t.Errorf("got: white x %v, want: 0.32168", white)
+			}
+		})
+
+		t.Run("it converts color layers only", func(t *testing.T) {
+			line := file.chunks[0]
+			red := math.Float32frombits(binary.LittleEndian.Uint32(line[0:]))
+			normalX := math.Float32frombits(binary.LittleEndian.Uint32(line[4:]))
+
+			expected := image.ConvertColor(beauty.Pixels[0].Color, image.LinearSRGB, image.ACEScg).R
+			if red != expected || normalX != 1.0 {
+				t.Errorf("got: red %v, normal %v, want: %v, 1", red, normalX, expected)
+			}
+		})
+	})
+
+	t.Run("When layers have different sizes", func(t *testing.T) {
+		layers := []Layer{
+			{Image: image.CreateImage(2, 2), PixelType: Half},
+			{Name: "depth", Image: image.CreateImage(1, 2), PixelType: Half},
+		}
+
+		err := Encode(ioutil.Discard, layers, CreateDefaultOptions())
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+}
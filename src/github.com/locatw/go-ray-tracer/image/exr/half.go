@@ -0,0 +1,84 @@
+package exr
+
+import "math"
+
+// Convert a float32 value to IEEE 754 half precision bits, rounding to nearest even.
+// Values too large for half precision become infinity.
+func FloatToHalf(value float32) uint16 {
+	bits := math.Float32bits(value)
+
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int((bits >> 23) & 0xff)
+	mantissa := bits & 0x7fffff
+
+	// infinity and NaN
+	if exponent == 0xff {
+		if mantissa == 0 {
+			return sign | 0x7c00
+		}
+		return sign | 0x7e00
+	}
+
+	// re-bias the exponent from 127 to 15
+	exponent = exponent - 127 + 15
+
+	if 0x1f <= exponent {
+		return sign | 0x7c00
+	}
+
+	if exponent <= 0 {
+		// subnormal half, or zero if it is too small
+		if exponent < -10 {
+			return sign
+		}
+
+		mantissa |= 0x800000
+		shift := uint(14 - exponent)
+		half := mantissa >> shift
+
+		// round to nearest even
+		remainder := mantissa & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if halfway < remainder || (remainder == halfway && half&1 == 1) {
+			half++
+		}
+
+		return sign | uint16(half)
+	}
+
+	half := uint32(exponent)<<10 | mantissa>>13
+
+	remainder := mantissa & 0x1fff
+	if 0x1000 < remainder || (remainder == 0x1000 && half&1 == 1) {
+		// a carry into the exponent is correct, and may produce infinity
+		half++
+	}
+
+	return sign | uint16(half)
+}
+
+// Convert IEEE 754 half precision bits to a float32 value.
+func HalfToFloat(half uint16) float32 {
+	sign := uint32(half&0x8000) << 16
+	exponent := uint32(half>>10) & 0x1f
+	mantissa := uint32(half & 0x3ff)
+
+	switch {
+	case exponent == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mantissa<<13)
+	case exponent != 0:
+		return math.Float32frombits(sign | (exponent+127-15)<<23 | mantissa<<13)
+	case mantissa == 0:
+		return math.Float32frombits(sign)
+	default:
+		// normalize a subnormal value
+		e := uint32(127 - 15 + 1)
+		for mantissa&0x400 == 0 {
+			mantissa <<= 1
+			e--
+		}
+		mantissa &= 0x3ff
+
+		return math.Float32frombits(sign | e<<23 | mantissa<<13)
+	}
+}
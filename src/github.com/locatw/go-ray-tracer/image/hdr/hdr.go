@@ -0,0 +1,329 @@
+package hdr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+// Scanlines of width in this range are run length encoded.
+const (
+	minEncodedWidth = 8
+	maxEncodedWidth = 0x7fff
+	maxRunLength    = 127
+)
+
+// Write an image in Radiance RGBE format with run length encoded scanlines.
+// Values are written as they are, so the image should hold linear radiance.
+func WriteHdr(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	err = Encode(writer, img)
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Encode an image in Radiance RGBE format. Linear color spaces other than linear sRGB are
+// declared by PRIMARIES, and sRGB encoded values are converted to linear sRGB.
+func Encode(writer io.Writer, img image.Image) error {
+	if img.ColorSpace == image.SRGB {
+		img = image.ConvertImage(img, image.LinearSRGB)
+	}
+
+	_, err := fmt.Fprintf(writer, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n")
+	if err != nil {
+		return err
+	}
+
+	if img.ColorSpace != image.LinearSRGB && img.ColorSpace != image.NonColor {
+		c, _ := img.ColorSpace.Chromaticities()
+		_, err = fmt.Fprintf(writer, "PRIMARIES=%g %g %g %g %g %g %g %g\n",
+			c.Red[0], c.Red[1], c.Green[0], c.Green[1], c.Blue[0], c.Blue[1], c.White[0], c.White[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(writer, "\n-Y %d +X %d\n", img.Height, img.Width)
+	if err != nil {
+		return err
+	}
+
+	scanline := make([][4]byte, img.Width)
+
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			scanline[x] = ToRgbe(img.Pixels[y*img.Width+x].Color)
+		}
+
+		if err := writeScanline(writer, scanline); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Convert a color to shared exponent representation.
+func ToRgbe(color image.Color) [4]byte {
+	r := math.Max(0.0, float64(color.R))
+	g := math.Max(0.0, float64(color.G))
+	b := math.Max(0.0, float64(color.B))
+
+	v := math.Max(r, math.Max(g, b))
+	if v < 1.0e-32 {
+		return [4]byte{0, 0, 0, 0}
+	}
+
+	mantissa, exponent := math.Frexp(v)
+	scale := mantissa * 256.0 / v
+
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exponent + 128)}
+}
+
+func FromRgbe(rgbe [4]byte) image.Color {
+	if rgbe[3] == 0 {
+		return image.CreateDefaultColor(image.Black)
+	}
+
+	// add 0.5 to restore the center of the quantization interval
+	scale := math.Ldexp(1.0, int(rgbe[3])-(128+8))
+
+	return image.Color{
+		R: float32((float64(rgbe[0]) + 0.5) * scale),
+		G: float32((float64(rgbe[1]) + 0.5) * scale),
+		B: float32((float64(rgbe[2]) + 0.5) * scale),
+	}
+}
+
+func writeScanline(writer io.Writer, scanline [][4]byte) error {
+	width := len(scanline)
+
+	if width < minEncodedWidth || maxEncodedWidth < width {
+		for _, rgbe := range scanline {
+			if _, err := writer.Write(rgbe[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	header := []byte{2, 2, byte(width >> 8), byte(width & 0xff)}
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+
+	// each component is encoded separately
+	component := make([]byte, width)
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; x++ {
+			component[x] = scanline[x][c]
+		}
+
+		if _, err := writer.Write(encodeRuns(component)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Encode bytes into runs of a repeated byte (count + 128, value)
+// and literal sequences (count, values...).
+func encodeRuns(data []byte) []byte {
+	result := make([]byte, 0, len(data)+len(data)/maxRunLength+1)
+
+	i := 0
+	for i < len(data) {
+		runLength := 1
+		for i+runLength < len(data) && runLength < maxRunLength && data[i+runLength] == data[i] {
+			runLength++
+		}
+
+		if 2 < runLength {
+			result = append(result, byte(128+runLength), data[i])
+			i += runLength
+			continue
+		}
+
+		// literal sequence until the next run of three or more
+		start := i
+		for i < len(data) && i-start < maxRunLength {
+			if i+2 < len(data) && data[i] == data[i+1] && data[i] == data[i+2] {
+				break
+			}
+			i++
+		}
+
+		result = append(result, byte(i-start))
+		result = append(result, data[start:i]...)
+	}
+
+	return result
+}
+
+func ReadHdr(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	defer file.Close()
+
+	return Decode(bufio.NewReader(file))
+}
+
+// Decode a Radiance RGBE image in the standard orientation (-Y height +X width).
+// The color space is linear sRGB unless PRIMARIES declares another known color space.
+func Decode(reader *bufio.Reader) (image.Image, error) {
+	colorSpace := image.LinearSRGB
+
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "#?") {
+		return image.Image{}, fmt.Errorf("invalid Radiance HDR signature")
+	}
+
+	for {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return image.Image{}, fmt.Errorf("invalid Radiance HDR header: %s", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return image.Image{}, fmt.Errorf("unsupported Radiance HDR format: %s", line)
+		}
+
+		if strings.HasPrefix(line, "PRIMARIES=") {
+			colorSpace, err = parsePrimaries(strings.TrimPrefix(line, "PRIMARIES="))
+			if err != nil {
+				return image.Image{}, err
+			}
+		}
+	}
+
+	var width, height int
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		return image.Image{}, fmt.Errorf("invalid Radiance HDR resolution: %s", err)
+	}
+	if _, err := fmt.Sscanf(line, "-Y %d +X %d", &height, &width); err != nil || width <= 0 || height <= 0 {
+		return image.Image{}, fmt.Errorf("unsupported Radiance HDR resolution: %q", strings.TrimSpace(line))
+	}
+
+	img := image.CreateImage(width, height)
+	img.ColorSpace = colorSpace
+	scanline := make([][4]byte, width)
+
+	for y := 0; y < height; y++ {
+		if err := readScanline(reader, scanline); err != nil {
+			return image.Image{}, fmt.Errorf("cannot read Radiance HDR scanline %d: %s", y, err)
+		}
+
+		for x := 0; x < width; x++ {
+			img.Pixels[y*width+x].Color = FromRgbe(scanline[x])
+		}
+	}
+
+	return img, nil
+}
+
+func parsePrimaries(value string) (image.ColorSpace, error) {
+	var c image.Chromaticities
+	_, err := fmt.Sscanf(value, "%g %g %g %g %g %g %g %g",
+		&c.Red[0], &c.Red[1], &c.Green[0], &c.Green[1], &c.Blue[0], &c.Blue[1], &c.White[0], &c.White[1])
+	if err != nil {
+		return image.LinearSRGB, fmt.Errorf("invalid Radiance HDR primaries: %s", value)
+	}
+
+	colorSpace, ok := image.LinearColorSpaceFromChromaticities(c)
+	if !ok {
+		return image.LinearSRGB, fmt.Errorf("unsupported Radiance HDR primaries: %s", value)
+	}
+
+	return colorSpace, nil
+}
+
+func readScanline(reader *bufio.Reader, scanline [][4]byte) error {
+	width := len(scanline)
+
+	var first [4]byte
+	if _, err := io.ReadFull(reader, first[:]); err != nil {
+		return err
+	}
+
+	isEncoded := minEncodedWidth <= width && width <= maxEncodedWidth &&
+		first[0] == 2 && first[1] == 2 && first[2]&0x80 == 0
+	if !isEncoded {
+		scanline[0] = first
+		for x := 1; x < width; x++ {
+			if _, err := io.ReadFull(reader, scanline[x][:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if int(first[2])<<8|int(first[3]) != width {
+		return fmt.Errorf("scanline width mismatch")
+	}
+
+	for c := 0; c < 4; c++ {
+		x := 0
+		for x < width {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			if 128 < count {
+				n := int(count) - 128
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				if width < x+n {
+					return fmt.Errorf("run overflows scanline")
+				}
+				for i := 0; i < n; i++ {
+					scanline[x+i][c] = value
+				}
+				x += n
+			} else {
+				n := int(count)
+				if n == 0 || width < x+n {
+					return fmt.Errorf("invalid literal length %d", n)
+				}
+				for i := 0; i < n; i++ {
+					value, err := reader.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[x+i][c] = value
+				}
+				x += n
+			}
+		}
+	}
+
+	return nil
+}
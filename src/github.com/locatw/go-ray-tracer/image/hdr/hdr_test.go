@@ -0,0 +1,126 @@
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+func TestToRgbe(t *testing.T) {
+	patterns := []struct {
+		color    image.Color
+		expected [4]byte
+	}{
+		{color: image.Color{R: 0.0, G: 0.0, B: 0.0}, expected: [4]byte{0, 0, 0, 0}},
+		{color: image.Color{R: 1.0, G: 0.5, B: 0.25}, expected: [4]byte{128, 64, 32, 129}},
+		{color: image.Color{R: 0.5, G: -1.0, B: 0.0}, expected: [4]byte{128, 0, 0, 128}},
+	}
+
+	for _, pattern := range patterns {
+		result := ToRgbe(pattern.color)
+		if result != pattern.expected {
+			t.Errorf("ToRgbe(%v) must return %v, actual %v", pattern.color, pattern.expected, result)
+		}
+	}
+}
+
+func TestEncodeRuns(t *testing.T) {
+	data := []byte{1, 2, 3, 3, 3, 3, 4, 4, 5}
+	expected := []byte{2, 1, 2, 128 + 4, 3, 3, 4, 4, 5}
+
+	result := encodeRuns(data)
+	if !bytes.Equal(result, expected) {
+		t.Errorf("encodeRuns(%v) must return %v, actual %v", data, expected, result)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	patterns := []struct {
+		name  string
+		width int
+	}{
+		{name: "When scanlines are run length encoded", width: 40},
+		{name: "When scanlines are too short to be encoded", width: 3},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			img := image.CreateImage(pattern.width, 2)
+			for i := range img.Pixels {
+				// constant areas and gradients to exercise both runs and literals
+				v := float32(i / 7)
+				img.Pixels[i].Color = image.Color{R: v, G: 0.5, B: 100.0 * float32(i)}
+			}
+
+			var buffer bytes.Buffer
+			if err := Encode(&buffer, img); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := Decode(bufio.NewReader(&buffer))
+
+			t.Run("it restores values within the precision of RGBE", func(t *testing.T) {
+				if err != nil {
+					t.Fatalf("got: %v, want: nil", err)
+				}
+
+				for i := range img.Pixels {
+					expected := img.Pixels[i].Color
+					actual := decoded.Pixels[i].Color
+					tolerance := 0.01 * math.Max(float64(expected.R), math.Max(float64(expected.G), float64(expected.B)))
+
+					if tolerance < math.Abs(float64(expected.R-actual.R)) ||
+						tolerance < math.Abs(float64(expected.G-actual.G)) ||
+						tolerance < math.Abs(float64(expected.B-actual.B)) {
+						t.Errorf("pixel %d must be %v, actual is %v", i, expected, actual)
+					}
+				}
+			})
+		})
+	}
+
+	t.Run("When an image is in ACEScg", func(t *testing.T) {
+		img := image.CreateImage(1, 1)
+		img.ColorSpace = image.ACEScg
+		img.Pixels[0].Color = image.Color{R: 1.0, G: 0.5, B: 0.25}
+
+		var buffer bytes.Buffer
+		if err := Encode(&buffer, img); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := Decode(bufio.NewReader(&buffer))
+
+		t.Run("it declares the color space by primaries", func(t *testing.T) {
+			if err != nil || decoded.ColorSpace != image.ACEScg || 0.01 < math.Abs(float64(decoded.Pixels[0].Color.G-0.5)) {
+				t.Errorf("got: %v in %s (error: %v), want: %v in %s",
+					decoded.Pixels[0].Color, decoded.ColorSpace, err, img.Pixels[0].Color, image.ACEScg)
+			}
+		})
+	})
+
+	t.Run("When primaries are unknown", func(t *testing.T) {
+		data := "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\nPRIMARIES=0.7 0.3 0.2 0.7 0.1 0.1 0.33 0.33\n\n-Y 1 +X 1\n\x80\x80\x80\x81"
+
+		_, err := Decode(bufio.NewReader(bytes.NewReader([]byte(data))))
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+
+	t.Run("When the signature is invalid", func(t *testing.T) {
+		_, err := Decode(bufio.NewReader(bytes.NewReader([]byte("P3\n1 1\n255\n"))))
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+}
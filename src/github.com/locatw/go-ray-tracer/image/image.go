@@ -7,12 +7,16 @@ type Coordinate struct {
 type Pixel struct {
 	Coordinate Coordinate
 	Color      Color
+	// Opacity in range [0.0, 1.0], which is used only by formats with an alpha channel.
+	Alpha float32
 }
 
 type Image struct {
 	Width  int
 	Height int
 	Pixels []Pixel
+	// Color space of Pixel.Color. The zero value is LinearSRGB.
+	ColorSpace ColorSpace
 }
 
 func CreateImage(width int, height int) Image {
@@ -22,9 +26,26 @@ func CreateImage(width int, height int) Image {
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
-			pixels[y*width+x] = Pixel{Coordinate: Coordinate{X: x, Y: y}, Color: black}
+			pixels[y*width+x] = Pixel{Coordinate: Coordinate{X: x, Y: y}, Color: black, Alpha: 1.0}
 		}
 	}
 
 	return Image{Width: width, Height: height, Pixels: pixels}
 }
+
+// Convert colors premultiplied by alpha, such as averages of samples including missed ones,
+// to straight colors by dividing them by alpha. Pixels whose alpha is zero are kept.
+func Unpremultiply(img Image) Image {
+	result := Image{Width: img.Width, Height: img.Height, Pixels: make([]Pixel, len(img.Pixels)), ColorSpace: img.ColorSpace}
+
+	copy(result.Pixels, img.Pixels)
+
+	for i := range result.Pixels {
+		pixel := &result.Pixels[i]
+		if 0.0 < pixel.Alpha {
+			pixel.Color = DivideScalar(pixel.Color, float64(pixel.Alpha))
+		}
+	}
+
+	return result
+}
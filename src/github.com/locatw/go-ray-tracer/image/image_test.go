@@ -35,4 +35,34 @@ func TestCreateImage(t *testing.T) {
 	if !isAllPixelBlack {
 		t.Errorf("CreateImage(%d, %d) must return an image with all pixels are black, but non-black pixel exists", width, height)
 	}
+
+	isAllPixelOpaque := true
+	for _, pixel := range image.Pixels {
+		if pixel.Alpha != 1.0 {
+			isAllPixelOpaque = false
+			break
+		}
+	}
+	if !isAllPixelOpaque {
+		t.Errorf("CreateImage(%d, %d) must return an image with all pixels are opaque, but non-opaque pixel exists", width, height)
+	}
+}
+
+func TestUnpremultiply(t *testing.T) {
+	img := CreateImage(3, 1)
+	// a pixel half covered by white
+	img.Pixels[0].Color = Color{R: 0.5, G: 0.5, B: 0.5}
+	img.Pixels[0].Alpha = 0.5
+	img.Pixels[1].Color = Color{R: 0.25, G: 0.5, B: 1.0}
+	img.Pixels[2].Alpha = 0.0
+
+	expected := []Color{{R: 1.0, G: 1.0, B: 1.0}, {R: 0.25, G: 0.5, B: 1.0}, {R: 0.0, G: 0.0, B: 0.0}}
+
+	result := Unpremultiply(img)
+
+	for i, e := range expected {
+		if !result.Pixels[i].Color.NearlyEqual(e) || result.Pixels[i].Alpha != img.Pixels[i].Alpha {
+			t.Errorf("Unpremultiply must convert pixel %d to %v, actual %v", i, e, result.Pixels[i].Color)
+		}
+	}
 }
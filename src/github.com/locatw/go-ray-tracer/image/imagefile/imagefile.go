@@ -0,0 +1,116 @@
+package imagefile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/exr"
+	"github.com/locatw/go-ray-tracer/image/hdr"
+	"github.com/locatw/go-ray-tracer/image/pfm"
+	"github.com/locatw/go-ray-tracer/image/png"
+	"github.com/locatw/go-ray-tracer/image/pnm"
+)
+
+type Format string
+
+const (
+	Ppm Format = "ppm"
+	Png Format = "png"
+	Pfm Format = "pfm"
+	Hdr Format = "hdr"
+	Exr Format = "exr"
+)
+
+var formats = []Format{Ppm, Png, Pfm, Hdr, Exr}
+
+type Options struct {
+	// Bits per channel for formats which support multiple bit depths.
+	BitDepth int
+	// Write Pixel.Alpha for formats which support an alpha channel.
+	Alpha bool
+}
+
+func CreateDefaultOptions() Options {
+	return Options{BitDepth: 8, Alpha: false}
+}
+
+func ParseFormat(name string) (Format, error) {
+	lower := strings.ToLower(name)
+
+	for _, format := range formats {
+		if string(format) == lower {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported image format: %s", name)
+}
+
+// High dynamic range formats store linear radiance without tone mapping.
+func (format Format) IsHighDynamicRange() bool {
+	return format == Pfm || format == Hdr || format == Exr
+}
+
+// Determine an image format by the extension of path.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot determine image format of %q because it has no extension", path)
+	}
+
+	return ParseFormat(ext)
+}
+
+// Write an image in the format determined by the extension of path.
+func Write(path string, img image.Image, options Options) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	return WriteFormat(path, format, img, options)
+}
+
+func WriteFormat(path string, format Format, img image.Image, options Options) error {
+	switch format {
+	case Ppm:
+		return pnm.WritePpm(path, img)
+	case Png:
+		return png.WritePng(path, img, png.Options{BitDepth: options.BitDepth, Alpha: options.Alpha})
+	case Pfm:
+		return pfm.WritePfm(path, img)
+	case Hdr:
+		return hdr.WriteHdr(path, img)
+	case Exr:
+		channels := "RGB"
+		if options.Alpha {
+			channels = "RGBA"
+		}
+		layers := []exr.Layer{{Image: img, Channels: channels, PixelType: exr.Half}}
+		return exr.WriteExr(path, layers, exr.CreateDefaultOptions())
+	default:
+		return fmt.Errorf("unsupported image format: %s", format)
+	}
+}
+
+// Read an image in the format determined by the extension of path.
+// The color space of the image is declared by the reader of each format.
+func Read(path string) (image.Image, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	switch format {
+	case Png:
+		return png.ReadPng(path)
+	case Pfm:
+		return pfm.ReadPfm(path)
+	case Hdr:
+		return hdr.ReadHdr(path)
+	default:
+		return image.Image{}, fmt.Errorf("reading %s images is not supported", format)
+	}
+}
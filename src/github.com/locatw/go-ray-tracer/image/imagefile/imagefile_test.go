@@ -0,0 +1,56 @@
+package imagefile
+
+import (
+	"testing"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	patterns := []struct {
+		path     string
+		expected Format
+		isError  bool
+	}{
+		{path: "image.ppm", expected: Ppm},
+		{path: "out/image.PNG", expected: Png},
+		{path: "image.pfm", expected: Pfm},
+		{path: "image.hdr", expected: Hdr},
+		{path: "image.exr", expected: Exr},
+		{path: "image.bmp", isError: true},
+		{path: "image", isError: true},
+	}
+
+	for _, pattern := range patterns {
+		format, err := FormatFromPath(pattern.path)
+
+		if pattern.isError {
+			if err == nil {
+				t.Errorf("FormatFromPath(%s) must return an error, actual format is %s", pattern.path, format)
+			}
+			continue
+		}
+
+		if err != nil || format != pattern.expected {
+			t.Errorf("FormatFromPath(%s) must return %s, actual is %s (error: %v)", pattern.path, pattern.expected, format, err)
+		}
+	}
+}
+
+func TestFormatIsHighDynamicRange(t *testing.T) {
+	patterns := []struct {
+		format   Format
+		expected bool
+	}{
+		{format: Ppm, expected: false},
+		{format: Png, expected: false},
+		{format: Pfm, expected: true},
+		{format: Hdr, expected: true},
+		{format: Exr, expected: true},
+	}
+
+	for _, pattern := range patterns {
+		result := pattern.format.IsHighDynamicRange()
+		if result != pattern.expected {
+			t.Errorf("%s.IsHighDynamicRange() must return %t, actual is %t", pattern.format, pattern.expected, result)
+		}
+	}
+}
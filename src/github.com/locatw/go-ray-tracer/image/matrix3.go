@@ -0,0 +1,65 @@
+package image
+
+// Row major 3x3 matrix which transforms colors.
+type Matrix3 [3][3]float64
+
+func IdentityMatrix3() Matrix3 {
+	return Matrix3{{1.0, 0.0, 0.0}, {0.0, 1.0, 0.0}, {0.0, 0.0, 1.0}}
+}
+
+func MultiplyMatrix3(m Matrix3, color Color) Color {
+	r := float64(color.R)
+	g := float64(color.G)
+	b := float64(color.B)
+
+	return Color{
+		R: float32(m[0][0]*r + m[0][1]*g + m[0][2]*b),
+		G: float32(m[1][0]*r + m[1][1]*g + m[1][2]*b),
+		B: float32(m[2][0]*r + m[2][1]*g + m[2][2]*b),
+	}
+}
+
+func MultiplyMatrices3(m1 Matrix3, m2 Matrix3) Matrix3 {
+	result := Matrix3{}
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result[i][j] += m1[i][k] * m2[k][j]
+			}
+		}
+	}
+
+	return result
+}
+
+// Return the inverse matrix, or false if m is singular.
+func InvertMatrix3(m Matrix3) (Matrix3, bool) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	if det == 0.0 {
+		return Matrix3{}, false
+	}
+
+	inv := 1.0 / det
+
+	return Matrix3{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) * inv,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) * inv,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) * inv,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) * inv,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) * inv,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) * inv,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) * inv,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) * inv,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) * inv,
+		},
+	}, true
+}
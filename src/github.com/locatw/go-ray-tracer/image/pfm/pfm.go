@@ -0,0 +1,139 @@
+package pfm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+// Write an image as color Portable FloatMap in little endian.
+// Values are written as they are, so the image should hold linear radiance.
+func WritePfm(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	err = Encode(writer, img)
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Encode an image in PFM format. PFM has no color space information,
+// so color values are converted to linear sRGB.
+func Encode(writer io.Writer, img image.Image) error {
+	if img.ColorSpace != image.LinearSRGB && img.ColorSpace != image.NonColor {
+		img = image.ConvertImage(img, image.LinearSRGB)
+	}
+
+	// negative scale means little endian
+	_, err := fmt.Fprintf(writer, "PF\n%d %d\n-1.0\n", img.Width, img.Height)
+	if err != nil {
+		return err
+	}
+
+	row := make([]byte, img.Width*3*4)
+
+	// scanlines are stored from bottom to top
+	for y := img.Height - 1; 0 <= y; y-- {
+		for x := 0; x < img.Width; x++ {
+			color := img.Pixels[y*img.Width+x].Color
+			offset := x * 3 * 4
+
+			binary.LittleEndian.PutUint32(row[offset+0:], math.Float32bits(color.R))
+			binary.LittleEndian.PutUint32(row[offset+4:], math.Float32bits(color.G))
+			binary.LittleEndian.PutUint32(row[offset+8:], math.Float32bits(color.B))
+		}
+
+		if _, err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ReadPfm(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	defer file.Close()
+
+	return Decode(bufio.NewReader(file))
+}
+
+// Decode a color (PF) or grayscale (Pf) Portable FloatMap.
+// A grayscale value is copied to all channels.
+func Decode(reader *bufio.Reader) (image.Image, error) {
+	var magic string
+	var width, height int
+	var scale float64
+
+	_, err := fmt.Fscan(reader, &magic, &width, &height, &scale)
+	if err != nil {
+		return image.Image{}, fmt.Errorf("invalid PFM header: %s", err)
+	}
+
+	// exactly one whitespace character follows the header
+	if _, err := reader.ReadByte(); err != nil {
+		return image.Image{}, fmt.Errorf("invalid PFM header: %s", err)
+	}
+
+	channelCount := 0
+	switch magic {
+	case "PF":
+		channelCount = 3
+	case "Pf":
+		channelCount = 1
+	default:
+		return image.Image{}, fmt.Errorf("invalid PFM magic: %q", magic)
+	}
+
+	if width <= 0 || height <= 0 || scale == 0.0 {
+		return image.Image{}, fmt.Errorf("invalid PFM header: %dx%d, scale %f", width, height, scale)
+	}
+
+	var byteOrder binary.ByteOrder = binary.BigEndian
+	if scale < 0.0 {
+		byteOrder = binary.LittleEndian
+	}
+
+	img := image.CreateImage(width, height)
+	row := make([]byte, width*channelCount*4)
+
+	for y := height - 1; 0 <= y; y-- {
+		if _, err := io.ReadFull(reader, row); err != nil {
+			return image.Image{}, fmt.Errorf("cannot read PFM pixels: %s", err)
+		}
+
+		for x := 0; x < width; x++ {
+			var values [3]float32
+			for c := 0; c < channelCount; c++ {
+				offset := (x*channelCount + c) * 4
+				values[c] = math.Float32frombits(byteOrder.Uint32(row[offset:]))
+			}
+			if channelCount == 1 {
+				values[1] = values[0]
+				values[2] = values[0]
+			}
+
+			img.Pixels[y*width+x].Color = image.Color{R: values[0], G: values[1], B: values[2]}
+		}
+	}
+
+	return img, nil
+}
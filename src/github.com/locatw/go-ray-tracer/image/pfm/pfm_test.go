@@ -0,0 +1,86 @@
+package pfm
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+func TestEncode(t *testing.T) {
+	img := image.CreateImage(2, 1)
+	img.Pixels[0].Color = image.Color{R: 1.0, G: 0.0, B: 0.0}
+	img.Pixels[1].Color = image.Color{R: 0.0, G: 0.0, B: -2.0}
+
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte("PF\n2 1\n-1.0\n")
+	expected = append(expected, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+	expected = append(expected, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0)
+
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Encode must write %v, actual %v", expected, buffer.Bytes())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("When data is encoded by Encode", func(t *testing.T) {
+		img := image.CreateImage(3, 2)
+		for i := range img.Pixels {
+			v := float32(i)
+			img.Pixels[i].Color = image.Color{R: v, G: 1000.0 * v, B: -v / 3.0}
+		}
+
+		var buffer bytes.Buffer
+		if err := Encode(&buffer, img); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := Decode(bufio.NewReader(&buffer))
+
+		t.Run("it restores the original values", func(t *testing.T) {
+			if err != nil {
+				t.Fatalf("got: %v, want: nil", err)
+			}
+
+			if decoded.Width != img.Width || decoded.Height != img.Height {
+				t.Fatalf("got: %dx%d, want: %dx%d", decoded.Width, decoded.Height, img.Width, img.Height)
+			}
+
+			for i := range img.Pixels {
+				if decoded.Pixels[i].Color != img.Pixels[i].Color {
+					t.Errorf("pixel %d must be %v, actual is %v", i, img.Pixels[i].Color, decoded.Pixels[i].Color)
+				}
+			}
+		})
+	})
+
+	t.Run("When data is big endian grayscale", func(t *testing.T) {
+		data := append([]byte("Pf\n1 1\n1.0\n"), 0x40, 0x00, 0x00, 0x00)
+
+		decoded, err := Decode(bufio.NewReader(bytes.NewReader(data)))
+
+		t.Run("it copies the value to all channels", func(t *testing.T) {
+			expected := image.Color{R: 2.0, G: 2.0, B: 2.0}
+			if err != nil || decoded.Pixels[0].Color != expected {
+				t.Errorf("got: %v (error: %v), want: %v", decoded.Pixels, err, expected)
+			}
+		})
+	})
+
+	t.Run("When data is truncated", func(t *testing.T) {
+		data := append([]byte("PF\n1 1\n-1.0\n"), 0x00, 0x00)
+
+		_, err := Decode(bufio.NewReader(bytes.NewReader(data)))
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+}
@@ -0,0 +1,132 @@
+package png
+
+import (
+	"bufio"
+	"fmt"
+	stdimage "image"
+	"image/color"
+	"image/draw"
+	stdpng "image/png"
+	"io"
+	"os"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+type Options struct {
+	// 8 or 16.
+	BitDepth int
+	// Write Pixel.Alpha as an alpha channel.
+	Alpha bool
+}
+
+func CreateDefaultOptions() Options {
+	return Options{BitDepth: 8, Alpha: false}
+}
+
+func WritePng(path string, img image.Image, options Options) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	err = Encode(writer, img, options)
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Encode an image in PNG format. Pixels are encoded in sRGB unless the image holds non-color values,
+// and readers take PNG files without color space chunks as sRGB.
+// The alpha channel is omitted if every pixel is opaque.
+func Encode(writer io.Writer, img image.Image, options Options) error {
+	if options.BitDepth != 8 && options.BitDepth != 16 {
+		return fmt.Errorf("unsupported bit depth: %d", options.BitDepth)
+	}
+
+	if img.Width <= 0 || img.Height <= 0 {
+		return fmt.Errorf("cannot encode an empty image: %dx%d", img.Width, img.Height)
+	}
+
+	if img.ColorSpace != image.NonColor && img.ColorSpace != image.SRGB {
+		img = image.ConvertImage(img, image.SRGB)
+	}
+
+	encoder := stdpng.Encoder{CompressionLevel: stdpng.BestCompression}
+
+	return encoder.Encode(writer, createNRGBAImage(img, options))
+}
+
+// Quantize pixels to NRGBA or NRGBA64 by the bit depth, whose alpha is opaque unless options.Alpha is set.
+func createNRGBAImage(img image.Image, options Options) draw.Image {
+	bounds := stdimage.Rect(0, 0, img.Width, img.Height)
+	maxValue := 1<<uint(options.BitDepth) - 1
+
+	var nrgba draw.Image = stdimage.NewNRGBA(bounds)
+	if options.BitDepth == 16 {
+		nrgba = stdimage.NewNRGBA64(bounds)
+	}
+
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			pixel := &img.Pixels[y*img.Width+x]
+
+			r := image.QuantizeValue(pixel.Color.R, maxValue)
+			g := image.QuantizeValue(pixel.Color.G, maxValue)
+			b := image.QuantizeValue(pixel.Color.B, maxValue)
+			a := maxValue
+			if options.Alpha {
+				a = image.QuantizeValue(pixel.Alpha, maxValue)
+			}
+
+			if options.BitDepth == 16 {
+				nrgba.Set(x, y, color.NRGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)})
+			} else {
+				nrgba.Set(x, y, color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: uint8(a)})
+			}
+		}
+	}
+
+	return nrgba
+}
+
+// Read a PNG image as a texture. Values are declared as sRGB encoded.
+func ReadPng(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	defer file.Close()
+
+	return Decode(bufio.NewReader(file))
+}
+
+func Decode(reader io.Reader) (image.Image, error) {
+	decoded, err := stdpng.Decode(reader)
+	if err != nil {
+		return image.Image{}, err
+	}
+
+	bounds := decoded.Bounds()
+	img := image.CreateImage(bounds.Dx(), bounds.Dy())
+	img.ColorSpace = image.SRGB
+
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			c := color.NRGBA64Model.Convert(decoded.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA64)
+
+			pixel := &img.Pixels[y*img.Width+x]
+			pixel.Color = image.Color{R: float32(c.R) / 65535.0, G: float32(c.G) / 65535.0, B: float32(c.B) / 65535.0}
+			pixel.Alpha = float32(c.A) / 65535.0
+		}
+	}
+
+	return img, nil
+}
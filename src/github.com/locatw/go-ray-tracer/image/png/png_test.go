@@ -0,0 +1,136 @@
+package png
+
+import (
+	"bytes"
+	stdimage "image"
+	"image/color"
+	stdpng "image/png"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+// Create an image whose values are already encoded in sRGB.
+func createTestImage() image.Image {
+	img := image.CreateImage(3, 2)
+	img.ColorSpace = image.SRGB
+	img.Pixels[0].Color = image.Color{R: 1.0, G: 0.0, B: 0.0}
+	img.Pixels[1].Color = image.Color{R: 0.0, G: 1.0, B: 0.0}
+	img.Pixels[2].Color = image.Color{R: 0.0, G: 0.0, B: 1.0}
+	img.Pixels[3].Color = image.Color{R: 0.5, G: 1.5, B: -0.5}
+	img.Pixels[4].Color = image.Color{R: 0.25, G: 0.25, B: 0.25}
+	img.Pixels[5].Color = image.Color{R: 1.0, G: 1.0, B: 1.0}
+	img.Pixels[4].Alpha = 0.0
+	img.Pixels[5].Alpha = 0.5
+
+	return img
+}
+
+func decode(t *testing.T, img image.Image, options Options) stdimage.Image {
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, img, options); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := stdpng.Decode(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return decoded
+}
+
+func TestEncode(t *testing.T) {
+	img := createTestImage()
+
+	t.Run("When bit depth is 8 without alpha", func(t *testing.T) {
+		decoded := decode(t, img, Options{BitDepth: 8, Alpha: false})
+
+		t.Run("it writes clamped and rounded 8-bit RGB values", func(t *testing.T) {
+			if _, ok := decoded.(*stdimage.RGBA); !ok {
+				t.Fatalf("got: %T, want: *image.RGBA", decoded)
+			}
+
+			expected := []color.RGBA{
+				{R: 255, G: 0, B: 0, A: 255},
+				{R: 0, G: 255, B: 0, A: 255},
+				{R: 0, G: 0, B: 255, A: 255},
+				{R: 128, G: 255, B: 0, A: 255},
+				{R: 64, G: 64, B: 64, A: 255},
+				{R: 255, G: 255, B: 255, A: 255},
+			}
+			for i, e := range expected {
+				actual := decoded.At(i%3, i/3).(color.RGBA)
+				if actual != e {
+					t.Errorf("pixel %d must be %v, actual is %v", i, e, actual)
+				}
+			}
+		})
+	})
+
+	t.Run("When bit depth is 16 with alpha", func(t *testing.T) {
+		decoded := decode(t, img, Options{BitDepth: 16, Alpha: true})
+
+		t.Run("it writes 16-bit RGBA values", func(t *testing.T) {
+			nrgba, ok := decoded.(*stdimage.NRGBA64)
+			if !ok {
+				t.Fatalf("got: %T, want: *image.NRGBA64", decoded)
+			}
+
+			expected := map[int]color.NRGBA64{
+				3: {R: 32768, G: 65535, B: 0, A: 65535},
+				4: {R: 16384, G: 16384, B: 16384, A: 0},
+				5: {R: 65535, G: 65535, B: 65535, A: 32768},
+			}
+			for i, e := range expected {
+				actual := nrgba.NRGBA64At(i%3, i/3)
+				if actual != e {
+					t.Errorf("pixel %d must be %v, actual is %v", i, e, actual)
+				}
+			}
+		})
+	})
+
+	t.Run("When an image is linear", func(t *testing.T) {
+		linear := image.CreateImage(1, 1)
+		linear.Pixels[0].Color = image.Color{R: 0.5, G: 0.0, B: 1.0}
+
+		decoded := decode(t, linear, Options{BitDepth: 8, Alpha: false})
+
+		t.Run("it encodes values with the sRGB transfer function", func(t *testing.T) {
+			expected := color.RGBA{R: 188, G: 0, B: 255, A: 255}
+			if actual := decoded.At(0, 0).(color.RGBA); actual != expected {
+				t.Errorf("got: %v, want: %v", actual, expected)
+			}
+		})
+	})
+
+	t.Run("When bit depth is 16 with alpha", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := Encode(&buffer, img, Options{BitDepth: 16, Alpha: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := Decode(&buffer)
+
+		t.Run("it is restored by Decode in sRGB", func(t *testing.T) {
+			if err != nil || decoded.ColorSpace != image.SRGB {
+				t.Fatalf("got: %s (error: %v), want: %s", decoded.ColorSpace, err, image.SRGB)
+			}
+
+			if decoded.Pixels[5].Color != img.Pixels[5].Color || decoded.Pixels[5].Alpha != 32768.0/65535.0 {
+				t.Errorf("got: %v, want: %v", decoded.Pixels[5], img.Pixels[5])
+			}
+		})
+	})
+
+	t.Run("When bit depth is not supported", func(t *testing.T) {
+		var buffer bytes.Buffer
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err := Encode(&buffer, img, Options{BitDepth: 4}); err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+}
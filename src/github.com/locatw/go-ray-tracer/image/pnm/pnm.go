@@ -8,17 +8,27 @@ import (
 	"strings"
 
 	"github.com/locatw/go-ray-tracer/image"
-	"github.com/locatw/go-ray-tracer/math"
 )
 
+func toSRGB(img image.Image) image.Image {
+	if img.ColorSpace == image.SRGB {
+		return img
+	}
+
+	return image.ConvertImage(img, image.SRGB)
+}
+
 // Convert a float value in range [0.0, 1.0] to a integer value in range [0, 255],
 // and return as string value.
 // If an input value is out of range, then clamp it.
 func processPixelValue(value float32) string {
-	return strconv.Itoa(int(math.Clamp32(math.Round32(255.0*value), 0.0, 255.0)))
+	return strconv.Itoa(image.QuantizeValue(value, 255))
 }
 
+// Write an image in plain PPM format. Pixels are encoded in sRGB.
 func WritePpm(path string, image image.Image) error {
+	image = toSRGB(image)
+
 	file, err := os.Create(path)
 	if err != nil {
 		return err
@@ -0,0 +1,11 @@
+package image
+
+import "github.com/locatw/go-ray-tracer/math"
+
+// Convert a float value in range [0.0, 1.0] to an integer value in range [0, maxValue].
+// If an input value is out of range, then clamp it.
+func QuantizeValue(value float32, maxValue int) int {
+	max := float32(maxValue)
+
+	return int(math.Clamp32(math.Round32(max*value), 0.0, max))
+}
@@ -0,0 +1,26 @@
+package image
+
+import "testing"
+
+func TestQuantizeValue(t *testing.T) {
+	patterns := []struct {
+		value    float32
+		maxValue int
+		expected int
+	}{
+		{value: 0.0, maxValue: 255, expected: 0},
+		{value: 1.0, maxValue: 255, expected: 255},
+		{value: 0.5, maxValue: 255, expected: 128},
+		{value: -0.1, maxValue: 255, expected: 0},
+		{value: 1.1, maxValue: 255, expected: 255},
+		{value: 0.5, maxValue: 65535, expected: 32768},
+		{value: 1.0, maxValue: 65535, expected: 65535},
+	}
+
+	for _, pattern := range patterns {
+		x := QuantizeValue(pattern.value, pattern.maxValue)
+		if x != pattern.expected {
+			t.Errorf("QuantizeValue(%f, %d) must return %d, actual %d", pattern.value, pattern.maxValue, pattern.expected, x)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tonemap
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Operator maps a linear radiance value of a channel to display range [0, 1].
+type Operator interface {
+	Map(value float64) float64
+}
+
+// Names of operators accepted by CreateOperator.
+const (
+	LinearName           = "linear"
+	ReinhardName         = "reinhard"
+	ExtendedReinhardName = "extended-reinhard"
+	AcesName             = "aces"
+	HableName            = "hable"
+)
+
+var OperatorNames = []string{LinearName, ReinhardName, ExtendedReinhardName, AcesName, HableName}
+
+// White point of Hable operator proposed for Uncharted 2.
+const DefaultHableWhitePoint = 11.2
+
+// Create an operator by name. whitePoint is the smallest value mapped to 1,
+// which is used by extended Reinhard and Hable operators. If it is zero or less,
+// Hable operator uses its default and extended Reinhard operator uses the maximum
+// luminance of the image which Setting applies it to.
+func CreateOperator(name string, whitePoint float64) (Operator, error) {
+	switch strings.ToLower(name) {
+	case LinearName:
+		return LinearOperator{}, nil
+	case ReinhardName:
+		return ReinhardOperator{}, nil
+	case ExtendedReinhardName:
+		return ExtendedReinhardOperator{WhitePoint: math.Max(whitePoint, 0.0)}, nil
+	case AcesName:
+		return AcesOperator{}, nil
+	case HableName:
+		if whitePoint <= 0.0 {
+			whitePoint = DefaultHableWhitePoint
+		}
+		return HableOperator{WhitePoint: whitePoint}, nil
+	default:
+		return nil, fmt.Errorf("unknown tone mapping operator: %s", name)
+	}
+}
+
+// Clamp values to [0, 1].
+type LinearOperator struct{}
+
+func (operator LinearOperator) Map(value float64) float64 {
+	return clamp(value)
+}
+
+// x / (1 + x)
+type ReinhardOperator struct{}
+
+func (operator ReinhardOperator) Map(value float64) float64 {
+	value = math.Max(value, 0.0)
+
+	return value / (1.0 + value)
+}
+
+// Reinhard operator which maps WhitePoint to 1. If WhitePoint is zero, Setting replaces it
+// with the maximum luminance of an image, and Map alone works as ReinhardOperator.
+type ExtendedReinhardOperator struct {
+	WhitePoint float64
+}
+
+func (operator ExtendedReinhardOperator) Map(value float64) float64 {
+	value = math.Max(value, 0.0)
+	if operator.WhitePoint <= 0.0 {
+		return value / (1.0 + value)
+	}
+
+	w2 := operator.WhitePoint * operator.WhitePoint
+
+	return clamp(value * (1.0 + value/w2) / (1.0 + value))
+}
+
+// Krzysztof Narkowicz's fit of ACES filmic curve.
+type AcesOperator struct{}
+
+func (operator AcesOperator) Map(value float64) float64 {
+	a := 2.51
+	b := 0.03
+	c := 2.43
+	d := 0.59
+	e := 0.14
+
+	value = math.Max(value, 0.0)
+
+	return clamp((value * (a*value + b)) / (value*(c*value+d) + e))
+}
+
+// John Hable's filmic curve used in Uncharted 2, normalized so that WhitePoint is mapped to 1.
+type HableOperator struct {
+	WhitePoint float64
+}
+
+func (operator HableOperator) Map(value float64) float64 {
+	value = math.Max(value, 0.0)
+
+	return clamp(hable(value) / hable(operator.WhitePoint))
+}
+
+func hable(x float64) float64 {
+	a := 0.15 // shoulder strength
+	b := 0.50 // linear strength
+	c := 0.10 // linear angle
+	d := 0.20 // toe strength
+	e := 0.02 // toe numerator
+	f := 0.30 // toe denominator
+
+	return ((x*(a*x+c*b) + d*e) / (x*(a*x+b) + d*f)) - e/f
+}
+
+func clamp(value float64) float64 {
+	return math.Min(math.Max(value, 0.0), 1.0)
+}
@@ -0,0 +1,67 @@
+package tonemap
+
+import (
+	"math"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+type Setting struct {
+	// If nil, LinearOperator is used.
+	Operator Operator
+	// Exposure compensation in EV. Radiance is multiplied by 2^Exposure before Operator is applied.
+	Exposure float64
+}
+
+func CreateDefaultSetting() Setting {
+	return Setting{Operator: LinearOperator{}, Exposure: 0.0}
+}
+
+// Return a copy of a linear image which is tone mapped and encoded in sRGB for display.
+// ExtendedReinhardOperator without a white point maps the maximum luminance of the image to 1.
+func (setting Setting) Apply(img image.Image) image.Image {
+	linear := image.ConvertImage(img, image.LinearSRGB)
+
+	if operator, ok := setting.Operator.(ExtendedReinhardOperator); ok && operator.WhitePoint <= 0.0 {
+		setting.Operator = ExtendedReinhardOperator{WhitePoint: math.Pow(2.0, setting.Exposure) * maxLuminance(linear)}
+	}
+
+	result := image.Image{
+		Width:      img.Width,
+		Height:     img.Height,
+		Pixels:     make([]image.Pixel, len(img.Pixels)),
+		ColorSpace: image.SRGB,
+	}
+
+	for i, pixel := range linear.Pixels {
+		result.Pixels[i] = pixel
+		result.Pixels[i].Color = image.ConvertColor(setting.MapColor(pixel.Color), image.LinearSRGB, image.SRGB)
+	}
+
+	return result
+}
+
+// Map a linear color to display range [0, 1] without encoding.
+func (setting Setting) MapColor(color image.Color) image.Color {
+	operator := setting.Operator
+	if operator == nil {
+		operator = LinearOperator{}
+	}
+
+	scale := math.Pow(2.0, setting.Exposure)
+	mapValue := func(value float32) float32 {
+		return float32(operator.Map(scale * float64(value)))
+	}
+
+	return image.Color{R: mapValue(color.R), G: mapValue(color.G), B: mapValue(color.B)}
+}
+
+// Maximum luminance of pixels in a linear sRGB image.
+func maxLuminance(img image.Image) float64 {
+	max := 0.0
+	for _, pixel := range img.Pixels {
+		max = math.Max(max, image.Luminance(pixel.Color))
+	}
+
+	return max
+}
@@ -0,0 +1,152 @@
+package tonemap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/locatw/go-ray-tracer/image"
+)
+
+func TestOperatorMap(t *testing.T) {
+	patterns := []struct {
+		name     string
+		operator Operator
+		value    float64
+		expected float64
+	}{
+		{name: "linear", operator: LinearOperator{}, value: 0.5, expected: 0.5},
+		{name: "linear", operator: LinearOperator{}, value: 2.0, expected: 1.0},
+		{name: "linear", operator: LinearOperator{}, value: -1.0, expected: 0.0},
+		{name: "reinhard", operator: ReinhardOperator{}, value: 1.0, expected: 0.5},
+		{name: "reinhard", operator: ReinhardOperator{}, value: 3.0, expected: 0.75},
+		{name: "extended reinhard", operator: ExtendedReinhardOperator{WhitePoint: 4.0}, value: 4.0, expected: 1.0},
+		{name: "extended reinhard", operator: ExtendedReinhardOperator{WhitePoint: 4.0}, value: 1.0, expected: 1.0625 / 2.0},
+		{name: "aces", operator: AcesOperator{}, value: 0.0, expected: 0.0},
+		{name: "aces", operator: AcesOperator{}, value: 100.0, expected: 1.0},
+		{name: "hable", operator: HableOperator{WhitePoint: 11.2}, value: 0.0, expected: 0.0},
+		{name: "hable", operator: HableOperator{WhitePoint: 11.2}, value: 11.2, expected: 1.0},
+	}
+
+	for _, pattern := range patterns {
+		actual := pattern.operator.Map(pattern.value)
+
+		if 1e-6 < math.Abs(actual-pattern.expected) {
+			t.Errorf("%s operator Map(%f) must return %f, actual %f", pattern.name, pattern.value, pattern.expected, actual)
+		}
+	}
+}
+
+func TestOperatorIsMonotonic(t *testing.T) {
+	for _, name := range OperatorNames {
+		operator, err := CreateOperator(name, 0.0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		previous := operator.Map(0.0)
+		for value := 0.01; value < 20.0; value += 0.01 {
+			current := operator.Map(value)
+			if current < previous || 1.0 < current {
+				t.Errorf("%s operator must be monotonic in [0, 1], actual Map(%f) is %f after %f", name, value, current, previous)
+				break
+			}
+			previous = current
+		}
+	}
+}
+
+func TestCreateOperator(t *testing.T) {
+	t.Run("When name is unknown", func(t *testing.T) {
+		_, err := CreateOperator("filmic", 0.0)
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil {
+				t.Errorf("got: nil, want: error")
+			}
+		})
+	})
+
+	t.Run("When white point is omitted", func(t *testing.T) {
+		operator, err := CreateOperator("Hable", 0.0)
+
+		t.Run("it uses the default white point", func(t *testing.T) {
+			expected := HableOperator{WhitePoint: DefaultHableWhitePoint}
+			if err != nil || operator != expected {
+				t.Errorf("got: %v (error: %v), want: %v", operator, err, expected)
+			}
+		})
+	})
+}
+
+func TestSettingApply(t *testing.T) {
+	img := image.CreateImage(2, 1)
+	img.Pixels[0].Color = image.Color{R: 0.25, G: 0.5, B: 4.0}
+	img.Pixels[1].Alpha = 0.5
+
+	t.Run("When exposure is set", func(t *testing.T) {
+		setting := Setting{Operator: LinearOperator{}, Exposure: 1.0}
+
+		result := setting.Apply(img)
+
+		t.Run("it scales radiance by a power of two before the operator", func(t *testing.T) {
+			expected := image.Color{
+				R: image.EncodeSRGB(0.5),
+				G: 1.0,
+				B: 1.0,
+			}
+			if !result.Pixels[0].Color.NearlyEqual(expected) {
+				t.Errorf("got: %v, want: %v", result.Pixels[0].Color, expected)
+			}
+		})
+
+		t.Run("it encodes in sRGB", func(t *testing.T) {
+			if result.ColorSpace != image.SRGB {
+				t.Errorf("got: %v, want: %v", result.ColorSpace, image.SRGB)
+			}
+		})
+
+		t.Run("it keeps alpha and the original image", func(t *testing.T) {
+			if result.Pixels[1].Alpha != 0.5 || img.Pixels[0].Color.B != 4.0 {
+				t.Errorf("got: alpha %f and original %v, want: alpha 0.5 and unchanged original", result.Pixels[1].Alpha, img.Pixels[0].Color)
+			}
+		})
+	})
+
+	t.Run("When extended Reinhard operator has no white point", func(t *testing.T) {
+		operator, err := CreateOperator(ExtendedReinhardName, 0.0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bright := image.CreateImage(2, 1)
+		bright.Pixels[0].Color = image.Color{R: 4.0, G: 4.0, B: 4.0}
+		bright.Pixels[1].Color = image.Color{R: 1.0, G: 1.0, B: 1.0}
+
+		result := Setting{Operator: operator}.Apply(bright)
+
+		t.Run("it maps the maximum luminance to 1", func(t *testing.T) {
+			expected := image.Color{R: 1.0, G: 1.0, B: 1.0}
+			if !result.Pixels[0].Color.NearlyEqual(expected) {
+				t.Errorf("got: %v, want: %v", result.Pixels[0].Color, expected)
+			}
+		})
+
+		t.Run("it compresses values below the maximum luminance", func(t *testing.T) {
+			value := image.EncodeSRGB(1.0625 / 2.0)
+			expected := image.Color{R: value, G: value, B: value}
+			if !result.Pixels[1].Color.NearlyEqual(expected) {
+				t.Errorf("got: %v, want: %v", result.Pixels[1].Color, expected)
+			}
+		})
+	})
+
+	t.Run("When operator is nil", func(t *testing.T) {
+		result := Setting{}.Apply(img)
+
+		t.Run("it clamps like linear operator", func(t *testing.T) {
+			if result.Pixels[0].Color.B != 1.0 {
+				t.Errorf("got: %v, want: blue 1.0", result.Pixels[0].Color)
+			}
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package rendering
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Heuristic of multiple importance sampling which combines light sampling and BSDF sampling.
+type MisHeuristic int
+
+const (
+	// Weight by squared densities (Veach, 1997), which is the default.
+	PowerHeuristic MisHeuristic = iota
+	// Weight by densities.
+	BalanceHeuristic
+	// Emission of sampled lights is estimated only by light sampling, and ignored when rays hit them.
+	LightSamplingOnly
+)
+
+// Names of heuristics in the order of their values.
+var MisHeuristicNames = []string{"power", "balance", "none"}
+
+func (heuristic MisHeuristic) String() string {
+	if 0 <= int(heuristic) && int(heuristic) < len(MisHeuristicNames) {
+		return MisHeuristicNames[heuristic]
+	}
+
+	return fmt.Sprintf("MisHeuristic(%d)", int(heuristic))
+}
+
+func ParseMisHeuristic(name string) (MisHeuristic, error) {
+	for i, heuristicName := range MisHeuristicNames {
+		if heuristicName == strings.ToLower(name) {
+			return MisHeuristic(i), nil
+		}
+	}
+
+	return PowerHeuristic, fmt.Errorf("unknown MIS heuristic: %s", name)
+}
+
+// Weight of a light sample whose density is lightPdf, which can also be sampled by BSDF with bsdfPdf.
+func (heuristic MisHeuristic) lightWeight(lightPdf float64, bsdfPdf float64) float64 {
+	if heuristic == LightSamplingOnly {
+		return 1.0
+	}
+
+	return heuristic.weight(lightPdf, bsdfPdf)
+}
+
+// Weight of a BSDF sample whose density is bsdfPdf, which can also be sampled by light with lightPdf.
+func (heuristic MisHeuristic) bsdfWeight(bsdfPdf float64, lightPdf float64) float64 {
+	if heuristic == LightSamplingOnly {
+		return 0.0
+	}
+
+	return heuristic.weight(bsdfPdf, lightPdf)
+}
+
+func (heuristic MisHeuristic) weight(pdf float64, otherPdf float64) float64 {
+	if pdf <= 0.0 {
+		return 0.0
+	}
+
+	if heuristic == BalanceHeuristic {
+		return pdf / (pdf + otherPdf)
+	}
+
+	p := pdf * pdf
+	o := otherPdf * otherPdf
+
+	return p / (p + o)
+}
@@ -0,0 +1,47 @@
+package rendering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMisHeuristicWeight(t *testing.T) {
+	patterns := []struct {
+		heuristic MisHeuristic
+		lightPdf  float64
+		bsdfPdf   float64
+		expected  float64
+	}{
+		{heuristic: PowerHeuristic, lightPdf: 1.0, bsdfPdf: 1.0, expected: 0.5},
+		{heuristic: PowerHeuristic, lightPdf: 3.0, bsdfPdf: 1.0, expected: 0.9},
+		{heuristic: BalanceHeuristic, lightPdf: 3.0, bsdfPdf: 1.0, expected: 0.75},
+		{heuristic: PowerHeuristic, lightPdf: 2.0, bsdfPdf: 0.0, expected: 1.0},
+		{heuristic: LightSamplingOnly, lightPdf: 1.0, bsdfPdf: 3.0, expected: 1.0},
+	}
+
+	for _, pattern := range patterns {
+		lightWeight := pattern.heuristic.lightWeight(pattern.lightPdf, pattern.bsdfPdf)
+		if 1e-9 < math.Abs(lightWeight-pattern.expected) {
+			t.Errorf("%s.lightWeight(%f, %f) must return %f, actual %f",
+				pattern.heuristic, pattern.lightPdf, pattern.bsdfPdf, pattern.expected, lightWeight)
+		}
+
+		bsdfWeight := pattern.heuristic.bsdfWeight(pattern.bsdfPdf, pattern.lightPdf)
+		if 1e-9 < math.Abs(lightWeight+bsdfWeight-1.0) {
+			t.Errorf("weights of %s must sum to 1, actual %f + %f", pattern.heuristic, lightWeight, bsdfWeight)
+		}
+	}
+}
+
+func TestParseMisHeuristic(t *testing.T) {
+	for _, heuristic := range []MisHeuristic{PowerHeuristic, BalanceHeuristic, LightSamplingOnly} {
+		result, err := ParseMisHeuristic(heuristic.String())
+		if err != nil || result != heuristic {
+			t.Errorf("ParseMisHeuristic(%q) must return %s, actual %s (error: %v)", heuristic.String(), heuristic, result, err)
+		}
+	}
+
+	if _, err := ParseMisHeuristic("unknown"); err == nil {
+		t.Errorf("ParseMisHeuristic(\"unknown\") must return an error")
+	}
+}
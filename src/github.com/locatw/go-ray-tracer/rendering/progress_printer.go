@@ -1,8 +1,12 @@
 package rendering
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type ProgressPrinter struct {
+	Writer     io.Writer
 	TotalCount int
 	Interval   int
 	Count      int
@@ -14,6 +18,6 @@ func (printer *ProgressPrinter) Print() {
 	if printer.Count%printer.Interval == 0 {
 		progress := float64(printer.Count) / float64(printer.TotalCount) * 100.0
 
-		fmt.Printf("Progress: %.1f %%\n", progress)
+		fmt.Fprintf(printer.Writer, "Progress: %.1f %%\n", progress)
 	}
 }
@@ -2,6 +2,8 @@ package rendering
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
 	"math/rand"
 	"runtime"
@@ -9,6 +11,7 @@ import (
 
 	. "github.com/locatw/go-ray-tracer/element"
 	"github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/tonemap"
 	. "github.com/locatw/go-ray-tracer/vector"
 )
 
@@ -18,39 +21,111 @@ type RayTracer struct {
 }
 
 type RenderingSetting struct {
-	Resolution                 image.Resolution
-	SamplingCount              int
-	TraceRecursionLimit        int
+	Resolution          image.Resolution
+	SamplingCount       int
+	TraceRecursionLimit int
+	// Divide shaded radiance by 1 + 0.01 d^2 of the distance d, which is not physical and darkens
+	// scenes by orders of magnitude. It is kept for scenes tuned with it.
 	DistanceAttenuationEnabled bool
+	// Number of goroutines which render pixels. If it is zero or less, runtime.NumCPU() is used.
+	WorkerCount int
+	// If set, random numbers are seeded per pixel so that rendering is reproducible
+	// regardless of the number of workers.
+	RandomSeed *int64
+	// Heuristic to combine light sampling and BSDF sampling. The zero value is PowerHeuristic.
+	MisHeuristic MisHeuristic
+	// Post process which converts linear radiance to a displayable image.
+	ToneMapping tonemap.Setting
+	// Writer where the number of workers and the progress are reported. Nothing is reported if it is nil.
+	Log io.Writer
+}
+
+func (setting *RenderingSetting) workerCount() int {
+	if setting.WorkerCount <= 0 {
+		return runtime.NumCPU()
+	}
+
+	return setting.WorkerCount
+}
+
+func (setting *RenderingSetting) log() io.Writer {
+	if setting.Log == nil {
+		return ioutil.Discard
+	}
+
+	return setting.Log
 }
 
 type renderingContext struct {
 	Random *rand.Rand
 }
 
+// Scattering event which generated a ray by sampling BSDF after lights were sampled.
+// It is used to weight emission which the ray finds.
+type scattering struct {
+	Position Vector
+	// Solid angle density of the ray direction.
+	Pdf float64
+}
+
+// Linear radiance and auxiliary passes of primary rays.
+type RenderResult struct {
+	// Average radiance of all samples, which is premultiplied by Alpha, the coverage of primary rays.
+	Beauty image.Image
+	// Average reflectance of the surfaces which primary rays hit.
+	Albedo image.Image
+	// Average normal of the surfaces which primary rays hit, stored in X, Y and Z as R, G and B.
+	Normal image.Image
+	// Average distance to the surfaces which primary rays hit, stored in all channels.
+	Depth image.Image
+}
+
+// Render and return the tone mapped image.
 func (rayTracer *RayTracer) Render() image.Image {
-	camera := rayTracer.Scene.Camera
+	return rayTracer.RenderingSetting.ToneMapping.Apply(rayTracer.RenderLinear())
+}
+
+// Render and return the linear radiance without tone mapping.
+func (rayTracer *RayTracer) RenderLinear() image.Image {
+	return rayTracer.RenderPasses().Beauty
+}
+
+// Render and return the linear radiance with auxiliary passes.
+func (rayTracer *RayTracer) RenderPasses() RenderResult {
+	// prepare the scene before workers look up objects concurrently
+	rayTracer.Scene.updateAccelerator()
+	rayTracer.Scene.indexLights()
 
 	resolution := rayTracer.RenderingSetting.Resolution
-	screen := CreateScreen(&camera, resolution)
-	img := image.CreateImage(resolution.Width, resolution.Height)
+	screen := CreateScreen(resolution)
+	result := RenderResult{
+		Beauty: image.CreateImage(resolution.Width, resolution.Height),
+		Albedo: image.CreateImage(resolution.Width, resolution.Height),
+		Normal: image.CreateImage(resolution.Width, resolution.Height),
+		Depth:  image.CreateImage(resolution.Width, resolution.Height),
+	}
+	result.Normal.ColorSpace = image.NonColor
+	result.Depth.ColorSpace = image.NonColor
 
 	capacity := resolution.PixelCount()
-	pixelCh := make(chan *image.Pixel, capacity)
-	resultCh := make(chan *image.Pixel, capacity)
+	pixelCh := make(chan int, capacity)
+	resultCh := make(chan int, capacity)
 
-	for i := 0; i < runtime.NumCPU(); i++ {
-		go rayTracer.renderPixelRoutine(&screen, pixelCh, resultCh)
+	workerCount := rayTracer.RenderingSetting.workerCount()
+	for i := 0; i < workerCount; i++ {
+		go rayTracer.renderPixelRoutine(i, &screen, &result, pixelCh, resultCh)
 	}
 
-	for i := 0; i < len(img.Pixels); i++ {
-		pixelCh <- &img.Pixels[i]
+	for i := 0; i < capacity; i++ {
+		pixelCh <- i
 	}
 
-	fmt.Printf("NumCPU: %d\n", runtime.NumCPU())
-	fmt.Printf("NumGoroutine: %d\n\n", runtime.NumGoroutine())
+	log := rayTracer.RenderingSetting.log()
+	fmt.Fprintf(log, "NumCPU: %d\n", runtime.NumCPU())
+	fmt.Fprintf(log, "Workers: %d\n\n", workerCount)
 
 	progressPrinter := ProgressPrinter{
+		Writer:     log,
 		TotalCount: resolution.PixelCount(),
 		Interval:   5 * resolution.Width,
 		Count:      0,
@@ -71,47 +146,114 @@ func (rayTracer *RayTracer) Render() image.Image {
 	close(pixelCh)
 	close(resultCh)
 
-	return img
+	return result
 }
 
-func (rayTracer *RayTracer) renderPixelRoutine(screen *Screen, pixelCh <-chan *image.Pixel, resultCh chan<- *image.Pixel) {
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (rayTracer *RayTracer) renderPixelRoutine(index int, screen *Screen, result *RenderResult, pixelCh <-chan int, resultCh chan<- int) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano() + int64(index)))
 	context := renderingContext{Random: random}
 
+	seed := rayTracer.RenderingSetting.RandomSeed
+
 	for {
-		pixel, ok := <-pixelCh
+		pixelIndex, ok := <-pixelCh
 		if !ok {
 			break
 		}
 
-		rayTracer.renderPixel(context, screen, pixel)
+		if seed != nil {
+			random.Seed(*seed + int64(pixelIndex))
+		}
+
+		rayTracer.renderPixel(context, screen, result, pixelIndex)
 
-		resultCh <- pixel
+		resultCh <- pixelIndex
 	}
 }
 
-func (rayTracer *RayTracer) renderPixel(context renderingContext, screen *Screen, pixel *image.Pixel) {
+func (rayTracer *RayTracer) renderPixel(context renderingContext, screen *Screen, result *RenderResult, pixelIndex int) {
 	camera := rayTracer.Scene.Camera
 	setting := rayTracer.RenderingSetting
+	pixel := &result.Beauty.Pixels[pixelIndex]
 
 	pixelColor := image.CreateDefaultColor(image.Black)
+	albedo := image.CreateDefaultColor(image.Black)
+	normal := CreateZeroVector()
+	depth := 0.0
+	hitCount := 0
 	for _, ray := range screen.CreatePixelRays(context, &camera, pixel.Coordinate.X, pixel.Coordinate.Y, setting.SamplingCount) {
-		color := rayTracer.traceRay(context, ray, setting.TraceRecursionLimit)
+		hitInfo := rayTracer.intersect(ray)
+		if hitInfo == nil {
+			pixelColor = image.AddColor(pixelColor, rayTracer.escapedRadiance(ray, nil))
+			continue
+		}
+
+		hitCount++
+		albedo = image.AddColor(albedo, surfaceAlbedo(hitInfo))
+		normal = Add(normal, hitInfo.ShadingNormal)
+		depth += hitInfo.T
+
+		color := rayTracer.shade(context, ray, hitInfo, setting.TraceRecursionLimit, nil)
 
 		pixelColor = image.AddColor(pixelColor, color)
 	}
 
-	pixel.Color = toneMap(image.DivideScalar(pixelColor, float64(setting.SamplingCount)))
+	pixel.Color = image.DivideScalar(pixelColor, float64(setting.SamplingCount))
+	// coverage of primary rays, so that the background becomes transparent even if infinite lights are seen
+	pixel.Alpha = float32(hitCount) / float32(setting.SamplingCount)
+
+	if 0 < hitCount {
+		n := Multiply(1.0/float64(hitCount), normal)
+		d := float32(depth / float64(hitCount))
+
+		result.Albedo.Pixels[pixelIndex].Color = image.DivideScalar(albedo, float64(hitCount))
+		result.Normal.Pixels[pixelIndex].Color = image.Color{R: float32(n.X), G: float32(n.Y), B: float32(n.Z)}
+		result.Depth.Pixels[pixelIndex].Color = image.Color{R: d, G: d, B: d}
+	}
 }
 
-func (rayTracer *RayTracer) traceRay(context renderingContext, ray Ray, depth int) image.Color {
-	if depth <= 0 {
+// Return the albedo of the BSDF at a hit, or black for a material which does not scatter light.
+func surfaceAlbedo(hitInfo *HitInfo) image.Color {
+	material := hitInfo.Object.GetMaterial()
+	bsdf := material.GetBSDF(hitInfo.TexturePoint())
+	if bsdf == nil {
 		return image.CreateDefaultColor(image.Black)
 	}
 
+	return bsdf.Albedo()
+}
+
+// Return the nearest hit of a ray, whose shading normal is perturbed by the material.
+func (rayTracer *RayTracer) intersect(ray Ray) *HitInfo {
 	hitInfo := rayTracer.Scene.LookForIntersectedObject(ray)
+	if hitInfo == nil {
+		return nil
+	}
+
+	material := hitInfo.Object.GetMaterial()
+	material.PerturbShadingNormal(hitInfo)
+
+	return hitInfo
+}
+
+// Trace a ray and return the incident radiance. If lights were sampled at the origin of the ray,
+// previous is the scattering which generated it. Otherwise it is nil.
+func (rayTracer *RayTracer) traceRay(context renderingContext, ray Ray, depth int, previous *scattering) image.Color {
+	if depth <= 0 {
+		return image.CreateDefaultColor(image.Black)
+	}
+
+	hitInfo := rayTracer.intersect(ray)
 
 	if hitInfo == nil {
+		return rayTracer.escapedRadiance(ray, previous)
+	}
+
+	return rayTracer.shade(context, ray, hitInfo, depth, previous)
+}
+
+func (rayTracer *RayTracer) shade(context renderingContext, ray Ray, hitInfo *HitInfo, depth int, previous *scattering) image.Color {
+	if depth <= 0 {
 		return image.CreateDefaultColor(image.Black)
 	}
 
@@ -119,81 +261,133 @@ func (rayTracer *RayTracer) traceRay(context renderingContext, ray Ray, depth in
 
 	emissionColor := image.CreateDefaultColor(image.Black)
 	if !material.Emission.NearlyEqual(emissionColor) {
-		emissionColor =
-			image.MultiplyScalar(Dot(Multiply(-1.0, ray.Direction), hitInfo.Normal), material.Emission)
-		emissionColor = rayTracer.distanceAttenuation(ray, hitInfo, emissionColor)
-	}
-
-	diffuseColor := image.CreateDefaultColor(image.Black)
-	if !material.Diffuse.NearlyEqual(diffuseColor) {
-		diffuseRay := CreateDiffuseRay(context.Random, ray, hitInfo)
-		diffuseColor = rayTracer.traceRay(context, diffuseRay, depth-1)
-		diffuseColor = image.MultiplyColor(material.Diffuse, diffuseColor)
-		diffuseColor = rayTracer.distanceAttenuation(ray, hitInfo, diffuseColor)
-	}
-
-	refractionColor := image.CreateDefaultColor(image.Black)
-	refracted := true
-	if material.IndexOfRefraction != nil {
-		refractRay, isTotalReflection := CreateRefractRay(ray, hitInfo)
-		if !isTotalReflection {
-			inObject := Dot(Multiply(-1.0, ray.Direction), hitInfo.Normal) < 0.0
-
-			normal := hitInfo.Normal
-			if inObject {
-				normal = Multiply(-1.0, hitInfo.Normal)
-			}
-
-			kr := rayTracer.reflectance(ray, normal, 1.0, *material.IndexOfRefraction)
-
-			if kr < context.Random.Float64() {
-				refractionColor = rayTracer.traceRay(context, refractRay, depth-1)
-				refractionColor = rayTracer.distanceAttenuation(ray, hitInfo, refractionColor)
-			} else {
-				refracted = false
-			}
-		} else {
-			refracted = false
+		emissionColor = EmittedRadiance(material, Multiply(-1.0, ray.Direction), hitInfo.Normal)
+		emissionColor = image.MultiplyScalar(rayTracer.emissionWeight(hitInfo, previous), emissionColor)
+	}
+
+	bsdf := material.GetBSDF(hitInfo.TexturePoint())
+	if bsdf == nil {
+		return rayTracer.distanceAttenuation(ray, hitInfo, emissionColor)
+	}
+
+	worldOutgoing := Multiply(-1.0, ray.Direction)
+	frame := hitInfo.ShadingFrameToward(worldOutgoing)
+	outgoing := frame.ToLocal(worldOutgoing)
+
+	// light found by the next ray is counted at depth-1, so lights are sampled only if it is traced
+	sampleLights := 1 < depth && 0 < len(rayTracer.Scene.sampledLights)
+
+	directColor := image.CreateDefaultColor(image.Black)
+	if sampleLights {
+		directColor = rayTracer.sampleDirectLight(context, hitInfo, frame, bsdf, outgoing)
+	}
+
+	indirectColor := image.CreateDefaultColor(image.Black)
+	sample, ok := bsdf.Sample(context.Random, outgoing)
+	if ok && hitInfo.IsConsistentScattering(frame, worldOutgoing, frame.ToWorld(sample.Incoming)) {
+		nextRay := CreateScatteredRay(hitInfo, frame.ToWorld(sample.Incoming))
+
+		var next *scattering
+		if sampleLights && !sample.IsDelta {
+			next = &scattering{Position: hitInfo.Position, Pdf: sample.Pdf}
 		}
-	} else {
-		refracted = false
+
+		indirectColor = rayTracer.traceRay(context, nextRay, depth-1, next)
+		indirectColor = image.MultiplyColor(sample.Weight, indirectColor)
 	}
 
-	specularColor := image.CreateDefaultColor(image.Black)
-	if !refracted && !material.Specular.NearlyEqual(specularColor) {
-		reflectRay := CreateReflectRay(ray, hitInfo)
-		specularColor = rayTracer.traceRay(context, reflectRay, depth-1)
-		specularColor = image.MultiplyColor(material.Specular, specularColor)
-		specularColor = rayTracer.distanceAttenuation(ray, hitInfo, specularColor)
+	return rayTracer.distanceAttenuation(ray, hitInfo, image.AddColorAll(emissionColor, directColor, indirectColor))
+}
+
+// Radiance of infinite lights seen by a ray which escapes from the scene, weighted against light sampling
+// at the origin of the ray.
+func (rayTracer *RayTracer) escapedRadiance(ray Ray, previous *scattering) image.Color {
+	radiance := image.CreateDefaultColor(image.Black)
+
+	for _, light := range rayTracer.Scene.infiniteLights {
+		color := light.Radiance(ray.Direction)
+
+		if previous != nil {
+			lightPdf := light.Pdf(ray.Direction) / float64(len(rayTracer.Scene.sampledLights))
+			color = image.MultiplyScalar(rayTracer.RenderingSetting.MisHeuristic.bsdfWeight(previous.Pdf, lightPdf), color)
+		}
+
+		radiance = image.AddColor(radiance, color)
 	}
 
-	return image.AddColorAll(emissionColor, diffuseColor, specularColor, refractionColor)
+	return radiance
 }
 
-func (rayTracer *RayTracer) distanceAttenuation(ray Ray, hitInfo *HitInfo, color image.Color) image.Color {
-	if rayTracer.RenderingSetting.DistanceAttenuationEnabled {
-		v := Subtract(hitInfo.Position, ray.Origin)
-		distance := v.Length()
+// Weight of emission found by a ray, which is also estimated by light sampling at the origin of the ray.
+func (rayTracer *RayTracer) emissionWeight(hitInfo *HitInfo, previous *scattering) float64 {
+	if previous == nil {
+		return 1.0
+	}
 
-		return image.DivideScalar(color, 1.0+0.01*math.Pow(distance, 2))
-	} else {
-		return color
+	light := rayTracer.Scene.emitterLight(hitInfo.Object)
+	if light == nil {
+		return 1.0
+	}
+
+	lightPdf := light.Pdf(previous.Position, hitInfo.Position, hitInfo.Normal) / float64(len(rayTracer.Scene.sampledLights))
+
+	return rayTracer.RenderingSetting.MisHeuristic.bsdfWeight(previous.Pdf, lightPdf)
+}
+
+// Estimate light scattered by the BSDF from a light chosen uniformly at random.
+func (rayTracer *RayTracer) sampleDirectLight(context renderingContext, hitInfo *HitInfo, frame Frame, bsdf BSDF, outgoing Vector) image.Color {
+	black := image.CreateDefaultColor(image.Black)
+
+	lights := rayTracer.Scene.sampledLights
+	light := lights[context.Random.Intn(len(lights))]
+
+	sample, ok := light.Sample(context.Random, hitInfo.Position)
+	if !ok || sample.Pdf <= 0.0 {
+		return black
+	}
+
+	if !hitInfo.IsConsistentScattering(frame, frame.ToWorld(outgoing), sample.Direction) {
+		return black
+	}
+
+	incoming := frame.ToLocal(sample.Direction)
+
+	f := bsdf.Evaluate(outgoing, incoming)
+	if f.NearlyEqual(black) {
+		return black
+	}
+
+	if !rayTracer.Scene.IsUnoccluded(CreateShadowRay(hitInfo, sample.Direction), sample.Distance) {
+		return black
 	}
+
+	lightPdf := sample.Pdf / float64(len(lights))
+
+	weight := 1.0
+	if !sample.IsDelta {
+		weight = rayTracer.RenderingSetting.MisHeuristic.lightWeight(lightPdf, bsdf.Pdf(outgoing, incoming))
+	}
+
+	scale := weight * math.Abs(incoming.Z) / lightPdf
+	color := image.MultiplyScalar(scale, image.MultiplyColor(f, sample.Radiance))
+
+	if !math.IsInf(sample.Distance, 1) {
+		color = rayTracer.attenuate(sample.Distance, color)
+	}
+
+	return color
 }
 
-func (rayTracer *RayTracer) reflectance(ray Ray, normal Vector, n1 float64, n2 float64) float64 {
-	// schilick's approximation
-	cosTheta := Dot(Multiply(-1.0, ray.Direction), normal)
-	r := math.Pow((n1-n2)/(n1+n2), 2.0)
+func (rayTracer *RayTracer) distanceAttenuation(ray Ray, hitInfo *HitInfo, color image.Color) image.Color {
+	v := Subtract(hitInfo.Position, ray.Origin)
 
-	return r + (1.0-r)*math.Pow(1.0-cosTheta, 5)
+	return rayTracer.attenuate(v.Length(), color)
 }
 
-func toneMap(color image.Color) image.Color {
-	e := 1.0 / 2.2
-	return image.Color{
-		R: float32(math.Pow(float64(color.R), e)),
-		G: float32(math.Pow(float64(color.G), e)),
-		B: float32(math.Pow(float64(color.B), e)),
+func (rayTracer *RayTracer) attenuate(distance float64, color image.Color) image.Color {
+	if rayTracer.RenderingSetting.DistanceAttenuationEnabled {
+		return image.DivideScalar(color, 1.0+0.01*math.Pow(distance, 2))
+	} else {
+		return color
 	}
 }
@@ -0,0 +1,158 @@
+package rendering
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	"github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Scene of a diffuse floor lit by a small emissive sphere.
+func createLightTestScene() Scene {
+	floor := CreateDefaultMaterial()
+	floor.Diffuse = image.Color{R: 0.5, G: 0.5, B: 0.5}
+
+	light := CreateDefaultMaterial()
+	light.Emission = image.Color{R: 10.0, G: 10.0, B: 10.0}
+
+	return Scene{
+		Shapes: []Shape{
+			&Plane{Center: CreateZeroVector(), Normal: Vector{X: 0.0, Y: 1.0, Z: 0.0}, Material: floor},
+			&Sphere{Center: Vector{X: 1.0, Y: 5.0, Z: 0.0}, Radius: 1.0, Material: light},
+		},
+	}
+}
+
+// Average radiance along a ray which hits the floor.
+func estimateRadiance(rayTracer *RayTracer, count int) float64 {
+	rayTracer.Scene.indexLights()
+
+	context := renderingContext{Random: rand.New(rand.NewSource(1))}
+	ray := CreateRay(Vector{X: 0.0, Y: 1.0, Z: 1.0}, Vector{X: 0.0, Y: -1.0, Z: -1.0})
+
+	sum := 0.0
+	for i := 0; i < count; i++ {
+		sum += float64(rayTracer.traceRay(context, ray, 3, nil).R)
+	}
+
+	return sum / float64(count)
+}
+
+func TestTraceRayWithLights(t *testing.T) {
+	t.Run("When a light is small and far from the floor", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("estimating reference radiance without light sampling takes long")
+		}
+
+		for _, attenuation := range []bool{false, true} {
+			setting := RenderingSetting{DistanceAttenuationEnabled: attenuation}
+
+			withoutLights := RayTracer{Scene: createLightTestScene(), RenderingSetting: setting}
+			expected := estimateRadiance(&withoutLights, 200000)
+
+			for _, heuristic := range []MisHeuristic{PowerHeuristic, BalanceHeuristic, LightSamplingOnly} {
+				setting.MisHeuristic = heuristic
+
+				withLights := RayTracer{Scene: createLightTestScene(), RenderingSetting: setting}
+				withLights.Scene.Lights = CreateAreaLights(withLights.Scene.Shapes)
+
+				actual := estimateRadiance(&withLights, 20000)
+
+				name := fmt.Sprintf("it returns the same radiance with %s heuristic and attenuation %t", heuristic, attenuation)
+				t.Run(name, func(t *testing.T) {
+					if 0.05*expected < math.Abs(actual-expected) {
+						t.Errorf("got: %f, want: %f", actual, expected)
+					}
+				})
+			}
+		}
+	})
+
+	t.Run("When a light is large and close to the floor", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("estimating reference radiance without light sampling takes long")
+		}
+
+		createScene := func() Scene {
+			scene := createLightTestScene()
+			scene.Shapes[1].(*Sphere).Center = Vector{X: 0.0, Y: 4.0, Z: 0.0}
+			scene.Shapes[1].(*Sphere).Radius = 3.0
+			return scene
+		}
+
+		withoutLights := RayTracer{Scene: createScene()}
+		expected := estimateRadiance(&withoutLights, 200000)
+
+		for _, heuristic := range []MisHeuristic{PowerHeuristic, BalanceHeuristic} {
+			withLights := RayTracer{Scene: createScene(), RenderingSetting: RenderingSetting{MisHeuristic: heuristic}}
+			withLights.Scene.Lights = CreateAreaLights(withLights.Scene.Shapes)
+
+			actual := estimateRadiance(&withLights, 20000)
+
+			t.Run("it returns the same radiance with "+heuristic.String()+" heuristic", func(t *testing.T) {
+				if 0.05*expected < math.Abs(actual-expected) {
+					t.Errorf("got: %f, want: %f", actual, expected)
+				}
+			})
+		}
+	})
+
+	t.Run("When a scene has a point light", func(t *testing.T) {
+		scene := createLightTestScene()
+		scene.Shapes = scene.Shapes[:1]
+		scene.Lights = []Light{&PointLight{Position: Vector{X: 0.0, Y: 2.0, Z: 0.0}, Intensity: image.Color{R: 4.0, G: 4.0, B: 4.0}}}
+		rayTracer := RayTracer{Scene: scene}
+
+		actual := estimateRadiance(&rayTracer, 1)
+
+		t.Run("it returns reflected light of the point light", func(t *testing.T) {
+			// albedo / pi * intensity / distance^2 at the origin of the floor
+			expected := 0.5 / math.Pi * 4.0 / 4.0
+			if 1e-6 < math.Abs(actual-expected) {
+				t.Errorf("got: %f, want: %f", actual, expected)
+			}
+		})
+	})
+
+	t.Run("When a scene has an environment light", func(t *testing.T) {
+		img := image.CreateImage(8, 4)
+		for i := range img.Pixels {
+			img.Pixels[i].Color = image.Color{R: 0.5, G: 0.5, B: 0.5}
+		}
+		img.Pixels[1*8+2].Color = image.Color{R: 50.0, G: 50.0, B: 50.0}
+		light := CreateEnvironmentLight(img, 0.3, image.Color{R: 2.0, G: 2.0, B: 2.0})
+
+		// albedo / pi * irradiance, where irradiance is integrated over the upper hemisphere
+		irradiance := 0.0
+		n := 400
+		for i := 0; i < n; i++ {
+			theta := 0.5 * math.Pi * (float64(i) + 0.5) / float64(n)
+			for j := 0; j < 4*n; j++ {
+				phi := 2.0 * math.Pi * (float64(j) + 0.5) / float64(4*n)
+				direction := Vector{X: math.Sin(theta) * math.Cos(phi), Y: math.Cos(theta), Z: math.Sin(theta) * math.Sin(phi)}
+				dOmega := math.Sin(theta) * (0.5 * math.Pi / float64(n)) * (2.0 * math.Pi / float64(4*n))
+				irradiance += float64(light.Radiance(direction).R) * math.Cos(theta) * dOmega
+			}
+		}
+		expected := 0.5 / math.Pi * irradiance
+
+		for _, heuristic := range []MisHeuristic{PowerHeuristic, BalanceHeuristic, LightSamplingOnly} {
+			scene := createLightTestScene()
+			scene.Shapes = scene.Shapes[:1]
+			scene.Lights = []Light{light}
+			rayTracer := RayTracer{Scene: scene, RenderingSetting: RenderingSetting{MisHeuristic: heuristic}}
+
+			actual := estimateRadiance(&rayTracer, 20000)
+
+			t.Run("it returns reflected light of the environment with "+heuristic.String()+" heuristic", func(t *testing.T) {
+				if 0.03*expected < math.Abs(actual-expected) {
+					t.Errorf("got: %f, want: %f", actual, expected)
+				}
+			})
+		}
+	})
+}
@@ -5,12 +5,154 @@ import . "github.com/locatw/go-ray-tracer/element"
 type Scene struct {
 	Camera Camera
 	Shapes []Shape
+	// Infinite lights such as EnvironmentLight, PreethamSky and SunLight are also the background,
+	// which is seen by rays escaping from the scene.
+	Lights []Light
+
+	bvh             *BVH
+	unboundedShapes []Shape
+	// Shapes which bvh and unboundedShapes are built from
+	acceleratedShapes []Shape
+	// Lights which are chosen by light sampling
+	sampledLights []Light
+	// area lights of objects in HitInfo, to weight emission found by rays
+	emitterLights map[Shape]*AreaLight
+	// lights seen by rays which escape from the scene
+	infiniteLights []InfiniteLight
+}
+
+// Relative tolerance of shadow rays which reach a sampled point on a light.
+const shadowEpsilon = 1.0e-6
+
+// Build the BVH over bounded shapes unless it is built from the current Shapes.
+// Unbounded shapes such as Plane are kept in a separate list.
+// Shapes is regarded as modified when it is assigned or resized, so replacing an element in place
+// has to be done by assigning a new slice.
+// It is called by lookups, which must not run concurrently until it has been called for the current
+// Shapes.
+func (scene *Scene) updateAccelerator() {
+	if scene.bvh != nil && isSameSlice(scene.acceleratedShapes, scene.Shapes) {
+		return
+	}
+
+	bounded, unbounded := SplitBoundedShapes(scene.Shapes)
+
+	scene.bvh = CreateBVH(bounded)
+	scene.unboundedShapes = unbounded
+	scene.acceleratedShapes = scene.Shapes
+}
+
+// Whether two slices refer to the same elements of the same array.
+func isSameSlice(a []Shape, b []Shape) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Index lights which are sampled and objects of area lights, so that emission found by rays is
+// weighted against light sampling.
+// Area lights which share objects with others, such as instances of the same mesh, cannot be told
+// apart from a hit, so they are not sampled and their emission is found only by rays.
+// It must be called again after Lights is modified.
+func (scene *Scene) indexLights() {
+	owners := make(map[Shape][]*AreaLight)
+	for _, light := range scene.Lights {
+		if areaLight, ok := light.(*AreaLight); ok {
+			for _, object := range hitObjects(areaLight.Shape) {
+				owners[object] = append(owners[object], areaLight)
+			}
+		}
+	}
+
+	shared := make(map[*AreaLight]bool)
+	for _, lights := range owners {
+		if 1 < len(lights) {
+			for _, light := range lights {
+				shared[light] = true
+			}
+		}
+	}
+
+	scene.sampledLights = make([]Light, 0, len(scene.Lights))
+	scene.emitterLights = make(map[Shape]*AreaLight)
+	scene.infiniteLights = make([]InfiniteLight, 0)
+	for _, light := range scene.Lights {
+		if infiniteLight, ok := light.(InfiniteLight); ok {
+			scene.infiniteLights = append(scene.infiniteLights, infiniteLight)
+		}
+
+		areaLight, ok := light.(*AreaLight)
+		if ok && shared[areaLight] {
+			continue
+		}
+
+		scene.sampledLights = append(scene.sampledLights, light)
+
+		if ok {
+			for _, object := range hitObjects(areaLight.Shape) {
+				scene.emitterLights[object] = areaLight
+			}
+		}
+	}
+}
+
+// Return objects which are set to HitInfo.Object by intersection with a shape.
+func hitObjects(shape Shape) []Shape {
+	switch s := shape.(type) {
+	case *Mesh:
+		objects := make([]Shape, len(s.Triangles))
+		for i, triangle := range s.Triangles {
+			objects[i] = triangle
+		}
+		return objects
+	case *TransformedShape:
+		return hitObjects(s.Shape)
+	default:
+		return []Shape{shape}
+	}
+}
+
+// Return the area light of an object which is sampled, or nil.
+func (scene *Scene) emitterLight(object Shape) *AreaLight {
+	return scene.emitterLights[object]
+}
+
+// Whether nothing blocks a ray before it travels distance.
+// It stops at the first blocking object instead of looking for the closest one.
+func (scene *Scene) IsUnoccluded(ray Ray, distance float64) bool {
+	scene.updateAccelerator()
+
+	tMax := distance * (1.0 - shadowEpsilon)
+	for _, shape := range scene.unboundedShapes {
+		hitInfo := shape.Intersect(ray)
+
+		if hitInfo != nil && hitInfo.T < tMax {
+			return false
+		}
+	}
+
+	return !scene.bvh.IntersectAny(ray, tMax)
 }
 
 func (scene *Scene) LookForIntersectedObject(ray Ray) *HitInfo {
+	scene.updateAccelerator()
+
+	minHitInfo := scene.bvh.Intersect(ray)
+
+	hitInfo := lookForIntersectedObject(scene.unboundedShapes, ray)
+	if hitInfo != nil && (minHitInfo == nil || hitInfo.T < minHitInfo.T) {
+		minHitInfo = hitInfo
+	}
+
+	if minHitInfo != nil {
+		minHitInfo.Time = ray.Time
+	}
+
+	return minHitInfo
+}
+
+func lookForIntersectedObject(shapes []Shape, ray Ray) *HitInfo {
 	var minHitInfo *HitInfo
 
-	for _, shape := range scene.Shapes {
+	for _, shape := range shapes {
 		hitInfo := shape.Intersect(ray)
 
 		if hitInfo == nil {
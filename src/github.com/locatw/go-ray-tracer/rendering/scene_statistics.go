@@ -0,0 +1,52 @@
+package rendering
+
+import . "github.com/locatw/go-ray-tracer/element"
+
+type SceneStatistics struct {
+	ShapeCount          int
+	BoundedShapeCount   int
+	UnboundedShapeCount int
+	TriangleCount       int
+	LightCount          int
+	// Bounds of the bounded shapes. Unbounded shapes are not included.
+	Bounds AABB
+}
+
+func (scene *Scene) Statistics() SceneStatistics {
+	statistics := SceneStatistics{
+		ShapeCount: len(scene.Shapes),
+		LightCount: len(scene.Lights),
+		Bounds:     CreateEmptyAABB(),
+	}
+
+	for _, shape := range scene.Shapes {
+		statistics.TriangleCount += triangleCount(shape)
+
+		if IsBoundedShape(shape) {
+			statistics.BoundedShapeCount++
+			statistics.Bounds = UnionAABB(statistics.Bounds, shape.(Bounded).Bounds())
+		} else {
+			statistics.UnboundedShapeCount++
+		}
+	}
+
+	return statistics
+}
+
+// Count triangles of a shape, including ones of a shape placed by a transform or moving.
+func triangleCount(shape Shape) int {
+	switch s := shape.(type) {
+	case *Mesh:
+		return len(s.Triangles)
+	case *Triangle:
+		return 1
+	case *TransformedShape:
+		return triangleCount(s.Shape)
+	case *AnimatedShape:
+		return triangleCount(s.Shape)
+	case *LinearMotionShape:
+		return triangleCount(s.Shape)
+	default:
+		return 0
+	}
+}
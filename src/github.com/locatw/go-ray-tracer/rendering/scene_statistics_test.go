@@ -0,0 +1,76 @@
+package rendering
+
+import (
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestSceneStatistics(t *testing.T) {
+	triangle := &Triangle{
+		Vertices: [3]Vector{{X: 0.0, Y: 0.0, Z: 0.0}, {X: 1.0, Y: 0.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 0.0}},
+	}
+	mesh := CreateMesh("mesh", []*Triangle{
+		{Vertices: [3]Vector{{X: 0.0, Y: 0.0, Z: 0.0}, {X: 1.0, Y: 0.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 0.0}}},
+		{Vertices: [3]Vector{{X: 0.0, Y: 0.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 0.0}, {X: 0.0, Y: 0.0, Z: -4.0}}},
+	}, CreateDefaultMaterial())
+	sphere := &Sphere{Center: Vector{X: 5.0, Y: 0.0, Z: 0.0}, Radius: 1.0}
+	plane := &Plane{Center: CreateZeroVector(), Normal: CreateAxisVector(YAxis)}
+
+	scene := Scene{Shapes: []Shape{triangle, mesh, sphere, plane}}
+	expected := SceneStatistics{
+		ShapeCount:          4,
+		BoundedShapeCount:   3,
+		UnboundedShapeCount: 1,
+		TriangleCount:       3,
+		Bounds:              AABB{Min: Vector{X: 0.0, Y: -1.0, Z: -4.0}, Max: Vector{X: 6.0, Y: 1.0, Z: 1.0}},
+	}
+
+	result := scene.Statistics()
+
+	if result != expected {
+		t.Errorf("Statistics() must return %v, actual is %v", expected, result)
+	}
+}
+
+func TestSceneStatisticsTriangleCount(t *testing.T) {
+	mesh := CreateMesh("mesh", []*Triangle{
+		{Vertices: [3]Vector{{X: 0.0, Y: 0.0, Z: 0.0}, {X: 1.0, Y: 0.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 0.0}}},
+		{Vertices: [3]Vector{{X: 0.0, Y: 0.0, Z: 0.0}, {X: 0.0, Y: 1.0, Z: 0.0}, {X: 0.0, Y: 0.0, Z: -4.0}}},
+	}, CreateDefaultMaterial())
+	translation := transform.CreateTranslation(Vector{X: 1.0, Y: 0.0, Z: 0.0})
+
+	patterns := []struct {
+		name     string
+		shape    Shape
+		expected int
+	}{
+		{name: "transformed mesh", shape: &TransformedShape{Shape: mesh, Transform: translation}, expected: 2},
+		{
+			name: "animated mesh",
+			shape: &AnimatedShape{
+				Shape:  mesh,
+				Motion: transform.CreateAnimatedTransform(transform.CreateIdentityTransform(), 0.0, translation, 1.0),
+			},
+			expected: 2,
+		},
+		{
+			name:     "moving transformed mesh",
+			shape:    &LinearMotionShape{Shape: &TransformedShape{Shape: mesh, Transform: translation}, EndTime: 1.0},
+			expected: 2,
+		},
+		{name: "transformed sphere", shape: &TransformedShape{Shape: &Sphere{Radius: 1.0}, Transform: translation}, expected: 0},
+	}
+
+	for _, pattern := range patterns {
+		scene := Scene{Shapes: []Shape{pattern.shape}}
+
+		result := scene.Statistics()
+
+		if result.TriangleCount != pattern.expected {
+			t.Errorf("Statistics() of %s must count %d triangles, actual is %d", pattern.name, pattern.expected, result.TriangleCount)
+		}
+	}
+}
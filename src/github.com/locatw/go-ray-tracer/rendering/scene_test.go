@@ -77,4 +77,69 @@ func TestLookForIntersectedObject(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("When a scene has bounded and unbounded shapes", func(t *testing.T) {
+		nearSphere := &Sphere{Center: Vector{X: 0.0, Y: 0.0, Z: 5.0}, Radius: 1.0}
+		farSphere := &Sphere{Center: Vector{X: 0.0, Y: 0.0, Z: 10.0}, Radius: 1.0}
+		plane := &Plane{Center: Vector{X: 0.0, Y: 0.0, Z: 7.0}, Normal: Multiply(-1.0, CreateAxisVector(ZAxis))}
+		sideRay := CreateRay(CreateZeroVector(), Vector{X: 0.0, Y: 1.0, Z: 1.0})
+
+		scene := Scene{Camera: camera, Shapes: []Shape{farSphere, plane, nearSphere}}
+
+		t.Run("it returns the nearest bounded object", func(t *testing.T) {
+			hitInfo := scene.LookForIntersectedObject(ray)
+
+			if hitInfo == nil || hitInfo.Object != nearSphere {
+				t.Errorf("got: %v, wont: %v", hitInfo, nearSphere)
+			}
+		})
+
+		t.Run("it returns the unbounded object if it is the nearest", func(t *testing.T) {
+			hitInfo := scene.LookForIntersectedObject(sideRay)
+
+			if hitInfo == nil || hitInfo.Object != plane {
+				t.Errorf("got: %v, wont: %v", hitInfo, plane)
+			}
+		})
+
+		t.Run("it returns a shape which is added after a lookup", func(t *testing.T) {
+			scene := Scene{Camera: camera, Shapes: []Shape{farSphere}}
+			scene.LookForIntersectedObject(ray)
+
+			scene.Shapes = append(scene.Shapes, nearSphere)
+			hitInfo := scene.LookForIntersectedObject(ray)
+
+			if hitInfo == nil || hitInfo.Object != nearSphere {
+				t.Errorf("got: %v, wont: %v", hitInfo, nearSphere)
+			}
+		})
+	})
+}
+
+func TestIsUnoccluded(t *testing.T) {
+	sphere := &Sphere{Center: Vector{X: 0.0, Y: 0.0, Z: 5.0}, Radius: 1.0}
+	plane := &Plane{Center: Vector{X: 0.0, Y: 0.0, Z: 7.0}, Normal: Multiply(-1.0, CreateAxisVector(ZAxis))}
+	scene := Scene{Shapes: []Shape{sphere, plane}}
+	ray := CreateRay(CreateZeroVector(), CreateAxisVector(ZAxis))
+	sideRay := CreateRay(Vector{X: 3.0, Y: 0.0, Z: 0.0}, CreateAxisVector(ZAxis))
+
+	patterns := []struct {
+		ray      Ray
+		distance float64
+		expected bool
+	}{
+		{ray: ray, distance: 3.0, expected: true},
+		{ray: ray, distance: 4.0, expected: true},
+		{ray: ray, distance: 4.5, expected: false},
+		{ray: sideRay, distance: 6.5, expected: true},
+		{ray: sideRay, distance: 7.5, expected: false},
+	}
+
+	for _, pattern := range patterns {
+		actual := scene.IsUnoccluded(pattern.ray, pattern.distance)
+
+		if actual != pattern.expected {
+			t.Errorf("IsUnoccluded must return %v for distance %v, actual %v", pattern.expected, pattern.distance, actual)
+		}
+	}
 }
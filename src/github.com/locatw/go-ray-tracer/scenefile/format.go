@@ -0,0 +1,281 @@
+package scenefile
+
+// Scene description stored as JSON. Vectors and colors are arrays of three numbers,
+// and angles are in degrees. Optional fields are pointers so that missing values can be detected.
+type SceneDescription struct {
+	Camera    *CameraDescription             `json:"camera"`
+	Materials map[string]MaterialDescription `json:"materials"`
+	Textures  map[string]TextureDescription  `json:"textures"`
+	Shapes    []ShapeDescription             `json:"shapes"`
+	// Shapes which have emission and can be sampled become area lights without being listed here.
+	Lights   []LightDescription   `json:"lights"`
+	Settings *SettingsDescription `json:"settings"`
+}
+
+type Vector3 [3]float64
+
+type CameraDescription struct {
+	Origin    *Vector3 `json:"origin"`
+	Direction *Vector3 `json:"direction"`
+	Up        *Vector3 `json:"up"`
+	// Vertical field of view of perspective, and the diameter of the image circle of fisheye.
+	Fov *float64 `json:"fov"`
+
+	// One of projection names, which defaults to perspective.
+	Projection string `json:"projection"`
+	// orthographic, height of the region seen by the camera.
+	Height *float64 `json:"height"`
+	// equirectangular, whose stereo image has the left eye on the top half and the right eye on the bottom half.
+	Stereo        bool     `json:"stereo"`
+	EyeSeparation *float64 `json:"eyeSeparation"`
+
+	// Interval of time where rays are sampled for motion blur. shutterOpen defaults to 0, and shutterClose
+	// defaults to shutterOpen, which makes the shutter instantaneous. Moving shapes move in the interval.
+	ShutterOpen  *float64 `json:"shutterOpen"`
+	ShutterClose *float64 `json:"shutterClose"`
+	// Transform operations applied to the camera at shutterClose, which moves from the place at shutterOpen.
+	EndTransform []TransformDescription `json:"endTransform"`
+
+	// Thin lens for depth of field, which is a pinhole if apertureRadius is omitted or 0.
+	// focusDistance is required with a positive apertureRadius. apertureBlades of 3 or more make the
+	// aperture a polygon, and anamorphicRatio, the ratio of its width to its height, defaults to 1.
+	ApertureRadius  *float64 `json:"apertureRadius"`
+	FocusDistance   *float64 `json:"focusDistance"`
+	ApertureBlades  *int     `json:"apertureBlades"`
+	AnamorphicRatio *float64 `json:"anamorphicRatio"`
+}
+
+const (
+	// Material of diffuse, specular and indexOfRefraction, which is used if type is omitted.
+	LegacyMaterialType     = ""
+	ConductorMaterialType  = "conductor"
+	DielectricMaterialType = "dielectric"
+	PrincipledMaterialType = "principled"
+)
+
+type MaterialDescription struct {
+	Type     string   `json:"type"`
+	Emission *Vector3 `json:"emission"`
+
+	// legacy, which also uses IndexOfRefraction
+	Diffuse  *Vector3 `json:"diffuse"`
+	Specular *Vector3 `json:"specular"`
+
+	// conductor and dielectric, where principled also uses Roughness
+	IndexOfRefraction *float64 `json:"indexOfRefraction"`
+	// Perceptual roughness in [0, 1] along the tangent.
+	Roughness *float64 `json:"roughness"`
+	// Roughness along the bitangent for anisotropic materials. Defaults to roughness.
+	RoughnessV *float64 `json:"roughnessV"`
+
+	// conductor, which is either a name of element.ConductorPresets or a complex index of refraction
+	Conductor string   `json:"conductor"`
+	Eta       *Vector3 `json:"eta"`
+	K         *Vector3 `json:"k"`
+
+	// principled, whose omitted fields are of element.CreateDefaultPrincipledParameters.
+	// Values other than baseColor are in [0, 1].
+	BaseColor *Vector3 `json:"baseColor"`
+	Metallic  *float64 `json:"metallic"`
+	// Specular of the principled BSDF, which is named to differ from the legacy specular color.
+	SpecularLevel  *float64 `json:"specularLevel"`
+	SpecularTint   *float64 `json:"specularTint"`
+	Sheen          *float64 `json:"sheen"`
+	SheenTint      *float64 `json:"sheenTint"`
+	Clearcoat      *float64 `json:"clearcoat"`
+	ClearcoatGloss *float64 `json:"clearcoatGloss"`
+	Transmission   *float64 `json:"transmission"`
+	Anisotropic    *float64 `json:"anisotropic"`
+
+	// Names of textures in SceneDescription.Textures keyed by the parameter which they override.
+	// Scalar parameters are read from the red channel. All materials accept normal and bump,
+	// which perturb the shading normal.
+	Textures map[string]string `json:"textures"`
+	// Height of the surface where the value of the bump texture is 1. Defaults to 1.
+	BumpScale *float64 `json:"bumpScale"`
+}
+
+// Parameters of materials which textures can override, keyed by material types.
+var TextureParameters = map[string][]string{
+	LegacyMaterialType:     {"diffuse", "specular", "normal", "bump"},
+	ConductorMaterialType:  {"roughness", "normal", "bump"},
+	DielectricMaterialType: {"roughness", "normal", "bump"},
+	PrincipledMaterialType: {"baseColor", "metallic", "roughness", "normal", "bump"},
+}
+
+// Parameters whose textures are data rather than colors. Image textures bound to them are read as
+// non-color unless colorSpace is set, since image files such as PNG are tagged as sRGB.
+var DataTextureParameters = []string{"metallic", "roughness", "normal", "bump"}
+
+const (
+	ConstantTextureType     = "constant"
+	ImageTextureType        = "image"
+	CheckerboardTextureType = "checkerboard"
+	NoiseTextureType        = "noise"
+)
+
+const (
+	PerspectiveProjectionName     = "perspective"
+	OrthographicProjectionName    = "orthographic"
+	FisheyeProjectionName         = "fisheye"
+	EquirectangularProjectionName = "equirectangular"
+)
+
+const (
+	RepeatWrapName = "repeat"
+	ClampWrapName  = "clamp"
+)
+
+const (
+	UVMappingName       = "uv"
+	PositionMappingName = "position"
+)
+
+type TextureDescription struct {
+	Type string `json:"type"`
+
+	// constant
+	Color *Vector3 `json:"color"`
+
+	// image, which is relative to the scene file
+	File string `json:"file"`
+	// Either repeat or clamp. Defaults to repeat.
+	Wrap string `json:"wrap"`
+	// Overrides the color space declared by the image file. Images bound to DataTextureParameters
+	// default to non-color.
+	ColorSpace string `json:"colorSpace"`
+
+	// checkerboard and noise, which default to black and white
+	Colors []Vector3 `json:"colors"`
+	// Defaults to 1.
+	Frequency *float64 `json:"frequency"`
+	// Either uv or position. Defaults to uv.
+	Mapping string `json:"mapping"`
+
+	// noise. Defaults to DefaultNoiseOctaves.
+	Octaves *int `json:"octaves"`
+}
+
+const DefaultNoiseOctaves = 4
+
+const (
+	SphereShapeType   = "sphere"
+	PlaneShapeType    = "plane"
+	TriangleShapeType = "triangle"
+	ObjShapeType      = "obj"
+)
+
+type ShapeDescription struct {
+	Type string `json:"type"`
+	// Name of a material in SceneDescription.Materials.
+	// It is optional for obj shapes, which use materials of MTL files if omitted.
+	Material string `json:"material"`
+
+	// sphere
+	Center *Vector3 `json:"center"`
+	Radius *float64 `json:"radius"`
+
+	// plane, which also uses Center
+	Normal *Vector3 `json:"normal"`
+
+	// triangle
+	Vertices []Vector3 `json:"vertices"`
+
+	// obj, which is relative to the scene file
+	File string `json:"file"`
+
+	// Transform operations applied in order.
+	Transform []TransformDescription `json:"transform"`
+
+	// Motion during the shutter interval of the camera. The shape moves from Transform at shutterOpen to
+	// EndTransform at shutterClose, or moves linearly by Velocity per unit time from shutterOpen.
+	EndTransform []TransformDescription `json:"endTransform"`
+	Velocity     *Vector3               `json:"velocity"`
+}
+
+// Transform operation. Exactly one of fields must be set.
+type TransformDescription struct {
+	Translate *Vector3             `json:"translate"`
+	Scale     *Vector3             `json:"scale"`
+	Rotate    *RotationDescription `json:"rotate"`
+}
+
+type RotationDescription struct {
+	Axis  *Vector3 `json:"axis"`
+	Angle *float64 `json:"angle"`
+}
+
+const (
+	PointLightType       = "point"
+	SpotLightType        = "spot"
+	DirectionalLightType = "directional"
+	EnvironmentLightType = "environment"
+	SkyLightType         = "sky"
+)
+
+type LightDescription struct {
+	Type string `json:"type"`
+
+	// point and spot
+	Position *Vector3 `json:"position"`
+	// point, spot, environment and sky
+	Intensity *Vector3 `json:"intensity"`
+
+	// spot and directional
+	Direction *Vector3 `json:"direction"`
+
+	// spot, half angles of the cone and the cone where falloff starts.
+	// FalloffAngle defaults to Angle, which makes a hard edge.
+	Angle        *float64 `json:"angle"`
+	FalloffAngle *float64 `json:"falloffAngle"`
+
+	// directional
+	Irradiance *Vector3 `json:"irradiance"`
+
+	// environment, an equirectangular image such as .hdr and .pfm relative to the scene file.
+	// The center of the image is seen toward -Z before rotation.
+	File string `json:"file"`
+	// Angle to rotate the image around the Y axis, counterclockwise seen from above.
+	// The radiance of the image is scaled by Intensity, which defaults to [1, 1, 1].
+	Rotation *float64 `json:"rotation"`
+
+	// sky, the Preetham sky and the sun disk in kcd/m^2, which are scaled by Intensity.
+	// SunDirection points toward the sun above the horizon. Turbidity is in range [2, 10].
+	SunDirection *Vector3 `json:"sunDirection"`
+	Turbidity    *float64 `json:"turbidity"`
+	GroundAlbedo *Vector3 `json:"groundAlbedo"`
+}
+
+const (
+	DefaultTurbidity    = 3.0
+	DefaultGroundAlbedo = 0.3
+)
+
+type SettingsDescription struct {
+	Width                      *int                    `json:"width"`
+	Height                     *int                    `json:"height"`
+	SamplingCount              *int                    `json:"samplingCount"`
+	TraceRecursionLimit        *int                    `json:"traceRecursionLimit"`
+	DistanceAttenuationEnabled *bool                   `json:"distanceAttenuationEnabled"`
+	ToneMapping                *ToneMappingDescription `json:"toneMapping"`
+	// One of rendering.MisHeuristicNames. Defaults to power.
+	MisHeuristic *string `json:"misHeuristic"`
+}
+
+type ToneMappingDescription struct {
+	// One of tonemap.OperatorNames. Defaults to linear.
+	Operator *string `json:"operator"`
+	// Exposure compensation in EV.
+	Exposure   *float64 `json:"exposure"`
+	WhitePoint *float64 `json:"whitePoint"`
+}
+
+// Values of settings which are omitted. Distance attenuation is not physical,
+// so scenes tuned with it have to enable it.
+const (
+	DefaultWidth                      = 640
+	DefaultHeight                     = 640
+	DefaultSamplingCount              = 1000
+	DefaultTraceRecursionLimit        = 10
+	DefaultDistanceAttenuationEnabled = false
+)
@@ -0,0 +1,653 @@
+package scenefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	. "github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/imagefile"
+	"github.com/locatw/go-ray-tracer/image/tonemap"
+	mathex "github.com/locatw/go-ray-tracer/math"
+	. "github.com/locatw/go-ray-tracer/rendering"
+	"github.com/locatw/go-ray-tracer/transform"
+	. "github.com/locatw/go-ray-tracer/vector"
+	"github.com/locatw/go-ray-tracer/wavefront"
+)
+
+// Read, validate and build a scene file.
+func Load(path string) (Scene, RenderingSetting, error) {
+	description, err := Read(path)
+	if err != nil {
+		return Scene{}, RenderingSetting{}, err
+	}
+
+	if errs := Validate(description); errs != nil {
+		return Scene{}, RenderingSetting{}, errs
+	}
+
+	return Build(description, filepath.Dir(path))
+}
+
+// Read a scene file without validation. Unknown fields are reported as errors.
+func Read(path string) (*SceneDescription, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(data, path)
+}
+
+func parse(data []byte, path string) (*SceneDescription, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	description := &SceneDescription{}
+	if err := decoder.Decode(description); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line, column := lineAndColumn(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, line, column, err)
+		}
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			line, column := lineAndColumn(data, typeErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, line, column, err)
+		}
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return description, nil
+}
+
+// Return the position of the last byte read by the JSON decoder, which has read offset bytes.
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	line := 1
+	column := 1
+
+	for i := int64(0); i < offset-1 && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
+
+type builder struct {
+	description *SceneDescription
+	baseDir     string
+	textures    map[string]Texture
+	materials   map[string]Material
+	// meshes loaded from OBJ files, keyed by file and material so that instances share them.
+	meshes map[objKey][]*Mesh
+}
+
+type objKey struct {
+	file     string
+	material string
+}
+
+// Build a scene and rendering setting from a valid scene description.
+// Relative paths in the description are resolved from baseDir.
+func Build(description *SceneDescription, baseDir string) (Scene, RenderingSetting, error) {
+	b := builder{
+		description: description,
+		baseDir:     baseDir,
+		textures:    make(map[string]Texture),
+		materials:   make(map[string]Material),
+		meshes:      make(map[objKey][]*Mesh),
+	}
+
+	_, dataTextures := textureUsage(description.Materials)
+	for name, texture := range description.Textures {
+		built, err := b.buildTexture(&texture, dataTextures[name])
+		if err != nil {
+			return Scene{}, RenderingSetting{}, fmt.Errorf("textures.%s: %s", name, err)
+		}
+
+		b.textures[name] = built
+	}
+
+	for name, material := range description.Materials {
+		b.materials[name] = b.buildMaterial(material)
+	}
+
+	shapes := make([]Shape, 0, len(description.Shapes))
+	for i, shape := range description.Shapes {
+		built, err := b.buildShape(&shape)
+		if err != nil {
+			return Scene{}, RenderingSetting{}, fmt.Errorf("shapes[%d]: %s", i, err)
+		}
+
+		shapes = append(shapes, built...)
+	}
+
+	lights := make([]Light, 0, len(description.Lights))
+	for i, light := range description.Lights {
+		built, err := b.buildLight(&light)
+		if err != nil {
+			return Scene{}, RenderingSetting{}, fmt.Errorf("lights[%d]: %s", i, err)
+		}
+
+		lights = append(lights, built...)
+	}
+	lights = append(lights, CreateAreaLights(shapes)...)
+
+	scene := Scene{
+		Camera: buildCamera(description.Camera),
+		Shapes: shapes,
+		Lights: lights,
+	}
+
+	setting, err := buildSetting(description.Settings)
+	if err != nil {
+		return Scene{}, RenderingSetting{}, fmt.Errorf("settings: %s", err)
+	}
+
+	return scene, setting, nil
+}
+
+func buildCamera(camera *CameraDescription) Camera {
+	fov := 0.0
+	if camera.Fov != nil {
+		fov = mathex.ToRadian(*camera.Fov)
+	}
+
+	built := CreateCamera(
+		camera.Origin.toVector(),
+		camera.Direction.toVector(),
+		camera.Up.toVector(),
+		fov)
+
+	switch camera.Projection {
+	case OrthographicProjectionName:
+		built.Projection = OrthographicProjection{Height: *camera.Height}
+	case FisheyeProjectionName:
+		built.Projection = FisheyeProjection{}
+	case EquirectangularProjectionName:
+		projection := EquirectangularProjection{Stereo: camera.Stereo}
+		if camera.Stereo {
+			projection.EyeSeparation = *camera.EyeSeparation
+		}
+		built.Projection = projection
+	}
+
+	built.ShutterOpen, built.ShutterClose = shutterInterval(camera)
+	if len(camera.EndTransform) != 0 {
+		motion := transform.CreateAnimatedTransform(
+			transform.CreateIdentityTransform(), built.ShutterOpen, buildTransform(camera.EndTransform), built.ShutterClose)
+		built.Motion = &motion
+	}
+
+	if camera.ApertureRadius != nil && 0.0 < *camera.ApertureRadius {
+		built.Lens = CreateThinLens(*camera.ApertureRadius, *camera.FocusDistance)
+
+		if camera.ApertureBlades != nil {
+			built.Lens.ApertureBlades = *camera.ApertureBlades
+		}
+		if camera.AnamorphicRatio != nil {
+			built.Lens.AnamorphicRatio = *camera.AnamorphicRatio
+		}
+	}
+
+	return built
+}
+
+func (b *builder) buildLight(description *LightDescription) ([]Light, error) {
+	switch description.Type {
+	case PointLightType:
+		return []Light{&PointLight{Position: description.Position.toVector(), Intensity: description.Intensity.toColor()}}, nil
+	case SpotLightType:
+		angle := mathex.ToRadian(*description.Angle)
+		falloffAngle := angle
+		if description.FalloffAngle != nil {
+			falloffAngle = mathex.ToRadian(*description.FalloffAngle)
+		}
+
+		return []Light{CreateSpotLight(
+			description.Position.toVector(),
+			description.Direction.toVector(),
+			description.Intensity.toColor(),
+			angle,
+			falloffAngle)}, nil
+	case DirectionalLightType:
+		return []Light{&DirectionalLight{
+			Direction:  Normalize(description.Direction.toVector()),
+			Irradiance: description.Irradiance.toColor(),
+		}}, nil
+	case EnvironmentLightType:
+		img, err := imagefile.Read(b.resolvePath(description.File))
+		if err != nil {
+			return nil, err
+		}
+
+		rotation := 0.0
+		if description.Rotation != nil {
+			rotation = mathex.ToRadian(*description.Rotation)
+		}
+
+		return []Light{CreateEnvironmentLight(img, rotation, buildLightIntensity(description))}, nil
+	case SkyLightType:
+		turbidity := DefaultTurbidity
+		if description.Turbidity != nil {
+			turbidity = *description.Turbidity
+		}
+
+		groundAlbedo := Color{R: DefaultGroundAlbedo, G: DefaultGroundAlbedo, B: DefaultGroundAlbedo}
+		if description.GroundAlbedo != nil {
+			groundAlbedo = description.GroundAlbedo.toColor()
+		}
+
+		return CreateDaylight(description.SunDirection.toVector(), turbidity, groundAlbedo, buildLightIntensity(description)), nil
+	default:
+		return nil, fmt.Errorf("unknown light type %q", description.Type)
+	}
+}
+
+// Intensity of environment and sky lights, which scales their radiance.
+func buildLightIntensity(description *LightDescription) Color {
+	if description.Intensity == nil {
+		return CreateDefaultColor(White)
+	}
+
+	return description.Intensity.toColor()
+}
+
+func (b *builder) buildMaterial(description MaterialDescription) Material {
+	material := CreateDefaultMaterial()
+
+	if description.Emission != nil {
+		material.Emission = description.Emission.toColor()
+	}
+	if len(description.Textures) != 0 {
+		material.Textures = b.buildMaterialTextures(description.Textures)
+
+		material.Textures.BumpScale = 1.0
+		if description.BumpScale != nil {
+			material.Textures.BumpScale = *description.BumpScale
+		}
+	}
+	if description.Type != LegacyMaterialType {
+		material.BSDF = buildBSDF(description)
+		return material
+	}
+
+	if description.Diffuse != nil {
+		material.Diffuse = description.Diffuse.toColor()
+	}
+	if description.Specular != nil {
+		material.Specular = description.Specular.toColor()
+	}
+	if description.IndexOfRefraction != nil {
+		ior := *description.IndexOfRefraction
+		material.IndexOfRefraction = &ior
+	}
+
+	return material
+}
+
+func (b *builder) buildMaterialTextures(names map[string]string) *MaterialTextures {
+	textures := &MaterialTextures{}
+
+	targets := map[string]*Texture{
+		"diffuse":   &textures.Diffuse,
+		"specular":  &textures.Specular,
+		"baseColor": &textures.BaseColor,
+		"metallic":  &textures.Metallic,
+		"roughness": &textures.Roughness,
+		"normal":    &textures.Normal,
+		"bump":      &textures.Bump,
+	}
+
+	for parameter, name := range names {
+		*targets[parameter] = b.textures[name]
+	}
+
+	return textures
+}
+
+// Build a texture. isData tells that the texture is bound to DataTextureParameters.
+func (b *builder) buildTexture(description *TextureDescription, isData bool) (Texture, error) {
+	switch description.Type {
+	case ConstantTextureType:
+		return &ConstantTexture{Color: description.Color.toColor()}, nil
+	case ImageTextureType:
+		return b.loadImageTexture(description, isData)
+	case CheckerboardTextureType, NoiseTextureType:
+		color1 := CreateDefaultColor(Black)
+		color2 := CreateDefaultColor(White)
+		if description.Colors != nil {
+			color1 = description.Colors[0].toColor()
+			color2 = description.Colors[1].toColor()
+		}
+
+		frequency := 1.0
+		if description.Frequency != nil {
+			frequency = *description.Frequency
+		}
+
+		mapping := UVMapping
+		if description.Mapping == PositionMappingName {
+			mapping = PositionMapping
+		}
+
+		if description.Type == CheckerboardTextureType {
+			return &CheckerboardTexture{Color1: color1, Color2: color2, Frequency: frequency, Mapping: mapping}, nil
+		}
+
+		octaves := DefaultNoiseOctaves
+		if description.Octaves != nil {
+			octaves = *description.Octaves
+		}
+
+		return &NoiseTexture{Color1: color1, Color2: color2, Frequency: frequency, Octaves: octaves, Mapping: mapping}, nil
+	default:
+		return nil, fmt.Errorf("unknown texture type %q", description.Type)
+	}
+}
+
+func (b *builder) loadImageTexture(description *TextureDescription, isData bool) (Texture, error) {
+	img, err := imagefile.Read(b.resolvePath(description.File))
+	if err != nil {
+		return nil, err
+	}
+
+	if description.ColorSpace != "" {
+		colorSpace, err := ParseColorSpace(description.ColorSpace)
+		if err != nil {
+			return nil, err
+		}
+		img.ColorSpace = colorSpace
+	} else if isData {
+		img.ColorSpace = NonColor
+	}
+
+	wrap := RepeatWrap
+	if description.Wrap == ClampWrapName {
+		wrap = ClampWrap
+	}
+
+	return CreateImageTexture(img, wrap), nil
+}
+
+func buildBSDF(description MaterialDescription) BSDF {
+	if description.Type == PrincipledMaterialType {
+		return buildPrincipledBSDF(description)
+	}
+
+	roughnessU := *description.Roughness
+	roughnessV := roughnessU
+	if description.RoughnessV != nil {
+		roughnessV = *description.RoughnessV
+	}
+	distribution := CreateGGXDistribution(roughnessU, roughnessV)
+
+	if description.Type == DielectricMaterialType {
+		return &RoughDielectricBSDF{Distribution: distribution, IndexOfRefraction: *description.IndexOfRefraction}
+	}
+
+	if description.Conductor != "" {
+		ior := ConductorPresets[description.Conductor]
+		return &RoughConductorBSDF{Distribution: distribution, Eta: ior.Eta, K: ior.K}
+	}
+
+	return &RoughConductorBSDF{Distribution: distribution, Eta: description.Eta.toColor(), K: description.K.toColor()}
+}
+
+func buildPrincipledBSDF(description MaterialDescription) BSDF {
+	parameters := CreateDefaultPrincipledParameters()
+
+	if description.BaseColor != nil {
+		parameters.BaseColor = description.BaseColor.toColor()
+	}
+
+	fields := []struct {
+		value  *float64
+		target *float64
+	}{
+		{value: description.Metallic, target: &parameters.Metallic},
+		{value: description.Roughness, target: &parameters.Roughness},
+		{value: description.SpecularLevel, target: &parameters.Specular},
+		{value: description.SpecularTint, target: &parameters.SpecularTint},
+		{value: description.Sheen, target: &parameters.Sheen},
+		{value: description.SheenTint, target: &parameters.SheenTint},
+		{value: description.Clearcoat, target: &parameters.Clearcoat},
+		{value: description.ClearcoatGloss, target: &parameters.ClearcoatGloss},
+		{value: description.Transmission, target: &parameters.Transmission},
+		{value: description.Anisotropic, target: &parameters.Anisotropic},
+	}
+
+	for _, field := range fields {
+		if field.value != nil {
+			*field.target = *field.value
+		}
+	}
+
+	return CreatePrincipledBSDF(parameters)
+}
+
+func (b *builder) buildShape(description *ShapeDescription) ([]Shape, error) {
+	material := b.materials[description.Material]
+
+	var shapes []Shape
+	switch description.Type {
+	case SphereShapeType:
+		shapes = []Shape{&Sphere{
+			Center:   description.Center.toVector(),
+			Radius:   *description.Radius,
+			Material: material,
+		}}
+	case PlaneShapeType:
+		shapes = []Shape{&Plane{
+			Center:   description.Center.toVector(),
+			Normal:   Normalize(description.Normal.toVector()),
+			Material: material,
+		}}
+	case TriangleShapeType:
+		shapes = []Shape{&Triangle{
+			Vertices: [3]Vector{
+				description.Vertices[0].toVector(),
+				description.Vertices[1].toVector(),
+				description.Vertices[2].toVector(),
+			},
+			Material: material,
+		}}
+	case ObjShapeType:
+		meshes, err := b.loadObj(description.File, description.Material)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mesh := range meshes {
+			shapes = append(shapes, mesh)
+		}
+	default:
+		return nil, fmt.Errorf("unknown shape type %q", description.Type)
+	}
+
+	shutterOpen, shutterClose := shutterInterval(b.description.Camera)
+
+	switch {
+	case len(description.EndTransform) != 0:
+		motion := transform.CreateAnimatedTransform(
+			buildTransform(description.Transform), shutterOpen, buildTransform(description.EndTransform), shutterClose)
+		for i, shape := range shapes {
+			shapes[i] = &AnimatedShape{Shape: shape, Motion: motion}
+		}
+	case len(description.Transform) != 0:
+		t := buildTransform(description.Transform)
+		for i, shape := range shapes {
+			shapes[i] = &TransformedShape{Shape: shape, Transform: t}
+		}
+	}
+
+	if description.Velocity != nil {
+		for i, shape := range shapes {
+			shapes[i] = &LinearMotionShape{Shape: shape, Velocity: description.Velocity.toVector(), StartTime: shutterOpen, EndTime: shutterClose}
+		}
+	}
+
+	return shapes, nil
+}
+
+// Return the shutter interval of a camera. The shutter is instantaneous at 0 if it is omitted.
+func shutterInterval(camera *CameraDescription) (float64, float64) {
+	shutterOpen := 0.0
+	if camera.ShutterOpen != nil {
+		shutterOpen = *camera.ShutterOpen
+	}
+
+	shutterClose := shutterOpen
+	if camera.ShutterClose != nil {
+		shutterClose = *camera.ShutterClose
+	}
+
+	return shutterOpen, shutterClose
+}
+
+// Return names of textures which materials bind to color parameters and to DataTextureParameters.
+func textureUsage(materials map[string]MaterialDescription) (map[string]bool, map[string]bool) {
+	color := make(map[string]bool)
+	data := make(map[string]bool)
+
+	for _, material := range materials {
+		for parameter, name := range material.Textures {
+			if containsString(DataTextureParameters, parameter) {
+				data[name] = true
+			} else {
+				color[name] = true
+			}
+		}
+	}
+
+	return color, data
+}
+
+// Resolve a path relative to the scene file.
+func (b *builder) resolvePath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(b.baseDir, file)
+}
+
+func (b *builder) loadObj(file string, materialName string) ([]*Mesh, error) {
+	path := b.resolvePath(file)
+
+	key := objKey{file: path, material: materialName}
+	if meshes, ok := b.meshes[key]; ok {
+		return meshes, nil
+	}
+
+	meshes, err := wavefront.LoadObj(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if materialName != "" {
+		for i, mesh := range meshes {
+			meshes[i] = CreateMesh(mesh.Name, mesh.Triangles, b.materials[materialName])
+		}
+	}
+
+	b.meshes[key] = meshes
+
+	return meshes, nil
+}
+
+func buildTransform(operations []TransformDescription) transform.Transform {
+	transforms := make([]transform.Transform, len(operations))
+
+	for i, operation := range operations {
+		switch {
+		case operation.Translate != nil:
+			transforms[i] = transform.CreateTranslation(operation.Translate.toVector())
+		case operation.Scale != nil:
+			transforms[i] = transform.CreateScaling(operation.Scale.toVector())
+		case operation.Rotate != nil:
+			transforms[i] = transform.CreateRotation(
+				operation.Rotate.Axis.toVector(), mathex.ToRadian(*operation.Rotate.Angle))
+		}
+	}
+
+	return transform.Compose(transforms...)
+}
+
+func buildSetting(settings *SettingsDescription) (RenderingSetting, error) {
+	setting := RenderingSetting{
+		Resolution:                 Resolution{Width: DefaultWidth, Height: DefaultHeight},
+		SamplingCount:              DefaultSamplingCount,
+		TraceRecursionLimit:        DefaultTraceRecursionLimit,
+		DistanceAttenuationEnabled: DefaultDistanceAttenuationEnabled,
+		ToneMapping:                tonemap.CreateDefaultSetting(),
+	}
+
+	if settings == nil {
+		return setting, nil
+	}
+
+	if settings.Width != nil {
+		setting.Resolution.Width = *settings.Width
+	}
+	if settings.Height != nil {
+		setting.Resolution.Height = *settings.Height
+	}
+	if settings.SamplingCount != nil {
+		setting.SamplingCount = *settings.SamplingCount
+	}
+	if settings.TraceRecursionLimit != nil {
+		setting.TraceRecursionLimit = *settings.TraceRecursionLimit
+	}
+	if settings.DistanceAttenuationEnabled != nil {
+		setting.DistanceAttenuationEnabled = *settings.DistanceAttenuationEnabled
+	}
+
+	if settings.MisHeuristic != nil {
+		heuristic, err := ParseMisHeuristic(*settings.MisHeuristic)
+		if err != nil {
+			return RenderingSetting{}, err
+		}
+		setting.MisHeuristic = heuristic
+	}
+
+	if toneMapping := settings.ToneMapping; toneMapping != nil {
+		if toneMapping.Exposure != nil {
+			setting.ToneMapping.Exposure = *toneMapping.Exposure
+		}
+
+		if toneMapping.Operator != nil || toneMapping.WhitePoint != nil {
+			name := tonemap.LinearName
+			if toneMapping.Operator != nil {
+				name = *toneMapping.Operator
+			}
+
+			whitePoint := 0.0
+			if toneMapping.WhitePoint != nil {
+				whitePoint = *toneMapping.WhitePoint
+			}
+
+			operator, err := tonemap.CreateOperator(name, whitePoint)
+			if err != nil {
+				return RenderingSetting{}, err
+			}
+			setting.ToneMapping.Operator = operator
+		}
+	}
+
+	return setting, nil
+}
+
+func (vector Vector3) toVector() Vector {
+	return Vector{X: vector[0], Y: vector[1], Z: vector[2]}
+}
+
+func (vector Vector3) toColor() Color {
+	return Color{R: float32(vector[0]), G: float32(vector[1]), B: float32(vector[2])}
+}
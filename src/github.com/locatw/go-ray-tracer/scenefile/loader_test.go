@@ -0,0 +1,444 @@
+package scenefile
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	"github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/imagefile"
+	"github.com/locatw/go-ray-tracer/image/tonemap"
+	"github.com/locatw/go-ray-tracer/rendering"
+	"github.com/locatw/go-ray-tracer/vector"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "scenefile")
+	if err != nil {
+		t.Fatalf("cannot create temp directory for test")
+	}
+
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	writeFile("triangle.obj", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n")
+
+	roughness := image.CreateImage(2, 2)
+	if err := imagefile.Write(filepath.Join(dir, "roughness.pfm"), roughness, imagefile.CreateDefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.5 stored as it is, which is read as sRGB by default
+	data := image.CreateImage(2, 2)
+	data.ColorSpace = image.NonColor
+	for i := range data.Pixels {
+		data.Pixels[i].Color = image.Color{R: 0.5, G: 0.5, B: 1.0}
+	}
+	if err := imagefile.Write(filepath.Join(dir, "data.png"), data, imagefile.CreateDefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("When a scene file is valid", func(t *testing.T) {
+		path := writeFile("valid.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"materials": {"light": {"emission": [1, 1, 1]}, "glass": {"specular": [1, 1, 1], "indexOfRefraction": 1.5},
+				"gold": {"type": "conductor", "conductor": "gold", "roughness": 0.5, "roughnessV": 0.1},
+				"paint": {"type": "principled", "baseColor": [0.8, 0.1, 0.1], "clearcoat": 1, "specularLevel": 0.3,
+					"textures": {"baseColor": "checker", "roughness": "roughness", "bump": "checker"}, "bumpScale": 0.5}},
+			"textures": {
+				"checker": {"type": "checkerboard", "colors": [[1, 0, 0], [0, 0, 1]], "frequency": 4, "mapping": "position"},
+				"roughness": {"type": "image", "file": "roughness.pfm", "wrap": "clamp", "colorSpace": "non-color"}
+			},
+			"shapes": [
+				{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "light"},
+				{"type": "obj", "file": "triangle.obj", "material": "glass", "transform": [{"translate": [1, 0, 0]}]},
+				{"type": "obj", "file": "triangle.obj", "material": "glass", "transform": [{"translate": [-1, 0, 0]}]},
+				{"type": "plane", "center": [0, -2, 0], "normal": [0, 1, 0], "material": "gold"},
+				{"type": "sphere", "center": [0, 5, 0], "radius": 1, "material": "paint"}
+			],
+			"lights": [{"type": "directional", "direction": [0, -1, 0], "irradiance": [1, 1, 1]}],
+			"settings": {"width": 32, "samplingCount": 4, "toneMapping": {"operator": "hable", "exposure": 2, "whitePoint": 4}, "misHeuristic": "balance"}
+		}`)
+
+		scene, setting, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds shapes", func(t *testing.T) {
+			if len(scene.Shapes) != 5 {
+				t.Fatalf("got: %d shapes, want: 5", len(scene.Shapes))
+			}
+
+			sphere, ok := scene.Shapes[0].(*Sphere)
+			if !ok || sphere.Radius != 2.0 || sphere.Material.Emission.R != 1.0 {
+				t.Errorf("got: %v, want: sphere with radius 2 and emission", scene.Shapes[0])
+			}
+
+			first, ok := scene.Shapes[1].(*TransformedShape)
+			if !ok {
+				t.Fatalf("got: %v, want: transformed shape", scene.Shapes[1])
+			}
+			if ior := first.GetMaterial().IndexOfRefraction; ior == nil || *ior != 1.5 {
+				t.Errorf("got: %v, want: %f", ior, 1.5)
+			}
+		})
+
+		t.Run("it builds a BSDF of a typed material", func(t *testing.T) {
+			bsdf, ok := scene.Shapes[3].GetMaterial().BSDF.(*RoughConductorBSDF)
+			expected := CreateGGXDistribution(0.5, 0.1)
+			if !ok || bsdf.Distribution != expected || bsdf.Eta != ConductorPresets["gold"].Eta {
+				t.Errorf("got: %v, want: gold conductor with %v", scene.Shapes[3].GetMaterial().BSDF, expected)
+			}
+
+			principled, ok := scene.Shapes[4].GetMaterial().BSDF.(*PrincipledBSDF)
+			if !ok {
+				t.Fatalf("got: %v, want: principled BSDF", scene.Shapes[4].GetMaterial().BSDF)
+			}
+
+			parameters := CreateDefaultPrincipledParameters()
+			parameters.BaseColor = image.Color{R: 0.8, G: 0.1, B: 0.1}
+			parameters.Clearcoat = 1.0
+			parameters.Specular = 0.3
+			if principled.Parameters != parameters {
+				t.Errorf("got: %v, want: %v", principled.Parameters, parameters)
+			}
+		})
+
+		t.Run("it builds textures of a material", func(t *testing.T) {
+			textures := scene.Shapes[4].GetMaterial().Textures
+			if textures == nil {
+				t.Fatalf("got: nil, want: textures")
+			}
+
+			expected := &CheckerboardTexture{
+				Color1:    image.Color{R: 1.0, G: 0.0, B: 0.0},
+				Color2:    image.Color{R: 0.0, G: 0.0, B: 1.0},
+				Frequency: 4.0,
+				Mapping:   PositionMapping,
+			}
+			if checker, ok := textures.BaseColor.(*CheckerboardTexture); !ok || *checker != *expected {
+				t.Errorf("got: %v, want: %v", textures.BaseColor, expected)
+			}
+
+			if textures.Bump != textures.BaseColor || textures.BumpScale != 0.5 {
+				t.Errorf("got: %v with scale %f, want: checker with scale 0.5", textures.Bump, textures.BumpScale)
+			}
+
+			imageTexture, ok := textures.Roughness.(*ImageTexture)
+			if !ok || imageTexture.Wrap != ClampWrap || imageTexture.Image.ColorSpace != image.NonColor || imageTexture.Image.Width != 2 {
+				t.Errorf("got: %v, want: clamped 2x2 non-color image", textures.Roughness)
+			}
+		})
+
+		t.Run("it builds listed lights and area lights of emissive shapes", func(t *testing.T) {
+			if len(scene.Lights) != 2 {
+				t.Fatalf("got: %d lights, want: 2", len(scene.Lights))
+			}
+
+			if _, ok := scene.Lights[0].(*DirectionalLight); !ok {
+				t.Errorf("got: %v, want: directional light", scene.Lights[0])
+			}
+			if areaLight, ok := scene.Lights[1].(*AreaLight); !ok || areaLight.Shape != scene.Shapes[0] {
+				t.Errorf("got: %v, want: area light of the sphere", scene.Lights[1])
+			}
+		})
+
+		t.Run("it shares a mesh between instances", func(t *testing.T) {
+			first := scene.Shapes[1].(*TransformedShape)
+			second := scene.Shapes[2].(*TransformedShape)
+
+			if first.Shape != second.Shape {
+				t.Errorf("got: %v and %v, want: the same mesh", first.Shape, second.Shape)
+			}
+		})
+
+		t.Run("it fills omitted settings with default values", func(t *testing.T) {
+			if setting.Resolution.Width != 32 || setting.Resolution.Height != DefaultHeight {
+				t.Errorf("got: %v, want: 32x%d", setting.Resolution, DefaultHeight)
+			}
+			if setting.SamplingCount != 4 || setting.TraceRecursionLimit != DefaultTraceRecursionLimit {
+				t.Errorf("got: %v, want: sampling count 4 and default recursion limit", setting)
+			}
+		})
+
+		t.Run("it builds MIS heuristic", func(t *testing.T) {
+			if setting.MisHeuristic != rendering.BalanceHeuristic {
+				t.Errorf("got: %s, want: %s", setting.MisHeuristic, rendering.BalanceHeuristic)
+			}
+		})
+
+		t.Run("it builds tone mapping", func(t *testing.T) {
+			expected := tonemap.Setting{Operator: tonemap.HableOperator{WhitePoint: 4.0}, Exposure: 2.0}
+			if setting.ToneMapping != expected {
+				t.Errorf("got: %v, want: %v", setting.ToneMapping, expected)
+			}
+		})
+	})
+
+	t.Run("When a scene file has invalid fields", func(t *testing.T) {
+		path := writeFile("invalid.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": -2, "material": "white"}]
+		}`)
+
+		_, _, err := Load(path)
+
+		t.Run("it returns validation errors", func(t *testing.T) {
+			errs, ok := err.(ValidationErrors)
+			if !ok || len(errs) != 1 || errs[0].Path != "shapes[0].radius" {
+				t.Errorf("got: %v, want: error at shapes[0].radius", err)
+			}
+		})
+	})
+
+	t.Run("When an image texture is bound to a data parameter", func(t *testing.T) {
+		path := writeFile("data_texture.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"textures": {"data": {"type": "image", "file": "data.png"}, "color": {"type": "image", "file": "data.png"}},
+			"materials": {"metal": {"type": "principled", "textures": {"roughness": "data", "baseColor": "color"}}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "metal"}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+		textures := scene.Shapes[0].GetMaterial().Textures
+		point := TexturePoint{UV: UV{U: 0.5, V: 0.5}}
+
+		t.Run("it reads the image as non-color", func(t *testing.T) {
+			if roughness := EvaluateFloatTexture(textures.Roughness, point); 0.01 < math.Abs(roughness-0.5) {
+				t.Errorf("got: %f, want: 0.5", roughness)
+			}
+		})
+
+		t.Run("it reads the same image bound to a color parameter as sRGB", func(t *testing.T) {
+			if baseColor := textures.BaseColor.Evaluate(point); 0.25 < baseColor.R {
+				t.Errorf("got: %v, want: red converted to linear", baseColor)
+			}
+		})
+	})
+
+	t.Run("When a flat normal map is an image", func(t *testing.T) {
+		path := writeFile("normal_map.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"textures": {"flat": {"type": "image", "file": "data.png"}},
+			"materials": {"white": {"diffuse": [1, 1, 1], "textures": {"normal": "flat"}}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it keeps the shading normal", func(t *testing.T) {
+			hitInfo := scene.Shapes[0].Intersect(CreateRay(vector.Vector{X: 1.0, Y: 0.5, Z: 10.0}, vector.Vector{X: 0.0, Y: 0.0, Z: -1.0}))
+			if hitInfo == nil {
+				t.Fatalf("got: nil, want: hit")
+			}
+
+			expected := hitInfo.ShadingNormal
+			material := scene.Shapes[0].GetMaterial()
+			material.PerturbShadingNormal(hitInfo)
+
+			if d := vector.Subtract(hitInfo.ShadingNormal, expected); 0.01 < d.Length() {
+				t.Errorf("got: %v, want: %v", hitInfo.ShadingNormal, expected)
+			}
+		})
+	})
+
+	t.Run("When a scene file has an environment light", func(t *testing.T) {
+		path := writeFile("environment.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}],
+			"lights": [{"type": "environment", "file": "roughness.pfm", "rotation": 90, "intensity": [2, 2, 2]}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds the environment light from the image", func(t *testing.T) {
+			light, ok := scene.Lights[0].(*EnvironmentLight)
+			if !ok || light.Image.Width != 2 || math.Abs(light.Rotation-math.Pi/2.0) > 1e-9 || light.Intensity.R != 2.0 {
+				t.Errorf("got: %v, want: environment light of the 2x2 image rotated by pi/2", scene.Lights[0])
+			}
+		})
+	})
+
+	t.Run("When a scene file has a sky light", func(t *testing.T) {
+		path := writeFile("sky.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}],
+			"lights": [{"type": "sky", "sunDirection": [1, 1, 0], "turbidity": 4}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds the sky and the sun", func(t *testing.T) {
+			if len(scene.Lights) != 2 {
+				t.Fatalf("got: %d lights, want: 2", len(scene.Lights))
+			}
+
+			sky, ok := scene.Lights[0].(*PreethamSky)
+			if !ok || sky.Turbidity != 4.0 || sky.GroundAlbedo.R != DefaultGroundAlbedo || 1e-9 < math.Abs(sky.SunDirection.Y-math.Sqrt(0.5)) {
+				t.Errorf("got: %v, want: sky of turbidity 4 with the sun at 45 degrees", scene.Lights[0])
+			}
+			if _, ok := scene.Lights[1].(*SunLight); !ok {
+				t.Errorf("got: %v, want: sun light", scene.Lights[1])
+			}
+		})
+	})
+
+	t.Run("When a scene file has a thin lens camera", func(t *testing.T) {
+		path := writeFile("lens.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60,
+				"apertureRadius": 0.2, "focusDistance": 10, "apertureBlades": 6},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds the lens with default anamorphic ratio", func(t *testing.T) {
+			expected := Lens{ApertureRadius: 0.2, FocusDistance: 10.0, ApertureBlades: 6, AnamorphicRatio: 1.0}
+			if scene.Camera.Lens != expected {
+				t.Errorf("got: %v, want: %v", scene.Camera.Lens, expected)
+			}
+		})
+	})
+
+	t.Run("When a scene file has a stereo equirectangular camera", func(t *testing.T) {
+		path := writeFile("equirectangular.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0],
+				"projection": "equirectangular", "stereo": true, "eyeSeparation": 0.065},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds the projection", func(t *testing.T) {
+			expected := EquirectangularProjection{Stereo: true, EyeSeparation: 0.065}
+			if scene.Camera.Projection != expected {
+				t.Errorf("got: %v, want: %v", scene.Camera.Projection, expected)
+			}
+		})
+	})
+
+	t.Run("When a scene file has motion", func(t *testing.T) {
+		path := writeFile("motion.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60,
+				"shutterOpen": 0, "shutterClose": 0.5, "endTransform": [{"translate": [1, 0, 0]}]},
+			"materials": {"white": {"diffuse": [1, 1, 1]}},
+			"shapes": [
+				{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white", "endTransform": [{"translate": [0, 1, 0]}]},
+				{"type": "sphere", "center": [5, 0, 0], "radius": 1, "material": "white", "velocity": [0, 0, 2]}
+			]
+		}`)
+
+		scene, _, err := Load(path)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it builds the shutter and the motion of the camera", func(t *testing.T) {
+			camera := scene.Camera
+			if camera.ShutterOpen != 0.0 || camera.ShutterClose != 0.5 || camera.Motion == nil || camera.Motion.EndTime != 0.5 {
+				t.Errorf("got: %v, want: camera moving in shutter [0, 0.5]", camera)
+			}
+		})
+
+		t.Run("it builds moving shapes in the shutter interval", func(t *testing.T) {
+			animated, ok := scene.Shapes[0].(*AnimatedShape)
+			if !ok || animated.Motion.StartTime != 0.0 || animated.Motion.EndTime != 0.5 {
+				t.Errorf("got: %v, want: animated shape in [0, 0.5]", scene.Shapes[0])
+			}
+
+			linear, ok := scene.Shapes[1].(*LinearMotionShape)
+			if !ok || linear.Velocity.Z != 2.0 || linear.EndTime != 0.5 {
+				t.Errorf("got: %v, want: linear motion shape in [0, 0.5]", scene.Shapes[1])
+			}
+		})
+	})
+
+	t.Run("When an image texture does not exist", func(t *testing.T) {
+		path := writeFile("missing_texture.json", `{
+			"camera": {"origin": [0, 0, 10], "direction": [0, 0, -1], "up": [0, 1, 0], "fov": 60},
+			"textures": {"wood": {"type": "image", "file": "wood.png"}},
+			"materials": {"white": {"diffuse": [1, 1, 1], "textures": {"diffuse": "wood"}}},
+			"shapes": [{"type": "sphere", "center": [0, 0, 0], "radius": 2, "material": "white"}]
+		}`)
+
+		_, _, err := Load(path)
+
+		t.Run("it returns an error with the name of the texture", func(t *testing.T) {
+			if err == nil || !strings.HasPrefix(err.Error(), "textures.wood: ") {
+				t.Errorf("got: %v, want: error of textures.wood", err)
+			}
+		})
+	})
+
+	t.Run("When a scene file has a syntax error", func(t *testing.T) {
+		path := writeFile("syntax.json", "{\n  \"camera\": {,\n}")
+
+		_, _, err := Load(path)
+
+		t.Run("it returns an error with line and column", func(t *testing.T) {
+			if err == nil || !strings.HasPrefix(err.Error(), path+":2:14: ") {
+				t.Errorf("got: %v, want: error at %s:2:14", err, path)
+			}
+		})
+	})
+
+	t.Run("When a scene file has an unknown field", func(t *testing.T) {
+		path := writeFile("unknown.json", `{"camera": {"origin": [0, 0, 10], "radus": 1}}`)
+
+		_, _, err := Load(path)
+
+		t.Run("it returns an error", func(t *testing.T) {
+			if err == nil || !strings.Contains(err.Error(), "radus") {
+				t.Errorf("got: %v, want: error about radus", err)
+			}
+		})
+	})
+}
+
+func TestLoadExampleScenes(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "scenes", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		if _, _, err := Load(path); err != nil {
+			t.Errorf("Load(%s) must succeed, actual error is %v", path, err)
+		}
+	}
+}
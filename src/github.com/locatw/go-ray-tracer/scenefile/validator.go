@@ -0,0 +1,564 @@
+package scenefile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/locatw/go-ray-tracer/element"
+	"github.com/locatw/go-ray-tracer/image"
+	"github.com/locatw/go-ray-tracer/image/tonemap"
+	"github.com/locatw/go-ray-tracer/rendering"
+	"github.com/locatw/go-ray-tracer/transform"
+)
+
+type ValidationError struct {
+	// Path of the invalid field such as "shapes[2].radius".
+	Path    string
+	Message string
+}
+
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+type validator struct {
+	errors ValidationErrors
+}
+
+// Validate a scene description and return all invalid fields, or nil if it is valid.
+func Validate(description *SceneDescription) ValidationErrors {
+	v := validator{}
+
+	v.validateCamera("camera", description.Camera)
+
+	textureNames := make([]string, 0, len(description.Textures))
+	for name := range description.Textures {
+		textureNames = append(textureNames, name)
+	}
+	sort.Strings(textureNames)
+	colorTextures, dataTextures := textureUsage(description.Materials)
+	for _, name := range textureNames {
+		texture := description.Textures[name]
+		path := fmt.Sprintf("textures.%s", name)
+
+		v.validateTexture(path, texture)
+
+		// the color space of an image cannot be decided by its usage if it is used for both
+		if texture.Type == ImageTextureType && texture.ColorSpace == "" && colorTextures[name] && dataTextures[name] {
+			v.addError(path+".colorSpace", "is required for an image used as both color and data")
+		}
+	}
+
+	names := make([]string, 0, len(description.Materials))
+	for name := range description.Materials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		material := description.Materials[name]
+		path := fmt.Sprintf("materials.%s", name)
+
+		v.validateMaterial(path, material)
+		v.validateMaterialTextures(path+".textures", material, description.Textures)
+	}
+
+	for i, shape := range description.Shapes {
+		v.validateShape(fmt.Sprintf("shapes[%d]", i), &shape, description.Materials)
+	}
+
+	backgroundCount := 0
+	for i, light := range description.Lights {
+		path := fmt.Sprintf("lights[%d]", i)
+		v.validateLight(path, &light)
+
+		if light.Type == EnvironmentLightType || light.Type == SkyLightType {
+			backgroundCount++
+			if 1 < backgroundCount {
+				v.addError(path, "only one environment or sky light is allowed")
+			}
+		}
+	}
+
+	if description.Settings != nil {
+		v.validateSettings("settings", description.Settings)
+	}
+
+	return v.errors
+}
+
+func (v *validator) addError(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) require(path string, present bool) bool {
+	if !present {
+		v.addError(path, "is required")
+	}
+
+	return present
+}
+
+func (v *validator) validateNonZeroVector(path string, vector *Vector3) {
+	if v.require(path, vector != nil) && vector.isZero() {
+		v.addError(path, "must not be a zero-length vector")
+	}
+}
+
+func (v *validator) validateColor(path string, color *Vector3) {
+	if color == nil {
+		return
+	}
+
+	for i, value := range color {
+		if value < 0.0 || math.IsInf(value, 0) || math.IsNaN(value) {
+			v.addError(fmt.Sprintf("%s[%d]", path, i), "must be a non-negative finite number")
+		}
+	}
+}
+
+func (v *validator) validateCamera(path string, camera *CameraDescription) {
+	if !v.require(path, camera != nil) {
+		return
+	}
+
+	v.require(path+".origin", camera.Origin != nil)
+	v.validateNonZeroVector(path+".direction", camera.Direction)
+	v.validateNonZeroVector(path+".up", camera.Up)
+
+	if camera.Direction != nil && camera.Up != nil && !camera.Direction.isZero() && !camera.Up.isZero() {
+		if camera.Direction.cross(*camera.Up).isZero() {
+			v.addError(path+".up", "must not be parallel to direction")
+		}
+	}
+
+	switch camera.Projection {
+	case "", PerspectiveProjectionName:
+		if v.require(path+".fov", camera.Fov != nil) && (*camera.Fov <= 0.0 || 180.0 <= *camera.Fov) {
+			v.addError(path+".fov", "must be in range (0, 180), got %g", *camera.Fov)
+		}
+	case OrthographicProjectionName:
+		if v.require(path+".height", camera.Height != nil) && *camera.Height <= 0.0 {
+			v.addError(path+".height", "must be positive, got %g", *camera.Height)
+		}
+	case FisheyeProjectionName:
+		if v.require(path+".fov", camera.Fov != nil) && (*camera.Fov <= 0.0 || 360.0 < *camera.Fov) {
+			v.addError(path+".fov", "must be in range (0, 360], got %g", *camera.Fov)
+		}
+	case EquirectangularProjectionName:
+		if camera.Stereo {
+			if v.require(path+".eyeSeparation", camera.EyeSeparation != nil) && *camera.EyeSeparation < 0.0 {
+				v.addError(path+".eyeSeparation", "must be non-negative, got %g", *camera.EyeSeparation)
+			}
+		}
+	default:
+		v.addError(path+".projection", "must be one of %s, %s, %s, %s, got %q",
+			PerspectiveProjectionName, OrthographicProjectionName, FisheyeProjectionName, EquirectangularProjectionName, camera.Projection)
+	}
+
+	if camera.Stereo && camera.Projection != EquirectangularProjectionName {
+		v.addError(path+".stereo", "must be set only for %s projection", EquirectangularProjectionName)
+	}
+
+	if shutterOpen, shutterClose := shutterInterval(camera); shutterClose < shutterOpen {
+		v.addError(path+".shutterClose", "must not be less than shutterOpen, got %g", shutterClose)
+	}
+	v.validateMotion(path, nil, camera.EndTransform)
+
+	if camera.ApertureRadius != nil {
+		if *camera.ApertureRadius < 0.0 {
+			v.addError(path+".apertureRadius", "must be non-negative, got %g", *camera.ApertureRadius)
+		} else if 0.0 < *camera.ApertureRadius {
+			v.require(path+".focusDistance", camera.FocusDistance != nil)
+		}
+	}
+	if camera.FocusDistance != nil && *camera.FocusDistance <= 0.0 {
+		v.addError(path+".focusDistance", "must be positive, got %g", *camera.FocusDistance)
+	}
+	if camera.ApertureBlades != nil && *camera.ApertureBlades != 0 && *camera.ApertureBlades < 3 {
+		v.addError(path+".apertureBlades", "must be 0 or at least 3, got %d", *camera.ApertureBlades)
+	}
+	if camera.AnamorphicRatio != nil && *camera.AnamorphicRatio <= 0.0 {
+		v.addError(path+".anamorphicRatio", "must be positive, got %g", *camera.AnamorphicRatio)
+	}
+}
+
+func (v *validator) validateMaterial(path string, material MaterialDescription) {
+	v.validateColor(path+".emission", material.Emission)
+
+	if material.IndexOfRefraction != nil && *material.IndexOfRefraction <= 0.0 {
+		v.addError(path+".indexOfRefraction", "must be positive, got %g", *material.IndexOfRefraction)
+	}
+
+	switch material.Type {
+	case LegacyMaterialType:
+		v.validateColor(path+".diffuse", material.Diffuse)
+		v.validateColor(path+".specular", material.Specular)
+		return
+	case ConductorMaterialType:
+		if material.Conductor != "" {
+			if _, ok := element.ConductorPresets[material.Conductor]; !ok {
+				v.addError(path+".conductor", "must be one of %s, got %q", strings.Join(conductorPresetNames(), ", "), material.Conductor)
+			}
+			if material.Eta != nil || material.K != nil {
+				v.addError(path+".conductor", "must not be set with eta and k")
+			}
+		} else {
+			v.validateRequiredColor(path+".eta", material.Eta)
+			v.validateRequiredColor(path+".k", material.K)
+		}
+	case DielectricMaterialType:
+		v.require(path+".indexOfRefraction", material.IndexOfRefraction != nil)
+	case PrincipledMaterialType:
+		v.validatePrincipled(path, material)
+		return
+	default:
+		v.addError(path+".type", "unknown material type %q", material.Type)
+		return
+	}
+
+	if material.Diffuse != nil || material.Specular != nil {
+		v.addError(path, "diffuse and specular must not be set for %s materials", material.Type)
+	}
+
+	if v.require(path+".roughness", material.Roughness != nil) {
+		v.validateRoughness(path+".roughness", *material.Roughness)
+	}
+	if material.RoughnessV != nil {
+		v.validateRoughness(path+".roughnessV", *material.RoughnessV)
+	}
+}
+
+func (v *validator) validatePrincipled(path string, material MaterialDescription) {
+	if material.Diffuse != nil || material.Specular != nil {
+		v.addError(path, "diffuse and specular must not be set for %s materials", material.Type)
+	}
+
+	v.validateColor(path+".baseColor", material.BaseColor)
+
+	fields := []struct {
+		name  string
+		value *float64
+	}{
+		{name: "metallic", value: material.Metallic},
+		{name: "roughness", value: material.Roughness},
+		{name: "specularLevel", value: material.SpecularLevel},
+		{name: "specularTint", value: material.SpecularTint},
+		{name: "sheen", value: material.Sheen},
+		{name: "sheenTint", value: material.SheenTint},
+		{name: "clearcoat", value: material.Clearcoat},
+		{name: "clearcoatGloss", value: material.ClearcoatGloss},
+		{name: "transmission", value: material.Transmission},
+		{name: "anisotropic", value: material.Anisotropic},
+	}
+
+	for _, field := range fields {
+		if field.value != nil {
+			v.validateRoughness(path+"."+field.name, *field.value)
+		}
+	}
+}
+
+// Validate a value in [0, 1] such as roughness.
+func (v *validator) validateRoughness(path string, roughness float64) {
+	if !(0.0 <= roughness && roughness <= 1.0) {
+		v.addError(path, "must be in range [0, 1], got %g", roughness)
+	}
+}
+
+func (v *validator) validateMaterialTextures(path string, material MaterialDescription, textures map[string]TextureDescription) {
+	parameters, ok := TextureParameters[material.Type]
+	if !ok {
+		// unknown type is reported by validateMaterial
+		return
+	}
+
+	keys := make([]string, 0, len(material.Textures))
+	for key := range material.Textures {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if material.Textures["normal"] != "" && material.Textures["bump"] != "" {
+		v.addError(path, "normal and bump must not be set together")
+	}
+	if material.BumpScale != nil && material.Textures["bump"] == "" {
+		v.addError(path+".bump", "is required if bumpScale is set")
+	}
+
+	for _, key := range keys {
+		if !containsString(parameters, key) {
+			v.addError(path+"."+key, "unknown parameter, textures of this material must be one of %s", strings.Join(parameters, ", "))
+		} else if _, ok := textures[material.Textures[key]]; !ok {
+			v.addError(path+"."+key, "undefined texture %q", material.Textures[key])
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *validator) validateTexture(path string, texture TextureDescription) {
+	switch texture.Type {
+	case ConstantTextureType:
+		v.validateRequiredColor(path+".color", texture.Color)
+	case ImageTextureType:
+		v.require(path+".file", texture.File != "")
+		if texture.Wrap != "" && texture.Wrap != RepeatWrapName && texture.Wrap != ClampWrapName {
+			v.addError(path+".wrap", "must be one of %s, %s, got %q", RepeatWrapName, ClampWrapName, texture.Wrap)
+		}
+		if texture.ColorSpace != "" {
+			if _, err := image.ParseColorSpace(texture.ColorSpace); err != nil {
+				v.addError(path+".colorSpace", "unknown color space %q", texture.ColorSpace)
+			}
+		}
+	case CheckerboardTextureType, NoiseTextureType:
+		if texture.Colors != nil {
+			if len(texture.Colors) != 2 {
+				v.addError(path+".colors", "must have 2 colors, got %d", len(texture.Colors))
+			}
+			for i := range texture.Colors {
+				v.validateColor(fmt.Sprintf("%s.colors[%d]", path, i), &texture.Colors[i])
+			}
+		}
+		if texture.Frequency != nil && !(0.0 < *texture.Frequency) {
+			v.addError(path+".frequency", "must be positive, got %g", *texture.Frequency)
+		}
+		if texture.Mapping != "" && texture.Mapping != UVMappingName && texture.Mapping != PositionMappingName {
+			v.addError(path+".mapping", "must be one of %s, %s, got %q", UVMappingName, PositionMappingName, texture.Mapping)
+		}
+		if texture.Type == NoiseTextureType && texture.Octaves != nil && *texture.Octaves <= 0 {
+			v.addError(path+".octaves", "must be positive, got %d", *texture.Octaves)
+		}
+	case "":
+		v.addError(path+".type", "is required")
+	default:
+		v.addError(path+".type", "unknown texture type %q", texture.Type)
+	}
+}
+
+func conductorPresetNames() []string {
+	names := make([]string, 0, len(element.ConductorPresets))
+	for name := range element.ConductorPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (v *validator) validateShape(path string, shape *ShapeDescription, materials map[string]MaterialDescription) {
+	switch shape.Type {
+	case SphereShapeType:
+		v.require(path+".center", shape.Center != nil)
+		if v.require(path+".radius", shape.Radius != nil) && *shape.Radius <= 0.0 {
+			v.addError(path+".radius", "must be positive, got %g", *shape.Radius)
+		}
+	case PlaneShapeType:
+		v.require(path+".center", shape.Center != nil)
+		v.validateNonZeroVector(path+".normal", shape.Normal)
+	case TriangleShapeType:
+		if len(shape.Vertices) != 3 {
+			v.addError(path+".vertices", "must have 3 vertices, got %d", len(shape.Vertices))
+		} else {
+			e1 := shape.Vertices[1].subtract(shape.Vertices[0])
+			e2 := shape.Vertices[2].subtract(shape.Vertices[0])
+			if e1.cross(e2).isZero() {
+				v.addError(path+".vertices", "must not be degenerate")
+			}
+		}
+	case ObjShapeType:
+		if shape.File == "" {
+			v.addError(path+".file", "is required")
+		}
+	case "":
+		v.addError(path+".type", "is required")
+	default:
+		v.addError(path+".type", "unknown shape type %q", shape.Type)
+	}
+
+	if shape.Material == "" {
+		if shape.Type != ObjShapeType {
+			v.addError(path+".material", "is required")
+		}
+	} else if _, ok := materials[shape.Material]; !ok {
+		v.addError(path+".material", "undefined material %q", shape.Material)
+	}
+
+	v.validateMotion(path, shape.Transform, shape.EndTransform)
+	if len(shape.EndTransform) != 0 && shape.Velocity != nil {
+		v.addError(path, "endTransform and velocity must not be set together")
+	}
+}
+
+func (v *validator) validateRequiredColor(path string, color *Vector3) {
+	if v.require(path, color != nil) {
+		v.validateColor(path, color)
+	}
+}
+
+func (v *validator) validateLight(path string, light *LightDescription) {
+	switch light.Type {
+	case PointLightType:
+		v.require(path+".position", light.Position != nil)
+		v.validateRequiredColor(path+".intensity", light.Intensity)
+	case SpotLightType:
+		v.require(path+".position", light.Position != nil)
+		v.validateRequiredColor(path+".intensity", light.Intensity)
+		v.validateNonZeroVector(path+".direction", light.Direction)
+
+		if v.require(path+".angle", light.Angle != nil) && (*light.Angle <= 0.0 || 180.0 <= *light.Angle) {
+			v.addError(path+".angle", "must be in range (0, 180), got %g", *light.Angle)
+		}
+		if light.FalloffAngle != nil && light.Angle != nil && (*light.FalloffAngle < 0.0 || *light.Angle < *light.FalloffAngle) {
+			v.addError(path+".falloffAngle", "must be in range [0, angle], got %g", *light.FalloffAngle)
+		}
+	case DirectionalLightType:
+		v.validateNonZeroVector(path+".direction", light.Direction)
+		v.validateRequiredColor(path+".irradiance", light.Irradiance)
+	case EnvironmentLightType:
+		v.require(path+".file", light.File != "")
+		v.validateColor(path+".intensity", light.Intensity)
+	case SkyLightType:
+		v.validateNonZeroVector(path+".sunDirection", light.SunDirection)
+		if light.SunDirection != nil && !light.SunDirection.isZero() && light.SunDirection[1] <= 0.0 {
+			v.addError(path+".sunDirection", "must point above the horizon")
+		}
+		if light.Turbidity != nil && (*light.Turbidity < 2.0 || 10.0 < *light.Turbidity) {
+			v.addError(path+".turbidity", "must be in range [2, 10], got %g", *light.Turbidity)
+		}
+		v.validateColor(path+".groundAlbedo", light.GroundAlbedo)
+		v.validateColor(path+".intensity", light.Intensity)
+	case "":
+		v.addError(path+".type", "is required")
+	default:
+		v.addError(path+".type", "unknown light type %q", light.Type)
+	}
+}
+
+// Validate transform operations at the start and the end of a motion. The motion must not pass
+// through a singular transform, which flattens a shape while it is being mirrored.
+func (v *validator) validateMotion(path string, start []TransformDescription, end []TransformDescription) {
+	errorCount := len(v.errors)
+	for i, operation := range start {
+		v.validateTransform(fmt.Sprintf("%s.transform[%d]", path, i), &operation)
+	}
+	for i, operation := range end {
+		v.validateTransform(fmt.Sprintf("%s.endTransform[%d]", path, i), &operation)
+	}
+
+	if len(end) == 0 || errorCount != len(v.errors) {
+		return
+	}
+
+	motion := transform.CreateAnimatedTransform(buildTransform(start), 0.0, buildTransform(end), 1.0)
+	if !motion.IsInvertible() {
+		v.addError(path+".endTransform", "must not become singular while moving from the start transform")
+	}
+}
+
+func (v *validator) validateTransform(path string, operation *TransformDescription) {
+	count := 0
+
+	if operation.Translate != nil {
+		count++
+	}
+
+	if operation.Scale != nil {
+		count++
+
+		for i, value := range operation.Scale {
+			if value == 0.0 {
+				v.addError(fmt.Sprintf("%s.scale[%d]", path, i), "must not be zero")
+			}
+		}
+	}
+
+	if operation.Rotate != nil {
+		count++
+
+		v.validateNonZeroVector(path+".rotate.axis", operation.Rotate.Axis)
+		v.require(path+".rotate.angle", operation.Rotate.Angle != nil)
+	}
+
+	if count != 1 {
+		v.addError(path, "must have exactly one of translate, scale and rotate")
+	}
+}
+
+func (v *validator) validateSettings(path string, settings *SettingsDescription) {
+	positiveInts := []struct {
+		name  string
+		value *int
+	}{
+		{name: "width", value: settings.Width},
+		{name: "height", value: settings.Height},
+		{name: "samplingCount", value: settings.SamplingCount},
+		{name: "traceRecursionLimit", value: settings.TraceRecursionLimit},
+	}
+
+	for _, field := range positiveInts {
+		if field.value != nil && *field.value <= 0 {
+			v.addError(path+"."+field.name, "must be positive, got %d", *field.value)
+		}
+	}
+
+	if settings.ToneMapping != nil {
+		v.validateToneMapping(path+".toneMapping", settings.ToneMapping)
+	}
+
+	if settings.MisHeuristic != nil {
+		if _, err := rendering.ParseMisHeuristic(*settings.MisHeuristic); err != nil {
+			v.addError(path+".misHeuristic", "must be one of %s, got %q",
+				strings.Join(rendering.MisHeuristicNames, ", "), *settings.MisHeuristic)
+		}
+	}
+}
+
+func (v *validator) validateToneMapping(path string, toneMapping *ToneMappingDescription) {
+	if toneMapping.Operator != nil {
+		if _, err := tonemap.CreateOperator(*toneMapping.Operator, 0.0); err != nil {
+			v.addError(path+".operator", "must be one of %s, got %q",
+				strings.Join(tonemap.OperatorNames, ", "), *toneMapping.Operator)
+		}
+	}
+
+	if toneMapping.WhitePoint != nil && !(0.0 < *toneMapping.WhitePoint) {
+		v.addError(path+".whitePoint", "must be positive, got %g", *toneMapping.WhitePoint)
+	}
+}
+
+func (vector Vector3) isZero() bool {
+	return vector[0] == 0.0 && vector[1] == 0.0 && vector[2] == 0.0
+}
+
+func (vector Vector3) subtract(other Vector3) Vector3 {
+	return Vector3{vector[0] - other[0], vector[1] - other[1], vector[2] - other[2]}
+}
+
+func (vector Vector3) cross(other Vector3) Vector3 {
+	return Vector3{
+		vector[1]*other[2] - vector[2]*other[1],
+		vector[2]*other[0] - vector[0]*other[2],
+		vector[0]*other[1] - vector[1]*other[0],
+	}
+}
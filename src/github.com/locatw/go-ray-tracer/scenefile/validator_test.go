@@ -0,0 +1,353 @@
+package scenefile
+
+import (
+	"testing"
+)
+
+func createValidDescription() *SceneDescription {
+	fov := 30.0
+	radius := 1.0
+
+	return &SceneDescription{
+		Camera: &CameraDescription{
+			Origin:    &Vector3{0.0, 0.0, 10.0},
+			Direction: &Vector3{0.0, 0.0, -1.0},
+			Up:        &Vector3{0.0, 1.0, 0.0},
+			Fov:       &fov,
+		},
+		Materials: map[string]MaterialDescription{
+			"white": {Diffuse: &Vector3{0.75, 0.75, 0.75}},
+		},
+		Shapes: []ShapeDescription{
+			{Type: SphereShapeType, Center: &Vector3{0.0, 0.0, 0.0}, Radius: &radius, Material: "white"},
+			{Type: PlaneShapeType, Center: &Vector3{0.0, -1.0, 0.0}, Normal: &Vector3{0.0, 1.0, 0.0}, Material: "white"},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("When a description is valid", func(t *testing.T) {
+		description := createValidDescription()
+
+		t.Run("it returns nil", func(t *testing.T) {
+			errs := Validate(description)
+
+			if errs != nil {
+				t.Errorf("got: %v, want: nil", errs)
+			}
+		})
+	})
+
+	negative := -1.0
+	zeroInt := 0
+	wrongFov := 180.0
+
+	patterns := []struct {
+		name     string
+		modify   func(description *SceneDescription)
+		expected string
+	}{
+		{
+			name:     "When a camera is missing",
+			modify:   func(d *SceneDescription) { d.Camera = nil },
+			expected: "camera: is required",
+		},
+		{
+			name:     "When a camera up is parallel to direction",
+			modify:   func(d *SceneDescription) { d.Camera.Up = &Vector3{0.0, 0.0, 2.0} },
+			expected: "camera.up: must not be parallel to direction",
+		},
+		{
+			name:     "When fov is out of range",
+			modify:   func(d *SceneDescription) { d.Camera.Fov = &wrongFov },
+			expected: "camera.fov: must be in range (0, 180), got 180",
+		},
+		{
+			name: "When a fisheye camera has fov out of range",
+			modify: func(d *SceneDescription) {
+				fov := 400.0
+				d.Camera.Projection = FisheyeProjectionName
+				d.Camera.Fov = &fov
+			},
+			expected: "camera.fov: must be in range (0, 360], got 400",
+		},
+		{
+			name:     "When an orthographic camera has no height",
+			modify:   func(d *SceneDescription) { d.Camera.Projection = OrthographicProjectionName },
+			expected: "camera.height: is required",
+		},
+		{
+			name:     "When a perspective camera is stereo",
+			modify:   func(d *SceneDescription) { d.Camera.Stereo = true },
+			expected: "camera.stereo: must be set only for equirectangular projection",
+		},
+		{
+			name: "When a camera closes the shutter before opening it",
+			modify: func(d *SceneDescription) {
+				shutterOpen := 0.5
+				shutterClose := 0.25
+				d.Camera.ShutterOpen = &shutterOpen
+				d.Camera.ShutterClose = &shutterClose
+			},
+			expected: "camera.shutterClose: must not be less than shutterOpen, got 0.25",
+		},
+		{
+			name: "When a shape has both end transform and velocity",
+			modify: func(d *SceneDescription) {
+				d.Shapes[0].EndTransform = []TransformDescription{{Translate: &Vector3{1.0, 0.0, 0.0}}}
+				d.Shapes[0].Velocity = &Vector3{1.0, 0.0, 0.0}
+			},
+			expected: "shapes[0]: endTransform and velocity must not be set together",
+		},
+		{
+			name: "When a shape is mirrored by end transform",
+			modify: func(d *SceneDescription) {
+				d.Shapes[0].EndTransform = []TransformDescription{{Scale: &Vector3{-1.0, 1.0, 1.0}}}
+			},
+			expected: "shapes[0].endTransform: must not become singular while moving from the start transform",
+		},
+		{
+			name: "When a camera has an aperture without focus distance",
+			modify: func(d *SceneDescription) {
+				apertureRadius := 0.1
+				d.Camera.ApertureRadius = &apertureRadius
+			},
+			expected: "camera.focusDistance: is required",
+		},
+		{
+			name: "When a camera has two aperture blades",
+			modify: func(d *SceneDescription) {
+				blades := 2
+				d.Camera.ApertureBlades = &blades
+			},
+			expected: "camera.apertureBlades: must be 0 or at least 3, got 2",
+		},
+		{
+			name:     "When a sphere has negative radius",
+			modify:   func(d *SceneDescription) { d.Shapes[0].Radius = &negative },
+			expected: "shapes[0].radius: must be positive, got -1",
+		},
+		{
+			name:     "When a plane has zero-length normal",
+			modify:   func(d *SceneDescription) { d.Shapes[1].Normal = &Vector3{0.0, 0.0, 0.0} },
+			expected: "shapes[1].normal: must not be a zero-length vector",
+		},
+		{
+			name:     "When a shape refers an undefined material",
+			modify:   func(d *SceneDescription) { d.Shapes[1].Material = "black" },
+			expected: "shapes[1].material: undefined material \"black\"",
+		},
+		{
+			name:     "When a shape has unknown type",
+			modify:   func(d *SceneDescription) { d.Shapes[0].Type = "cube" },
+			expected: "shapes[0].type: unknown shape type \"cube\"",
+		},
+		{
+			name: "When a triangle is degenerate",
+			modify: func(d *SceneDescription) {
+				d.Shapes[0] = ShapeDescription{
+					Type:     TriangleShapeType,
+					Vertices: []Vector3{{0.0, 0.0, 0.0}, {1.0, 1.0, 1.0}, {2.0, 2.0, 2.0}},
+					Material: "white",
+				}
+			},
+			expected: "shapes[0].vertices: must not be degenerate",
+		},
+		{
+			name: "When a material has negative color",
+			modify: func(d *SceneDescription) {
+				d.Materials["white"] = MaterialDescription{Diffuse: &Vector3{0.5, -0.5, 0.5}}
+			},
+			expected: "materials.white.diffuse[1]: must be a non-negative finite number",
+		},
+		{
+			name: "When a transform scales by zero",
+			modify: func(d *SceneDescription) {
+				d.Shapes[0].Transform = []TransformDescription{
+					{Translate: &Vector3{1.0, 0.0, 0.0}},
+					{Scale: &Vector3{1.0, 1.0, 0.0}},
+				}
+			},
+			expected: "shapes[0].transform[1].scale[2]: must not be zero",
+		},
+		{
+			name:     "When settings has zero sampling count",
+			modify:   func(d *SceneDescription) { d.Settings = &SettingsDescription{SamplingCount: &zeroInt} },
+			expected: "settings.samplingCount: must be positive, got 0",
+		},
+		{
+			name: "When a spot light has a falloff angle larger than the angle",
+			modify: func(d *SceneDescription) {
+				angle := 30.0
+				falloffAngle := 45.0
+				d.Lights = []LightDescription{{
+					Type:         SpotLightType,
+					Position:     &Vector3{0.0, 1.0, 0.0},
+					Direction:    &Vector3{0.0, -1.0, 0.0},
+					Intensity:    &Vector3{1.0, 1.0, 1.0},
+					Angle:        &angle,
+					FalloffAngle: &falloffAngle,
+				}}
+			},
+			expected: "lights[0].falloffAngle: must be in range [0, angle], got 45",
+		},
+		{
+			name: "When a material has an unknown type",
+			modify: func(d *SceneDescription) {
+				d.Materials["white"] = MaterialDescription{Type: "plastic"}
+			},
+			expected: `materials.white.type: unknown material type "plastic"`,
+		},
+		{
+			name: "When a conductor has an unknown preset",
+			modify: func(d *SceneDescription) {
+				roughness := 0.5
+				d.Materials["white"] = MaterialDescription{Type: ConductorMaterialType, Conductor: "brass", Roughness: &roughness}
+			},
+			expected: `materials.white.conductor: must be one of aluminium, copper, gold, silver, got "brass"`,
+		},
+		{
+			name: "When a dielectric has roughness out of range",
+			modify: func(d *SceneDescription) {
+				ior := 1.5
+				roughness := 1.5
+				d.Materials["white"] = MaterialDescription{Type: DielectricMaterialType, IndexOfRefraction: &ior, Roughness: &roughness}
+			},
+			expected: "materials.white.roughness: must be in range [0, 1], got 1.5",
+		},
+		{
+			name: "When a conductor has diffuse color",
+			modify: func(d *SceneDescription) {
+				roughness := 0.5
+				d.Materials["white"] = MaterialDescription{
+					Type: ConductorMaterialType, Conductor: "gold", Roughness: &roughness, Diffuse: &Vector3{1.0, 1.0, 1.0},
+				}
+			},
+			expected: "materials.white: diffuse and specular must not be set for conductor materials",
+		},
+		{
+			name: "When a principled material has metallic out of range",
+			modify: func(d *SceneDescription) {
+				metallic := -0.5
+				d.Materials["white"] = MaterialDescription{Type: PrincipledMaterialType, Metallic: &metallic}
+			},
+			expected: "materials.white.metallic: must be in range [0, 1], got -0.5",
+		},
+		{
+			name: "When a material refers an undefined texture",
+			modify: func(d *SceneDescription) {
+				d.Materials["white"] = MaterialDescription{Textures: map[string]string{"diffuse": "wood"}}
+			},
+			expected: `materials.white.textures.diffuse: undefined texture "wood"`,
+		},
+		{
+			name: "When a texture overrides a parameter which the material does not have",
+			modify: func(d *SceneDescription) {
+				d.Textures = map[string]TextureDescription{"wood": {Type: ConstantTextureType, Color: &Vector3{1.0, 1.0, 1.0}}}
+				d.Materials["white"] = MaterialDescription{Textures: map[string]string{"roughness": "wood"}}
+			},
+			expected: "materials.white.textures.roughness: unknown parameter, textures of this material must be one of diffuse, specular, normal, bump",
+		},
+		{
+			name: "When a material has both normal and bump textures",
+			modify: func(d *SceneDescription) {
+				d.Textures = map[string]TextureDescription{"flat": {Type: ConstantTextureType, Color: &Vector3{0.5, 0.5, 1.0}}}
+				d.Materials["white"] = MaterialDescription{Textures: map[string]string{"normal": "flat", "bump": "flat"}}
+			},
+			expected: "materials.white.textures: normal and bump must not be set together",
+		},
+		{
+			name: "When an image texture without color space is used as both color and data",
+			modify: func(d *SceneDescription) {
+				d.Textures = map[string]TextureDescription{"wood": {Type: ImageTextureType, File: "wood.png"}}
+				d.Materials["white"] = MaterialDescription{Type: PrincipledMaterialType, Textures: map[string]string{"baseColor": "wood", "roughness": "wood"}}
+			},
+			expected: "textures.wood.colorSpace: is required for an image used as both color and data",
+		},
+		{
+			name: "When a checkerboard texture has one color",
+			modify: func(d *SceneDescription) {
+				d.Textures = map[string]TextureDescription{"tiles": {Type: CheckerboardTextureType, Colors: []Vector3{{1.0, 1.0, 1.0}}}}
+			},
+			expected: "textures.tiles.colors: must have 2 colors, got 1",
+		},
+		{
+			name: "When an image texture has an unknown wrap mode",
+			modify: func(d *SceneDescription) {
+				d.Textures = map[string]TextureDescription{"wood": {Type: ImageTextureType, File: "wood.png", Wrap: "mirror"}}
+			},
+			expected: `textures.wood.wrap: must be one of repeat, clamp, got "mirror"`,
+		},
+		{
+			name: "When there are two environment lights",
+			modify: func(d *SceneDescription) {
+				d.Lights = []LightDescription{
+					{Type: EnvironmentLightType, File: "studio.hdr"},
+					{Type: EnvironmentLightType, File: "sky.hdr"},
+				}
+			},
+			expected: "lights[1]: only one environment or sky light is allowed",
+		},
+		{
+			name: "When there are an environment light and a sky light",
+			modify: func(d *SceneDescription) {
+				d.Lights = []LightDescription{
+					{Type: EnvironmentLightType, File: "studio.hdr"},
+					{Type: SkyLightType, SunDirection: &Vector3{0.0, 1.0, 0.0}},
+				}
+			},
+			expected: "lights[1]: only one environment or sky light is allowed",
+		},
+		{
+			name: "When a sky light has the sun below the horizon",
+			modify: func(d *SceneDescription) {
+				d.Lights = []LightDescription{{Type: SkyLightType, SunDirection: &Vector3{1.0, -0.5, 0.0}}}
+			},
+			expected: "lights[0].sunDirection: must point above the horizon",
+		},
+		{
+			name: "When a sky light has turbidity out of range",
+			modify: func(d *SceneDescription) {
+				turbidity := 1.0
+				d.Lights = []LightDescription{{Type: SkyLightType, SunDirection: &Vector3{0.0, 1.0, 0.0}, Turbidity: &turbidity}}
+			},
+			expected: "lights[0].turbidity: must be in range [2, 10], got 1",
+		},
+		{
+			name:     "When a light has an unknown type",
+			modify:   func(d *SceneDescription) { d.Lights = []LightDescription{{Type: "area"}} },
+			expected: `lights[0].type: unknown light type "area"`,
+		},
+		{
+			name: "When tone mapping has an unknown operator",
+			modify: func(d *SceneDescription) {
+				operator := "filmic"
+				d.Settings = &SettingsDescription{ToneMapping: &ToneMappingDescription{Operator: &operator}}
+			},
+			expected: `settings.toneMapping.operator: must be one of linear, reinhard, extended-reinhard, aces, hable, got "filmic"`,
+		},
+		{
+			name: "When MIS heuristic is unknown",
+			modify: func(d *SceneDescription) {
+				heuristic := "maximum"
+				d.Settings = &SettingsDescription{MisHeuristic: &heuristic}
+			},
+			expected: `settings.misHeuristic: must be one of power, balance, none, got "maximum"`,
+		},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			description := createValidDescription()
+			pattern.modify(description)
+
+			t.Run("it returns an error with the path of the invalid field", func(t *testing.T) {
+				errs := Validate(description)
+
+				if len(errs) != 1 || errs[0].Error() != pattern.expected {
+					t.Errorf("got: %v, want: %s", errs, pattern.expected)
+				}
+			})
+		})
+	}
+}
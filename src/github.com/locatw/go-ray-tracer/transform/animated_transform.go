@@ -0,0 +1,283 @@
+package transform
+
+import (
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Transform which moves from Start at StartTime to End at EndTime. Translation, rotation and scale
+// decomposed from both transforms are interpolated, so that rotation keeps shapes rigid.
+// The transform stays at Start before StartTime and at End after EndTime.
+type AnimatedTransform struct {
+	Start, End         Transform
+	StartTime, EndTime float64
+
+	translations [2]Vector
+	rotations    [2]quaternion
+	scales       [2]Matrix
+}
+
+// Create an animated transform. Both transforms must be affine.
+func CreateAnimatedTransform(start Transform, startTime float64, end Transform, endTime float64) AnimatedTransform {
+	animated := AnimatedTransform{Start: start, End: end, StartTime: startTime, EndTime: endTime}
+
+	animated.translations[0], animated.rotations[0], animated.scales[0] = decompose(start.Matrix)
+	animated.translations[1], animated.rotations[1], animated.scales[1] = decompose(end.Matrix)
+
+	return animated
+}
+
+func (animated *AnimatedTransform) IsAnimated() bool {
+	return animated.Start.Matrix != animated.End.Matrix && animated.StartTime < animated.EndTime
+}
+
+// Return the transform at time. The animated transform must be invertible during the motion.
+func (animated *AnimatedTransform) At(time float64) Transform {
+	if !animated.IsAnimated() || time <= animated.StartTime {
+		return animated.Start
+	}
+	if animated.EndTime <= time {
+		return animated.End
+	}
+
+	s := (time - animated.StartTime) / (animated.EndTime - animated.StartTime)
+
+	translation := Add(Multiply(1.0-s, animated.translations[0]), Multiply(s, animated.translations[1]))
+	rotation := slerp(animated.rotations[0], animated.rotations[1], s).toMatrix()
+	scale := animated.scaleAt(s)
+
+	m := MultiplyMatrix(rotation, scale)
+	m[0][3] = translation.X
+	m[1][3] = translation.Y
+	m[2][3] = translation.Z
+
+	// the inverse of T R S is S^-1 R^T T^-1, which avoids elimination of the whole matrix for each ray
+	inv := MultiplyMatrix(inverse3(scale), Transpose(rotation))
+	for row := 0; row < 3; row++ {
+		inv[row][3] = -(inv[row][0]*translation.X + inv[row][1]*translation.Y + inv[row][2]*translation.Z)
+	}
+
+	return Transform{Matrix: m, Inverse: inv}
+}
+
+// Whether the transform is invertible during the whole motion. Otherwise At returns a transform whose
+// inverse is not finite at some time. The determinant of the interpolated scale is a cubic polynomial
+// of the interpolation parameter, so it is checked at both ends and at its extrema between them.
+func (animated *AnimatedTransform) IsInvertible() bool {
+	if !animated.IsAnimated() {
+		return true
+	}
+
+	a := animated.scales[0]
+	d := animated.scales[1]
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			d[i][j] -= a[i][j]
+		}
+	}
+
+	// det(A + s D) = det(A) + tr(adj(A) D) s + tr(A adj(D)) s^2 + det(D) s^3
+	c0 := a.Determinant3()
+	c1 := trace3(MultiplyMatrix(adjugate3(a), d))
+	c2 := trace3(MultiplyMatrix(a, adjugate3(d)))
+	c3 := d.Determinant3()
+
+	determinant := func(s float64) float64 {
+		return c0 + s*(c1+s*(c2+s*c3))
+	}
+
+	candidates := []float64{0.0, 1.0}
+	// roots of the derivative 3 c3 s^2 + 2 c2 s + c1
+	if c3 != 0.0 {
+		discriminant := c2*c2 - 3.0*c3*c1
+		if 0.0 <= discriminant {
+			root := math.Sqrt(discriminant)
+			candidates = append(candidates, (-c2-root)/(3.0*c3), (-c2+root)/(3.0*c3))
+		}
+	} else if c2 != 0.0 {
+		candidates = append(candidates, -c1/(2.0*c2))
+	}
+
+	tolerance := 1.0e-9 * math.Max(math.Abs(c0), math.Abs(determinant(1.0)))
+	for _, s := range candidates {
+		if s < 0.0 || 1.0 < s {
+			continue
+		}
+
+		if value := determinant(s); math.Abs(value) <= tolerance || (value < 0.0) != (c0 < 0.0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Linearly interpolate the scale matrices.
+func (animated *AnimatedTransform) scaleAt(s float64) Matrix {
+	var scale Matrix
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			scale[i][j] = (1.0-s)*animated.scales[0][i][j] + s*animated.scales[1][i][j]
+		}
+	}
+
+	return scale
+}
+
+// Adjugate of the upper left 3x3 part of a matrix, whose other elements are of the identity matrix.
+func adjugate3(m Matrix) Matrix {
+	adj := CreateIdentityMatrix()
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			i1, i2 := (i+1)%3, (i+2)%3
+			j1, j2 := (j+1)%3, (j+2)%3
+			adj[j][i] = m[i1][j1]*m[i2][j2] - m[i1][j2]*m[i2][j1]
+		}
+	}
+
+	return adj
+}
+
+// Inverse of the upper left 3x3 part of a matrix, whose other elements are of the identity matrix.
+func inverse3(m Matrix) Matrix {
+	inv := adjugate3(m)
+	determinant := m.Determinant3()
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			inv[i][j] /= determinant
+		}
+	}
+
+	return inv
+}
+
+func trace3(m Matrix) float64 {
+	return m[0][0] + m[1][1] + m[2][2]
+}
+
+// Decompose an affine matrix into translation T, rotation R and scale S where M = T R S.
+// R is found by the polar decomposition, and S can have shear and negative scale.
+func decompose(m Matrix) (Vector, quaternion, Matrix) {
+	translation := Vector{X: m[0][3], Y: m[1][3], Z: m[2][3]}
+
+	linear := m
+	linear[0][3], linear[1][3], linear[2][3] = 0.0, 0.0, 0.0
+
+	// average the matrix and its inverse transpose until it converges to a rotation
+	r := linear
+	for i := 0; i < 100; i++ {
+		inv, ok := Inverse(Transpose(r))
+		if !ok {
+			break
+		}
+
+		next := r
+		norm := 0.0
+		for row := 0; row < 3; row++ {
+			for column := 0; column < 3; column++ {
+				next[row][column] = 0.5 * (r[row][column] + inv[row][column])
+				norm = math.Max(norm, math.Abs(next[row][column]-r[row][column]))
+			}
+		}
+		r = next
+
+		if norm < 1.0e-12 {
+			break
+		}
+	}
+
+	// a reflection is left to the scale so that the rotation is proper
+	if r.Determinant3() < 0.0 {
+		for row := 0; row < 3; row++ {
+			for column := 0; column < 3; column++ {
+				r[row][column] = -r[row][column]
+			}
+		}
+	}
+
+	scale := MultiplyMatrix(Transpose(r), linear)
+
+	return translation, quaternionFromMatrix(r), scale
+}
+
+// Unit quaternion which represents a rotation.
+type quaternion struct {
+	w, x, y, z float64
+}
+
+// Convert the rotation of the upper left 3x3 part of a matrix.
+func quaternionFromMatrix(m Matrix) quaternion {
+	trace := m[0][0] + m[1][1] + m[2][2]
+
+	var q quaternion
+	switch {
+	case 0.0 < trace:
+		s := 2.0 * math.Sqrt(trace+1.0)
+		q = quaternion{w: 0.25 * s, x: (m[2][1] - m[1][2]) / s, y: (m[0][2] - m[2][0]) / s, z: (m[1][0] - m[0][1]) / s}
+	case m[1][1] < m[0][0] && m[2][2] < m[0][0]:
+		s := 2.0 * math.Sqrt(1.0+m[0][0]-m[1][1]-m[2][2])
+		q = quaternion{w: (m[2][1] - m[1][2]) / s, x: 0.25 * s, y: (m[0][1] + m[1][0]) / s, z: (m[0][2] + m[2][0]) / s}
+	case m[2][2] < m[1][1]:
+		s := 2.0 * math.Sqrt(1.0+m[1][1]-m[0][0]-m[2][2])
+		q = quaternion{w: (m[0][2] - m[2][0]) / s, x: (m[0][1] + m[1][0]) / s, y: 0.25 * s, z: (m[1][2] + m[2][1]) / s}
+	default:
+		s := 2.0 * math.Sqrt(1.0+m[2][2]-m[0][0]-m[1][1])
+		q = quaternion{w: (m[1][0] - m[0][1]) / s, x: (m[0][2] + m[2][0]) / s, y: (m[1][2] + m[2][1]) / s, z: 0.25 * s}
+	}
+
+	return q.normalize()
+}
+
+func (q quaternion) dot(other quaternion) float64 {
+	return q.w*other.w + q.x*other.x + q.y*other.y + q.z*other.z
+}
+
+func (q quaternion) normalize() quaternion {
+	length := math.Sqrt(q.dot(q))
+
+	return quaternion{w: q.w / length, x: q.x / length, y: q.y / length, z: q.z / length}
+}
+
+// Spherical linear interpolation along the shorter arc.
+func slerp(q1 quaternion, q2 quaternion, s float64) quaternion {
+	cosTheta := q1.dot(q2)
+	if cosTheta < 0.0 {
+		q2 = quaternion{w: -q2.w, x: -q2.x, y: -q2.y, z: -q2.z}
+		cosTheta = -cosTheta
+	}
+
+	a, b := 1.0-s, s
+	// nearly the same rotations are interpolated linearly to avoid division by zero
+	if cosTheta < 0.9995 {
+		theta := math.Acos(cosTheta)
+		sinTheta := math.Sin(theta)
+		a = math.Sin((1.0-s)*theta) / sinTheta
+		b = math.Sin(s*theta) / sinTheta
+	}
+
+	return quaternion{
+		w: a*q1.w + b*q2.w,
+		x: a*q1.x + b*q2.x,
+		y: a*q1.y + b*q2.y,
+		z: a*q1.z + b*q2.z,
+	}.normalize()
+}
+
+func (q quaternion) toMatrix() Matrix {
+	m := CreateIdentityMatrix()
+
+	m[0][0] = 1.0 - 2.0*(q.y*q.y+q.z*q.z)
+	m[0][1] = 2.0 * (q.x*q.y - q.w*q.z)
+	m[0][2] = 2.0 * (q.x*q.z + q.w*q.y)
+	m[1][0] = 2.0 * (q.x*q.y + q.w*q.z)
+	m[1][1] = 1.0 - 2.0*(q.x*q.x+q.z*q.z)
+	m[1][2] = 2.0 * (q.y*q.z - q.w*q.x)
+	m[2][0] = 2.0 * (q.x*q.z - q.w*q.y)
+	m[2][1] = 2.0 * (q.y*q.z + q.w*q.x)
+	m[2][2] = 1.0 - 2.0*(q.x*q.x+q.y*q.y)
+
+	return m
+}
@@ -0,0 +1,114 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	mathex "github.com/locatw/go-ray-tracer/math"
+)
+
+var epsilon float64 = mathex.Epsilon()
+
+// 4x4 matrix in row-major order.
+type Matrix [4][4]float64
+
+func CreateIdentityMatrix() Matrix {
+	return Matrix{
+		{1.0, 0.0, 0.0, 0.0},
+		{0.0, 1.0, 0.0, 0.0},
+		{0.0, 0.0, 1.0, 0.0},
+		{0.0, 0.0, 0.0, 1.0},
+	}
+}
+
+func (m Matrix) NearlyEqual(other Matrix) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 1.0e-9 < math.Abs(m[i][j]-other[i][j]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (m Matrix) String() string {
+	return fmt.Sprintf("Mat(%v, %v, %v, %v)", m[0], m[1], m[2], m[3])
+}
+
+func MultiplyMatrix(m1 Matrix, m2 Matrix) Matrix {
+	var result Matrix
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m1[i][k] * m2[k][j]
+			}
+		}
+	}
+
+	return result
+}
+
+func Transpose(m Matrix) Matrix {
+	var result Matrix
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+
+	return result
+}
+
+// Determinant of the upper left 3x3 part, which is the ratio of volumes scaled by the matrix.
+func (m Matrix) Determinant3() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// Compute the inverse matrix by Gauss-Jordan elimination with partial pivoting.
+// The second return value is false if the matrix is singular.
+func Inverse(m Matrix) (Matrix, bool) {
+	a := m
+	inv := CreateIdentityMatrix()
+
+	for column := 0; column < 4; column++ {
+		pivot := column
+		for row := column + 1; row < 4; row++ {
+			if math.Abs(a[pivot][column]) < math.Abs(a[row][column]) {
+				pivot = row
+			}
+		}
+
+		if math.Abs(a[pivot][column]) <= epsilon {
+			return Matrix{}, false
+		}
+
+		a[column], a[pivot] = a[pivot], a[column]
+		inv[column], inv[pivot] = inv[pivot], inv[column]
+
+		scale := 1.0 / a[column][column]
+		for j := 0; j < 4; j++ {
+			a[column][j] *= scale
+			inv[column][j] *= scale
+		}
+
+		for row := 0; row < 4; row++ {
+			if row == column {
+				continue
+			}
+
+			factor := a[row][column]
+			for j := 0; j < 4; j++ {
+				a[row][j] -= factor * a[column][j]
+				inv[row][j] -= factor * inv[column][j]
+			}
+		}
+	}
+
+	return inv, true
+}
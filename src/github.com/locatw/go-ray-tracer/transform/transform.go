@@ -0,0 +1,143 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Affine transform which keeps its inverse matrix.
+type Transform struct {
+	Matrix  Matrix
+	Inverse Matrix
+}
+
+func CreateIdentityTransform() Transform {
+	return Transform{Matrix: CreateIdentityMatrix(), Inverse: CreateIdentityMatrix()}
+}
+
+// Create a transform from a matrix. It panics if the matrix is not invertible.
+func CreateTransform(m Matrix) Transform {
+	inv, ok := Inverse(m)
+	if !ok {
+		panic(fmt.Sprintf("cannot create transform because matrix is singular: %v", m))
+	}
+
+	return Transform{Matrix: m, Inverse: inv}
+}
+
+func CreateTranslation(delta Vector) Transform {
+	m := CreateIdentityMatrix()
+	m[0][3] = delta.X
+	m[1][3] = delta.Y
+	m[2][3] = delta.Z
+
+	inv := CreateIdentityMatrix()
+	inv[0][3] = -delta.X
+	inv[1][3] = -delta.Y
+	inv[2][3] = -delta.Z
+
+	return Transform{Matrix: m, Inverse: inv}
+}
+
+// Create a scaling transform. It panics if any scale factor is zero.
+func CreateScaling(scale Vector) Transform {
+	if scale.X == 0.0 || scale.Y == 0.0 || scale.Z == 0.0 {
+		panic(fmt.Sprintf("cannot create scaling transform because scale has zero: %v", scale))
+	}
+
+	m := CreateIdentityMatrix()
+	m[0][0] = scale.X
+	m[1][1] = scale.Y
+	m[2][2] = scale.Z
+
+	inv := CreateIdentityMatrix()
+	inv[0][0] = 1.0 / scale.X
+	inv[1][1] = 1.0 / scale.Y
+	inv[2][2] = 1.0 / scale.Z
+
+	return Transform{Matrix: m, Inverse: inv}
+}
+
+// Create a rotation transform around an axis. angle is in radians and counterclockwise
+// when looking from the tip of the axis.
+func CreateRotation(axis Vector, angle float64) Transform {
+	a := Normalize(axis)
+	sin := math.Sin(angle)
+	cos := math.Cos(angle)
+
+	m := CreateIdentityMatrix()
+	m[0][0] = a.X*a.X + (1.0-a.X*a.X)*cos
+	m[0][1] = a.X*a.Y*(1.0-cos) - a.Z*sin
+	m[0][2] = a.X*a.Z*(1.0-cos) + a.Y*sin
+	m[1][0] = a.X*a.Y*(1.0-cos) + a.Z*sin
+	m[1][1] = a.Y*a.Y + (1.0-a.Y*a.Y)*cos
+	m[1][2] = a.Y*a.Z*(1.0-cos) - a.X*sin
+	m[2][0] = a.X*a.Z*(1.0-cos) - a.Y*sin
+	m[2][1] = a.Y*a.Z*(1.0-cos) + a.X*sin
+	m[2][2] = a.Z*a.Z + (1.0-a.Z*a.Z)*cos
+
+	// the inverse of a rotation matrix is its transpose
+	return Transform{Matrix: m, Inverse: Transpose(m)}
+}
+
+// Compose transforms so that the result applies them from left to right,
+// that is, Compose(a, b) applies a first and then b.
+func Compose(transforms ...Transform) Transform {
+	result := CreateIdentityTransform()
+
+	for _, t := range transforms {
+		result = Transform{
+			Matrix:  MultiplyMatrix(t.Matrix, result.Matrix),
+			Inverse: MultiplyMatrix(result.Inverse, t.Inverse),
+		}
+	}
+
+	return result
+}
+
+func (t Transform) Invert() Transform {
+	return Transform{Matrix: t.Inverse, Inverse: t.Matrix}
+}
+
+func (t Transform) IsIdentity() bool {
+	return t.Matrix == CreateIdentityMatrix()
+}
+
+func (t Transform) TransformPoint(p Vector) Vector {
+	m := &t.Matrix
+
+	x := m[0][0]*p.X + m[0][1]*p.Y + m[0][2]*p.Z + m[0][3]
+	y := m[1][0]*p.X + m[1][1]*p.Y + m[1][2]*p.Z + m[1][3]
+	z := m[2][0]*p.X + m[2][1]*p.Y + m[2][2]*p.Z + m[2][3]
+	w := m[3][0]*p.X + m[3][1]*p.Y + m[3][2]*p.Z + m[3][3]
+
+	if w == 1.0 {
+		return Vector{X: x, Y: y, Z: z}
+	}
+
+	return Vector{X: x / w, Y: y / w, Z: z / w}
+}
+
+func (t Transform) TransformVector(v Vector) Vector {
+	m := &t.Matrix
+
+	return Vector{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// Transform a normal by the inverse transpose matrix so that it stays perpendicular to the surface.
+// The result is not normalized.
+func (t Transform) TransformNormal(n Vector) Vector {
+	inv := &t.Inverse
+
+	return Vector{
+		X: inv[0][0]*n.X + inv[1][0]*n.Y + inv[2][0]*n.Z,
+		Y: inv[0][1]*n.X + inv[1][1]*n.Y + inv[2][1]*n.Z,
+		Z: inv[0][2]*n.X + inv[1][2]*n.Y + inv[2][2]*n.Z,
+	}
+}
@@ -0,0 +1,163 @@
+package transform
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func nearlyEqualVector(v1 Vector, v2 Vector) bool {
+	return math.Abs(v1.X-v2.X) <= 1.0e-9 && math.Abs(v1.Y-v2.Y) <= 1.0e-9 && math.Abs(v1.Z-v2.Z) <= 1.0e-9
+}
+
+func TestInverse(t *testing.T) {
+	t.Run("When a matrix is invertible", func(t *testing.T) {
+		m := Compose(
+			CreateScaling(Vector{X: 2.0, Y: 3.0, Z: 4.0}),
+			CreateRotation(Vector{X: 1.0, Y: 1.0, Z: 0.0}, 0.3),
+			CreateTranslation(Vector{X: 1.0, Y: -2.0, Z: 3.0})).Matrix
+
+		inv, ok := Inverse(m)
+
+		t.Run("it returns the inverse matrix", func(t *testing.T) {
+			if !ok {
+				t.Fatalf("got: %t, want: %t", ok, true)
+			}
+
+			result := MultiplyMatrix(m, inv)
+			if !result.NearlyEqual(CreateIdentityMatrix()) {
+				t.Errorf("got: %v, want: %v", result, CreateIdentityMatrix())
+			}
+		})
+	})
+
+	t.Run("When a matrix is singular", func(t *testing.T) {
+		m := CreateIdentityMatrix()
+		m[2][2] = 0.0
+
+		_, ok := Inverse(m)
+
+		t.Run("it returns false", func(t *testing.T) {
+			if ok {
+				t.Errorf("got: %t, want: %t", ok, false)
+			}
+		})
+	})
+}
+
+func TestCompose(t *testing.T) {
+	scaling := CreateScaling(Vector{X: 2.0, Y: 2.0, Z: 2.0})
+	translation := CreateTranslation(Vector{X: 1.0, Y: 0.0, Z: 0.0})
+	p := Vector{X: 1.0, Y: 1.0, Z: 1.0}
+
+	patterns := []struct {
+		transform Transform
+		expected  Vector
+	}{
+		{transform: Compose(scaling, translation), expected: Vector{X: 3.0, Y: 2.0, Z: 2.0}},
+		{transform: Compose(translation, scaling), expected: Vector{X: 4.0, Y: 2.0, Z: 2.0}},
+		{transform: Compose(), expected: p},
+	}
+
+	for _, pattern := range patterns {
+		result := pattern.transform.TransformPoint(p)
+		if !nearlyEqualVector(result, pattern.expected) {
+			t.Errorf("TransformPoint(%v) must return %v, actual is %v", p, pattern.expected, result)
+		}
+
+		back := pattern.transform.Invert().TransformPoint(result)
+		if !nearlyEqualVector(back, p) {
+			t.Errorf("inverse of TransformPoint(%v) must return %v, actual is %v", result, p, back)
+		}
+	}
+}
+
+func TestCreateRotation(t *testing.T) {
+	rotation := CreateRotation(CreateAxisVector(ZAxis), math.Pi/2.0)
+	v := CreateAxisVector(XAxis)
+	expected := CreateAxisVector(YAxis)
+
+	result := rotation.TransformVector(v)
+	if !nearlyEqualVector(result, expected) {
+		t.Errorf("rotation around z by pi/2 must transform %v to %v, actual is %v", v, expected, result)
+	}
+}
+
+func TestTransformVector(t *testing.T) {
+	translation := CreateTranslation(Vector{X: 1.0, Y: 2.0, Z: 3.0})
+	v := Vector{X: 1.0, Y: 0.0, Z: 0.0}
+
+	result := translation.TransformVector(v)
+	if result != v {
+		t.Errorf("TransformVector(%v) must not be affected by translation, actual is %v", v, result)
+	}
+}
+
+func TestTransformNormal(t *testing.T) {
+	// a plane x + y = 0 scaled along x
+	scaling := CreateScaling(Vector{X: 2.0, Y: 1.0, Z: 1.0})
+	n := Vector{X: 1.0, Y: 1.0, Z: 0.0}
+	tangent := Vector{X: 1.0, Y: -1.0, Z: 0.0}
+
+	transformedNormal := scaling.TransformNormal(n)
+	transformedTangent := scaling.TransformVector(tangent)
+
+	if 1.0e-9 < math.Abs(Dot(transformedNormal, transformedTangent)) {
+		t.Errorf("TransformNormal(%v) must be perpendicular to transformed tangent %v, actual is %v",
+			n, transformedTangent, transformedNormal)
+	}
+}
+
+func TestAnimatedTransformAt(t *testing.T) {
+	axis := Vector{X: 0.0, Y: 1.0, Z: 0.0}
+	start := Compose(CreateScaling(Vector{X: 2.0, Y: 2.0, Z: 2.0}), CreateTranslation(Vector{X: 1.0, Y: 0.0, Z: 0.0}))
+	end := Compose(CreateScaling(Vector{X: 4.0, Y: 4.0, Z: 4.0}), CreateRotation(axis, math.Pi/2.0), CreateTranslation(Vector{X: 3.0, Y: 0.0, Z: 0.0}))
+	animated := CreateAnimatedTransform(start, 1.0, end, 3.0)
+
+	patterns := []struct {
+		time     float64
+		expected Transform
+	}{
+		{time: 0.0, expected: start},
+		{time: 1.0, expected: start},
+		{time: 2.0, expected: Compose(CreateScaling(Vector{X: 3.0, Y: 3.0, Z: 3.0}), CreateRotation(axis, math.Pi/4.0), CreateTranslation(Vector{X: 2.0, Y: 0.0, Z: 0.0}))},
+		{time: 3.0, expected: end},
+		{time: 4.0, expected: end},
+	}
+
+	for _, pattern := range patterns {
+		result := animated.At(pattern.time)
+
+		if !result.Matrix.NearlyEqual(pattern.expected.Matrix) {
+			t.Errorf("At(%f) must return %v, actual %v", pattern.time, pattern.expected.Matrix, result.Matrix)
+		}
+		if !result.Inverse.NearlyEqual(pattern.expected.Inverse) {
+			t.Errorf("At(%f) must return inverse %v, actual %v", pattern.time, pattern.expected.Inverse, result.Inverse)
+		}
+	}
+}
+
+func TestAnimatedTransformIsInvertible(t *testing.T) {
+	axis := Vector{X: 0.0, Y: 0.0, Z: 1.0}
+
+	patterns := []struct {
+		name     string
+		end      Transform
+		expected bool
+	}{
+		{name: "translation", end: CreateTranslation(Vector{X: 1.0, Y: 2.0, Z: 3.0}), expected: true},
+		{name: "rotation", end: CreateRotation(axis, math.Pi), expected: true},
+		{name: "shrink", end: CreateScaling(Vector{X: 0.01, Y: 0.5, Z: 2.0}), expected: true},
+		{name: "mirror", end: CreateScaling(Vector{X: -1.0, Y: 1.0, Z: 1.0}), expected: false},
+		{name: "half turn by negative scales", end: Compose(CreateScaling(Vector{X: 1.0, Y: -1.0, Z: -1.0}), CreateRotation(axis, math.Pi/2.0)), expected: true},
+	}
+
+	for _, pattern := range patterns {
+		animated := CreateAnimatedTransform(CreateIdentityTransform(), 0.0, pattern.end, 1.0)
+
+		if animated.IsInvertible() != pattern.expected {
+			t.Errorf("IsInvertible() of %s must return %v, actual %v", pattern.name, pattern.expected, !pattern.expected)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package vector
+
+// Orthonormal coordinate system, whose Z axis is Normal.
+type Frame struct {
+	Tangent   Vector
+	Bitangent Vector
+	Normal    Vector
+}
+
+// Create a frame around a unit normal with arbitrary tangents.
+func CreateFrame(normal Vector) Frame {
+	tangent, bitangent := CreateOrthonormalBasis(normal)
+
+	return Frame{Tangent: tangent, Bitangent: bitangent, Normal: normal}
+}
+
+// Create a frame around a unit normal whose tangent is the direction of tangent projected onto the surface.
+// If tangent is parallel to the normal, arbitrary tangents are used.
+func CreateFrameFromTangent(normal Vector, tangent Vector) Frame {
+	projected := Subtract(tangent, Multiply(Dot(tangent, normal), normal))
+
+	length := projected.Length()
+	if length < 1e-9*tangent.Length() || length == 0.0 {
+		return CreateFrame(normal)
+	}
+
+	t := Multiply(1.0/length, projected)
+
+	return Frame{Tangent: t, Bitangent: Cross(normal, t), Normal: normal}
+}
+
+// Transform a vector in world space into the frame.
+func (frame Frame) ToLocal(v Vector) Vector {
+	return Vector{X: Dot(v, frame.Tangent), Y: Dot(v, frame.Bitangent), Z: Dot(v, frame.Normal)}
+}
+
+// Transform a vector in the frame into world space.
+func (frame Frame) ToWorld(v Vector) Vector {
+	return AddAll(Multiply(v.X, frame.Tangent), Multiply(v.Y, frame.Bitangent), Multiply(v.Z, frame.Normal))
+}
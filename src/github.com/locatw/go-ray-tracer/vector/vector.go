@@ -31,6 +31,19 @@ func (v Vector) NearlyEqual(other Vector) bool {
 		math.Abs(v.Z-other.Z) <= epsilon
 }
 
+func (v Vector) Component(axis Axis) float64 {
+	switch axis {
+	case XAxis:
+		return v.X
+	case YAxis:
+		return v.Y
+	case ZAxis:
+		return v.Z
+	default:
+		panic(fmt.Sprintf("unknown axis: %d", axis))
+	}
+}
+
 func (v Vector) String() string {
 	return fmt.Sprintf("Vec(%f, %f, %f)", v.X, v.Y, v.Z)
 }
@@ -91,3 +104,16 @@ func Cross(v1 Vector, v2 Vector) Vector {
 func Multiply(a float64, v Vector) Vector {
 	return Vector{X: a * v.X, Y: a * v.Y, Z: a * v.Z}
 }
+
+// Create two unit vectors which form an orthonormal basis with a unit vector n
+// (Duff et al., "Building an Orthonormal Basis, Revisited", 2017).
+func CreateOrthonormalBasis(n Vector) (Vector, Vector) {
+	sign := math.Copysign(1.0, n.Z)
+	a := -1.0 / (sign + n.Z)
+	b := n.X * n.Y * a
+
+	t := Vector{X: 1.0 + sign*n.X*n.X*a, Y: sign * b, Z: -sign * n.X}
+	bt := Vector{X: b, Y: sign + n.Y*n.Y*a, Z: -n.Y}
+
+	return t, bt
+}
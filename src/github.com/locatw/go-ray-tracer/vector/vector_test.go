@@ -1,6 +1,7 @@
 package vector
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -37,6 +38,25 @@ func TestVectorNearlyEqual(t *testing.T) {
 	}
 }
 
+func TestVectorComponent(t *testing.T) {
+	v := Vector{X: 1.0, Y: 2.0, Z: 3.0}
+	patterns := []struct {
+		axis     Axis
+		expected float64
+	}{
+		{axis: XAxis, expected: 1.0},
+		{axis: YAxis, expected: 2.0},
+		{axis: ZAxis, expected: 3.0},
+	}
+
+	for _, pattern := range patterns {
+		result := v.Component(pattern.axis)
+		if result != pattern.expected {
+			t.Errorf("%v.Component(%d) must return %f, actual is %f", v, pattern.axis, pattern.expected, result)
+		}
+	}
+}
+
 func TestVectorAdd(t *testing.T) {
 	v1 := Vector{X: 1.0, Y: 2.0, Z: 3.0}
 	v2 := Vector{X: 10.0, Y: 20.0, Z: 30.0}
@@ -147,6 +167,77 @@ func TestVectorMultiply(t *testing.T) {
 	}
 }
 
+func TestCreateOrthonormalBasis(t *testing.T) {
+	normals := []Vector{
+		{X: 0.0, Y: 0.0, Z: 1.0},
+		{X: 0.0, Y: 0.0, Z: -1.0},
+		{X: 1.0, Y: 0.0, Z: 0.0},
+		Normalize(Vector{X: 1.0, Y: -2.0, Z: 3.0}),
+		Normalize(Vector{X: -0.1, Y: 0.2, Z: -5.0}),
+	}
+
+	for _, n := range normals {
+		tangent, bitangent := CreateOrthonormalBasis(n)
+
+		values := []float64{
+			Dot(tangent, n), Dot(bitangent, n), Dot(tangent, bitangent),
+			tangent.Length() - 1.0, bitangent.Length() - 1.0,
+		}
+		for _, value := range values {
+			if 1e-9 < math.Abs(value) {
+				t.Errorf("CreateOrthonormalBasis(%v) must return orthonormal vectors, actual %v and %v", n, tangent, bitangent)
+				break
+			}
+		}
+
+		difference := Subtract(Cross(tangent, bitangent), n)
+		if 1e-9 < difference.Length() {
+			t.Errorf("CreateOrthonormalBasis(%v) must return a right-handed basis, actual %v and %v", n, tangent, bitangent)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	frame := CreateFrame(Normalize(Vector{X: 1.0, Y: -2.0, Z: 3.0}))
+	v := Vector{X: 0.5, Y: -1.5, Z: 2.0}
+
+	local := frame.ToLocal(v)
+	if 1e-9 < math.Abs(local.Z-Dot(v, frame.Normal)) {
+		t.Errorf("ToLocal(%v) must return the normal component as Z, actual %v", v, local)
+	}
+
+	result := frame.ToWorld(local)
+	if difference := Subtract(result, v); 1e-9 < difference.Length() {
+		t.Errorf("ToWorld(ToLocal(%v)) must return the original vector, actual %v", v, result)
+	}
+}
+
+func TestCreateFrameFromTangent(t *testing.T) {
+	normal := Vector{X: 0.0, Y: 0.0, Z: 1.0}
+
+	patterns := []struct {
+		tangent  Vector
+		expected Vector
+	}{
+		// the component along the normal is removed
+		{tangent: Vector{X: 2.0, Y: 0.0, Z: 1.0}, expected: Vector{X: 1.0, Y: 0.0, Z: 0.0}},
+		// parallel to the normal
+		{tangent: Vector{X: 0.0, Y: 0.0, Z: 2.0}, expected: CreateFrame(normal).Tangent},
+		{tangent: CreateZeroVector(), expected: CreateFrame(normal).Tangent},
+	}
+
+	for _, pattern := range patterns {
+		frame := CreateFrameFromTangent(normal, pattern.tangent)
+
+		if difference := Subtract(frame.Tangent, pattern.expected); 1e-9 < difference.Length() {
+			t.Errorf("CreateFrameFromTangent(%v, %v) must return tangent %v, actual %v", normal, pattern.tangent, pattern.expected, frame.Tangent)
+		}
+		if difference := Subtract(Cross(frame.Tangent, frame.Bitangent), normal); 1e-9 < difference.Length() {
+			t.Errorf("CreateFrameFromTangent(%v, %v) must return a right-handed frame, actual %v", normal, pattern.tangent, frame)
+		}
+	}
+}
+
 var loopCount = 1000
 
 func createVecs(count int) []Vector {
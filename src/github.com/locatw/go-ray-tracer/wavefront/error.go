@@ -0,0 +1,17 @@
+package wavefront
+
+import "fmt"
+
+type ParseError struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.Path, err.Line, err.Message)
+}
+
+func newParseError(path string, line int, format string, args ...interface{}) *ParseError {
+	return &ParseError{Path: path, Line: line, Message: fmt.Sprintf(format, args...)}
+}
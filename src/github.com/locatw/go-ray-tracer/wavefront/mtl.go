@@ -0,0 +1,189 @@
+package wavefront
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	. "github.com/locatw/go-ray-tracer/image"
+)
+
+// Illumination models of MTL which refract light.
+var refractiveIlluminationModels = map[int]bool{4: true, 6: true, 7: true, 9: true}
+
+type mtlMaterial struct {
+	material          Material
+	indexOfRefraction *float64
+	illum             int
+	dissolve          float64
+}
+
+func (m *mtlMaterial) toMaterial() Material {
+	material := m.material
+
+	if m.indexOfRefraction != nil && (refractiveIlluminationModels[m.illum] || m.dissolve < 1.0) {
+		ior := *m.indexOfRefraction
+		material.IndexOfRefraction = &ior
+	}
+
+	return material
+}
+
+// Load materials defined in an MTL file.
+// Kd, Ks and Ke are mapped to Diffuse, Specular and Emission.
+// Ni is mapped to IndexOfRefraction only if the material is transparent,
+// that is, illum is a refractive model or d is less than 1.
+func LoadMtl(path string) (map[string]Material, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return readMtl(file, path)
+}
+
+// Read materials in MTL format from reader. path is only used for error messages.
+func readMtl(reader io.Reader, path string) (map[string]Material, error) {
+	materials := make(map[string]Material)
+
+	var current *mtlMaterial
+	var currentName string
+	flush := func() {
+		if current != nil {
+			materials[currentName] = current.toMaterial()
+		}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		fields := splitLine(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		keyword := fields[0]
+		args := fields[1:]
+
+		if keyword == "newmtl" {
+			if len(args) == 0 {
+				return nil, newParseError(path, lineNumber, "newmtl requires a material name")
+			}
+
+			flush()
+
+			currentName = strings.Join(args, " ")
+			current = &mtlMaterial{material: CreateDefaultMaterial(), illum: 2, dissolve: 1.0}
+			continue
+		}
+
+		if current == nil {
+			if isMaterialStatement(keyword) {
+				return nil, newParseError(path, lineNumber, "%s appears before newmtl", keyword)
+			}
+			continue
+		}
+
+		var err error
+		switch keyword {
+		case "Kd":
+			current.material.Diffuse, err = parseColor(args)
+		case "Ks":
+			current.material.Specular, err = parseColor(args)
+		case "Ke":
+			current.material.Emission, err = parseColor(args)
+		case "Ni":
+			var ior float64
+			ior, err = parseSingleFloat(args)
+			current.indexOfRefraction = &ior
+		case "d":
+			current.dissolve, err = parseSingleFloat(args)
+		case "Tr":
+			var transparency float64
+			transparency, err = parseSingleFloat(args)
+			current.dissolve = 1.0 - transparency
+		case "illum":
+			current.illum, err = parseSingleInt(args)
+		default:
+			// other statements such as Ka, Ns and texture maps are not supported, so ignore them.
+		}
+
+		if err != nil {
+			return nil, newParseError(path, lineNumber, "invalid %s statement: %s", keyword, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	return materials, nil
+}
+
+func isMaterialStatement(keyword string) bool {
+	switch keyword {
+	case "Kd", "Ks", "Ke", "Ni", "d", "Tr", "illum":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseColor(args []string) (Color, error) {
+	if len(args) != 1 && len(args) != 3 {
+		return Color{}, fmt.Errorf("expected 1 or 3 values, got %d", len(args))
+	}
+
+	values := make([]float32, len(args))
+	for i, arg := range args {
+		value, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return Color{}, fmt.Errorf("%q is not a number", arg)
+		}
+		values[i] = float32(value)
+	}
+
+	if len(values) == 1 {
+		return Color{R: values[0], G: values[0], B: values[0]}, nil
+	}
+
+	return Color{R: values[0], G: values[1], B: values[2]}, nil
+}
+
+func parseSingleFloat(args []string) (float64, error) {
+	if len(args) != 1 {
+		return 0.0, fmt.Errorf("expected 1 value, got %d", len(args))
+	}
+
+	value, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0.0, fmt.Errorf("%q is not a number", args[0])
+	}
+
+	return value, nil
+}
+
+func parseSingleInt(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected 1 value, got %d", len(args))
+	}
+
+	value, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", args[0])
+	}
+
+	return value, nil
+}
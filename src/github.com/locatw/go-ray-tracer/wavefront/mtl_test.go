@@ -0,0 +1,89 @@
+package wavefront
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/image"
+)
+
+func TestReadMtl(t *testing.T) {
+	t.Run("When MTL has materials", func(t *testing.T) {
+		source := strings.Join([]string{
+			"# comment",
+			"newmtl red",
+			"Kd 0.75 0.25 0.25",
+			"Ks 0.1",
+			"Ns 10.0",
+			"",
+			"newmtl light",
+			"Ke 10 10 10 # emission",
+			"",
+			"newmtl glass",
+			"illum 7",
+			"Ni 1.5",
+			"",
+			"newmtl opaque",
+			"Ni 1.5",
+		}, "\n")
+
+		materials, err := readMtl(strings.NewReader(source), "test.mtl")
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it maps Kd and Ks to Diffuse and Specular", func(t *testing.T) {
+			material := materials["red"]
+			if material.Diffuse != (Color{R: 0.75, G: 0.25, B: 0.25}) {
+				t.Errorf("got: %v, want: %v", material.Diffuse, Color{R: 0.75, G: 0.25, B: 0.25})
+			}
+			if material.Specular != (Color{R: 0.1, G: 0.1, B: 0.1}) {
+				t.Errorf("got: %v, want: %v", material.Specular, Color{R: 0.1, G: 0.1, B: 0.1})
+			}
+		})
+
+		t.Run("it maps Ke to Emission", func(t *testing.T) {
+			material := materials["light"]
+			if material.Emission != (Color{R: 10.0, G: 10.0, B: 10.0}) {
+				t.Errorf("got: %v, want: %v", material.Emission, Color{R: 10.0, G: 10.0, B: 10.0})
+			}
+		})
+
+		t.Run("it maps Ni to IndexOfRefraction of a transparent material", func(t *testing.T) {
+			ior := materials["glass"].IndexOfRefraction
+			if ior == nil || *ior != 1.5 {
+				t.Errorf("got: %v, want: %f", ior, 1.5)
+			}
+		})
+
+		t.Run("it ignores Ni of an opaque material", func(t *testing.T) {
+			ior := materials["opaque"].IndexOfRefraction
+			if ior != nil {
+				t.Errorf("got: %f, want: nil", *ior)
+			}
+		})
+	})
+
+	patterns := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "When a color has invalid number", source: "newmtl a\nKd 1 x 1", expected: "test.mtl:2: invalid Kd statement: \"x\" is not a number"},
+		{name: "When a color has two values", source: "newmtl a\n\nKd 1 1", expected: "test.mtl:3: invalid Kd statement: expected 1 or 3 values, got 2"},
+		{name: "When a statement appears before newmtl", source: "Kd 1 1 1", expected: "test.mtl:1: Kd appears before newmtl"},
+		{name: "When newmtl has no name", source: "newmtl", expected: "test.mtl:1: newmtl requires a material name"},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			t.Run("it returns an error with file and line", func(t *testing.T) {
+				_, err := readMtl(strings.NewReader(pattern.source), "test.mtl")
+
+				if err == nil || err.Error() != pattern.expected {
+					t.Errorf("got: %v, want: %s", err, pattern.expected)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package wavefront
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+// Material of faces which have no usemtl statement.
+func CreateObjDefaultMaterial() Material {
+	material := CreateDefaultMaterial()
+	material.Diffuse = Color{R: 0.8, G: 0.8, B: 0.8}
+
+	return material
+}
+
+type faceVertex struct {
+	position int
+	uv       int
+	normal   int
+}
+
+type meshKey struct {
+	group    string
+	material string
+}
+
+type objParser struct {
+	path      string
+	positions []Vector
+	uvs       []UV
+	normals   []Vector
+	materials map[string]Material
+
+	group      string
+	material   string
+	meshKeys   []meshKey
+	meshFaces  map[meshKey][]*Triangle
+	loadMtlLib func(name string) (map[string]Material, error)
+}
+
+// Load triangle meshes from an OBJ file.
+// Faces are split into meshes for each pair of group and material in the order of appearance,
+// and polygons are triangulated as triangle fans, skipping triangles with zero area.
+// MTL files referenced by mtllib are resolved relative to the directory of the OBJ file.
+func LoadObj(path string) ([]*Mesh, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	dir := filepath.Dir(path)
+	loadMtlLib := func(name string) (map[string]Material, error) {
+		return LoadMtl(filepath.Join(dir, name))
+	}
+
+	return readObj(file, path, loadMtlLib)
+}
+
+func readObj(reader io.Reader, path string, loadMtlLib func(name string) (map[string]Material, error)) ([]*Mesh, error) {
+	parser := objParser{
+		path:       path,
+		materials:  make(map[string]Material),
+		meshFaces:  make(map[meshKey][]*Triangle),
+		loadMtlLib: loadMtlLib,
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		fields := splitLine(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := parser.parseStatement(fields[0], fields[1:]); err != nil {
+			if _, ok := err.(*ParseError); ok {
+				return nil, err
+			}
+			return nil, newParseError(path, lineNumber, "invalid %s statement: %s", fields[0], err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return parser.createMeshes(), nil
+}
+
+func (parser *objParser) parseStatement(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		if len(args) != 3 && len(args) != 4 {
+			return fmt.Errorf("expected 3 or 4 values, got %d", len(args))
+		}
+
+		values, err := parseFloats(args[:3])
+		if err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				return fmt.Errorf("position must be finite")
+			}
+		}
+
+		parser.positions = append(parser.positions, Vector{X: values[0], Y: values[1], Z: values[2]})
+	case "vt":
+		if len(args) < 1 || 3 < len(args) {
+			return fmt.Errorf("expected 1 to 3 values, got %d", len(args))
+		}
+
+		values, err := parseFloats(args)
+		if err != nil {
+			return err
+		}
+
+		uv := UV{U: values[0], V: 0.0}
+		if 2 <= len(values) {
+			uv.V = values[1]
+		}
+
+		parser.uvs = append(parser.uvs, uv)
+	case "vn":
+		if len(args) != 3 {
+			return fmt.Errorf("expected 3 values, got %d", len(args))
+		}
+
+		values, err := parseFloats(args)
+		if err != nil {
+			return err
+		}
+
+		normal := Vector{X: values[0], Y: values[1], Z: values[2]}
+		length := normal.Length()
+		if length == 0.0 || math.IsInf(length, 0) || math.IsNaN(length) {
+			return fmt.Errorf("normal must have a finite non-zero length")
+		}
+
+		parser.normals = append(parser.normals, Normalize(normal))
+	case "f":
+		return parser.parseFace(args)
+	case "g", "o":
+		parser.group = strings.Join(args, " ")
+	case "usemtl":
+		if len(args) == 0 {
+			return fmt.Errorf("material name is required")
+		}
+
+		name := strings.Join(args, " ")
+		if _, ok := parser.materials[name]; !ok {
+			return fmt.Errorf("undefined material %q", name)
+		}
+
+		parser.material = name
+	case "mtllib":
+		if len(args) == 0 {
+			return fmt.Errorf("file name is required")
+		}
+
+		for _, name := range args {
+			materials, err := parser.loadMtlLib(name)
+			if err != nil {
+				if _, ok := err.(*ParseError); ok {
+					return err
+				}
+				return fmt.Errorf("cannot load %q: %s", name, err)
+			}
+
+			for materialName, material := range materials {
+				parser.materials[materialName] = material
+			}
+		}
+	default:
+		// other statements such as s, l and p are not supported, so ignore them.
+	}
+
+	return nil
+}
+
+func (parser *objParser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("a face requires at least 3 vertices, got %d", len(args))
+	}
+
+	vertices := make([]faceVertex, len(args))
+	for i, arg := range args {
+		vertex, err := parser.parseFaceVertex(arg)
+		if err != nil {
+			return err
+		}
+		vertices[i] = vertex
+	}
+
+	key := meshKey{group: parser.group, material: parser.material}
+	for i := 1; i < len(vertices)-1; i++ {
+		// zero-area triangles are common in real-world files, and they can never be hit
+		if parser.isDegenerate(vertices[0], vertices[i], vertices[i+1]) {
+			continue
+		}
+
+		if _, ok := parser.meshFaces[key]; !ok {
+			parser.meshKeys = append(parser.meshKeys, key)
+		}
+
+		triangle := parser.createTriangle(vertices[0], vertices[i], vertices[i+1])
+		parser.meshFaces[key] = append(parser.meshFaces[key], triangle)
+	}
+
+	return nil
+}
+
+// Whether a triangle has no area, that is, its vertices are on a line.
+// The area is compared with the square of the size of the triangle to tolerate rounding errors.
+func (parser *objParser) isDegenerate(v0, v1, v2 faceVertex) bool {
+	e1 := Subtract(parser.positions[v1.position], parser.positions[v0.position])
+	e2 := Subtract(parser.positions[v2.position], parser.positions[v0.position])
+
+	normal := Cross(e1, e2)
+	size := math.Max(e1.Length(), e2.Length())
+
+	return normal.Length() <= 1.0e-12*size*size
+}
+
+// Parse a vertex of a face in the form of v, v/vt, v//vn or v/vt/vn.
+// The returned indices are zero-based, and -1 means that the index is omitted.
+func (parser *objParser) parseFaceVertex(arg string) (faceVertex, error) {
+	parts := strings.Split(arg, "/")
+	if 3 < len(parts) {
+		return faceVertex{}, fmt.Errorf("invalid face vertex %q", arg)
+	}
+
+	vertex := faceVertex{position: -1, uv: -1, normal: -1}
+
+	var err error
+	vertex.position, err = resolveIndex(parts[0], len(parser.positions))
+	if err != nil {
+		return faceVertex{}, err
+	}
+
+	if 2 <= len(parts) && parts[1] != "" {
+		vertex.uv, err = resolveIndex(parts[1], len(parser.uvs))
+		if err != nil {
+			return faceVertex{}, err
+		}
+	}
+
+	if 3 == len(parts) && parts[2] != "" {
+		vertex.normal, err = resolveIndex(parts[2], len(parser.normals))
+		if err != nil {
+			return faceVertex{}, err
+		}
+	}
+
+	return vertex, nil
+}
+
+// Convert a one-based or negative relative OBJ index to a zero-based index.
+func resolveIndex(value string, count int) (int, error) {
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		return -1, fmt.Errorf("%q is not an index", value)
+	}
+
+	resolved := index - 1
+	if index < 0 {
+		resolved = count + index
+	}
+
+	if index == 0 || resolved < 0 || count <= resolved {
+		return -1, fmt.Errorf("index %d is out of range", index)
+	}
+
+	return resolved, nil
+}
+
+func (parser *objParser) createTriangle(v0, v1, v2 faceVertex) *Triangle {
+	vertices := [3]faceVertex{v0, v1, v2}
+
+	triangle := &Triangle{}
+
+	hasUVs := true
+	hasNormals := true
+	for i, vertex := range vertices {
+		triangle.Vertices[i] = parser.positions[vertex.position]
+		hasUVs = hasUVs && 0 <= vertex.uv
+		hasNormals = hasNormals && 0 <= vertex.normal
+	}
+
+	if hasUVs {
+		triangle.UVs = &[3]UV{parser.uvs[v0.uv], parser.uvs[v1.uv], parser.uvs[v2.uv]}
+	}
+
+	if hasNormals {
+		triangle.Normals = &[3]Vector{parser.normals[v0.normal], parser.normals[v1.normal], parser.normals[v2.normal]}
+	}
+
+	return triangle
+}
+
+func (parser *objParser) createMeshes() []*Mesh {
+	meshes := make([]*Mesh, 0, len(parser.meshKeys))
+
+	for _, key := range parser.meshKeys {
+		material := CreateObjDefaultMaterial()
+		if key.material != "" {
+			material = parser.materials[key.material]
+		}
+
+		meshes = append(meshes, CreateMesh(key.group, parser.meshFaces[key], material))
+	}
+
+	return meshes
+}
+
+func parseFloats(args []string) ([]float64, error) {
+	values := make([]float64, len(args))
+
+	for i, arg := range args {
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", arg)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// Split a line into fields, removing a comment.
+func splitLine(line string) []string {
+	if index := strings.Index(line, "#"); 0 <= index {
+		line = line[:index]
+	}
+
+	return strings.Fields(line)
+}
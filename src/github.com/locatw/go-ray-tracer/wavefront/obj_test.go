@@ -0,0 +1,242 @@
+package wavefront
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/locatw/go-ray-tracer/element"
+	. "github.com/locatw/go-ray-tracer/image"
+	. "github.com/locatw/go-ray-tracer/vector"
+)
+
+func noMtlLib(name string) (map[string]Material, error) {
+	return nil, fmt.Errorf("no such file")
+}
+
+func TestReadObj(t *testing.T) {
+	t.Run("When OBJ has a quad with texture coordinates and normals", func(t *testing.T) {
+		source := strings.Join([]string{
+			"v 0 0 0",
+			"v 1 0 0",
+			"v 1 1 0",
+			"v 0 1 0",
+			"vt 0 0",
+			"vt 1 0",
+			"vt 1 1",
+			"vt 0 1",
+			"vn 0 0 2",
+			"f 1/1/1 2/2/1 3/3/1 4/4/1",
+		}, "\n")
+
+		meshes, err := readObj(strings.NewReader(source), "test.obj", noMtlLib)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it triangulates the quad", func(t *testing.T) {
+			if len(meshes) != 1 || len(meshes[0].Triangles) != 2 {
+				t.Fatalf("got: %v, want: a mesh with 2 triangles", meshes)
+			}
+
+			second := meshes[0].Triangles[1]
+			expected := [3]Vector{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 1, Z: 0}, {X: 0, Y: 1, Z: 0}}
+			if second.Vertices != expected {
+				t.Errorf("got: %v, want: %v", second.Vertices, expected)
+			}
+		})
+
+		t.Run("it sets per-vertex texture coordinates and normalized normals", func(t *testing.T) {
+			triangle := meshes[0].Triangles[0]
+			if triangle.UVs == nil || triangle.UVs[2] != (UV{U: 1, V: 1}) {
+				t.Errorf("got: %v, want: %v", triangle.UVs, UV{U: 1, V: 1})
+			}
+			if triangle.Normals == nil || triangle.Normals[0] != CreateAxisVector(ZAxis) {
+				t.Errorf("got: %v, want: %v", triangle.Normals, CreateAxisVector(ZAxis))
+			}
+		})
+
+		t.Run("it uses the default material", func(t *testing.T) {
+			if meshes[0].Material.Diffuse != CreateObjDefaultMaterial().Diffuse {
+				t.Errorf("got: %v, want: %v", meshes[0].Material, CreateObjDefaultMaterial())
+			}
+		})
+	})
+
+	t.Run("When OBJ has negative indices and faces without attributes", func(t *testing.T) {
+		source := "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -3 -2 -1\n"
+
+		meshes, err := readObj(strings.NewReader(source), "test.obj", noMtlLib)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it resolves indices relative to the end", func(t *testing.T) {
+			triangle := meshes[0].Triangles[0]
+			if triangle.Vertices[1] != (Vector{X: 1, Y: 0, Z: 0}) {
+				t.Errorf("got: %v, want: %v", triangle.Vertices[1], Vector{X: 1, Y: 0, Z: 0})
+			}
+			if triangle.UVs != nil || triangle.Normals != nil {
+				t.Errorf("got: %v, %v, want: nil, nil", triangle.UVs, triangle.Normals)
+			}
+		})
+	})
+
+	t.Run("When OBJ has faces with zero area", func(t *testing.T) {
+		source := strings.Join([]string{
+			"v 0 0 0", "v 1 0 0", "v 2 0 0", "v 1 1 0", "v 0 1 0",
+			// collinear vertices
+			"g line", "f 1 2 3",
+			// a quad whose first fan triangle has zero area
+			"g quad", "f 1 2 3 4",
+			// a triangle which has the same vertex twice
+			"g repeated", "f 1 4 1",
+		}, "\n")
+
+		meshes, err := readObj(strings.NewReader(source), "test.obj", noMtlLib)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it skips triangles with zero area", func(t *testing.T) {
+			if len(meshes) != 1 || meshes[0].Name != "quad" || len(meshes[0].Triangles) != 1 {
+				t.Fatalf("got: %v, want: a mesh of quad with 1 triangle", meshes)
+			}
+
+			triangle := meshes[0].Triangles[0]
+			if triangle.Vertices[1] != (Vector{X: 2, Y: 0, Z: 0}) || triangle.Vertices[2] != (Vector{X: 1, Y: 1, Z: 0}) {
+				t.Errorf("got: %v, want: the triangle of vertices 1, 3 and 4", triangle.Vertices)
+			}
+		})
+	})
+
+	t.Run("When OBJ has groups and materials", func(t *testing.T) {
+		source := strings.Join([]string{
+			"mtllib scene.mtl",
+			"v 0 0 0",
+			"v 1 0 0",
+			"v 0 1 0",
+			"g floor",
+			"usemtl white",
+			"f 1 2 3",
+			"g wall",
+			"usemtl red",
+			"f 1 2 3",
+			"g floor",
+			"usemtl white",
+			"f 3 2 1",
+		}, "\n")
+		mtlLib := func(name string) (map[string]Material, error) {
+			white := CreateDefaultMaterial()
+			white.Diffuse = CreateDefaultColor(White)
+			red := CreateDefaultMaterial()
+			red.Diffuse = Color{R: 1.0, G: 0.0, B: 0.0}
+
+			return map[string]Material{"white": white, "red": red}, nil
+		}
+
+		meshes, err := readObj(strings.NewReader(source), "test.obj", mtlLib)
+		if err != nil {
+			t.Fatalf("got: %v, want: nil", err)
+		}
+
+		t.Run("it splits faces by group and material", func(t *testing.T) {
+			if len(meshes) != 2 {
+				t.Fatalf("got: %d meshes, want: 2", len(meshes))
+			}
+
+			if meshes[0].Name != "floor" || len(meshes[0].Triangles) != 2 {
+				t.Errorf("got: %s with %d triangles, want: floor with 2 triangles", meshes[0].Name, len(meshes[0].Triangles))
+			}
+
+			if meshes[1].Name != "wall" || meshes[1].Triangles[0].Material.Diffuse != (Color{R: 1.0, G: 0.0, B: 0.0}) {
+				t.Errorf("got: %s with %v, want: wall with red material", meshes[1].Name, meshes[1].Triangles[0].Material)
+			}
+		})
+	})
+
+	patterns := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "When a vertex has invalid number", source: "v 0 a 0", expected: "test.obj:1: invalid v statement: \"a\" is not a number"},
+		{name: "When a vertex has two values", source: "v 0 0", expected: "test.obj:1: invalid v statement: expected 3 or 4 values, got 2"},
+		{name: "When a face has two vertices", source: "v 0 0 0\nv 1 0 0\nf 1 2", expected: "test.obj:3: invalid f statement: a face requires at least 3 vertices, got 2"},
+		{name: "When a face refers an undefined vertex", source: "v 0 0 0\nv 1 0 0\n\nf 1 2 3", expected: "test.obj:4: invalid f statement: index 3 is out of range"},
+		{name: "When a face has zero index", source: "v 0 0 0\nf 0 1 1", expected: "test.obj:2: invalid f statement: index 0 is out of range"},
+		{name: "When a face refers an undefined normal", source: "v 0 0 0\nf 1//1 1//1 1//1", expected: "test.obj:2: invalid f statement: index 1 is out of range"},
+		{name: "When a normal has zero length", source: "vn 0 0 0", expected: "test.obj:1: invalid vn statement: normal must have a finite non-zero length"},
+		{name: "When a normal is not finite", source: "vn 0 inf 0", expected: "test.obj:1: invalid vn statement: normal must have a finite non-zero length"},
+		{name: "When a vertex is not finite", source: "v 0 0 0\nv 0 nan 0", expected: "test.obj:2: invalid v statement: position must be finite"},
+		{name: "When a vertex is infinite", source: "v -inf 0 0", expected: "test.obj:1: invalid v statement: position must be finite"},
+		{name: "When usemtl refers an undefined material", source: "usemtl missing", expected: "test.obj:1: invalid usemtl statement: undefined material \"missing\""},
+		{name: "When mtllib cannot be loaded", source: "mtllib missing.mtl", expected: "test.obj:1: invalid mtllib statement: cannot load \"missing.mtl\": no such file"},
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern.name, func(t *testing.T) {
+			t.Run("it returns an error with file and line", func(t *testing.T) {
+				_, err := readObj(strings.NewReader(pattern.source), "test.obj", noMtlLib)
+
+				if err == nil || err.Error() != pattern.expected {
+					t.Errorf("got: %v, want: %s", err, pattern.expected)
+				}
+			})
+		})
+	}
+}
+
+func TestLoadObj(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "wavefront")
+	if err != nil {
+		t.Fatalf("cannot create temp directory for test")
+	}
+
+	defer os.RemoveAll(dir)
+
+	obj := "mtllib box.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl light\nf 1 2 3\n"
+	mtl := "newmtl light\nKe 1 1 1\nKd 0 0 0\nnewmtl broken\nKs 1 2\n"
+
+	err = ioutil.WriteFile(filepath.Join(dir, "box.obj"), []byte(obj), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "box.mtl"), []byte(mtl), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("When MTL referenced from OBJ is malformed", func(t *testing.T) {
+		_, err := LoadObj(filepath.Join(dir, "box.obj"))
+
+		t.Run("it returns an error with the MTL file and line", func(t *testing.T) {
+			expected := filepath.Join(dir, "box.mtl") + ":5: invalid Ks statement: expected 1 or 3 values, got 2"
+			if err == nil || err.Error() != expected {
+				t.Errorf("got: %v, want: %s", err, expected)
+			}
+		})
+	})
+
+	t.Run("When MTL referenced from OBJ is valid", func(t *testing.T) {
+		err = ioutil.WriteFile(filepath.Join(dir, "box.mtl"), []byte("newmtl light\nKe 1 1 1\nKd 0 0 0\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		meshes, err := LoadObj(filepath.Join(dir, "box.obj"))
+
+		t.Run("it loads the MTL relative to the OBJ", func(t *testing.T) {
+			if err != nil {
+				t.Fatalf("got: %v, want: nil", err)
+			}
+
+			if meshes[0].Material.Emission != CreateDefaultColor(White) {
+				t.Errorf("got: %v, want: %v", meshes[0].Material.Emission, CreateDefaultColor(White))
+			}
+		})
+	})
+}